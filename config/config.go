@@ -0,0 +1,379 @@
+// Package config loads network profiles describing the live chains a test
+// suite runs against, so the same tests can target another testnet by
+// switching a single flag.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	host "github.com/cosmos/ibc-go/v7/modules/core/24-host"
+	"github.com/decentrio/e2e-testing-live/cosmos"
+	"github.com/decentrio/e2e-testing-live/cosmos/hub"
+	"github.com/decentrio/e2e-testing-live/cosmos/rollapp"
+	"github.com/pelletier/go-toml/v2"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultPort is the IBC port assumed when a channel pair omits it.
+const DefaultPort = "transfer"
+
+// profileExtensions lists the file extensions LoadProfile looks for, in order.
+var profileExtensions = []string{".yaml", ".yml", ".json", ".toml"}
+
+// ChainConfig is the on-disk description of a single chain.
+type ChainConfig struct {
+	ChainID     string `json:"chain_id" yaml:"chain_id" toml:"chain_id"`
+	RPCAddr     string `json:"rpc_addr" yaml:"rpc_addr" toml:"rpc_addr"`
+	JsonRPCAddr string `json:"json_rpc_addr" yaml:"json_rpc_addr" toml:"json_rpc_addr"`
+	GrpcAddr    string `json:"grpc_addr" yaml:"grpc_addr" toml:"grpc_addr"`
+	// GrpcTLS is one of insecure, tls or skip-verify. When empty, TLS is
+	// used for https addresses and port 443.
+	GrpcTLS       string `json:"grpc_tls" yaml:"grpc_tls" toml:"grpc_tls"`
+	Bin           string `json:"bin" yaml:"bin" toml:"bin"`
+	GasPrices     string `json:"gas_prices" yaml:"gas_prices" toml:"gas_prices"`
+	GasAdjustment string `json:"gas_adjustment" yaml:"gas_adjustment" toml:"gas_adjustment"`
+	Denom         string `json:"denom" yaml:"denom" toml:"denom"`
+	Fees          string `json:"fees" yaml:"fees" toml:"fees"`
+	Bech32Prefix  string `json:"bech32_prefix" yaml:"bech32_prefix" toml:"bech32_prefix"`
+	FaucetURL     string `json:"faucet_url" yaml:"faucet_url" toml:"faucet_url"`
+	// FaucetBody is the JSON body of faucet requests, see cosmos.Faucet.
+	FaucetBody string `json:"faucet_body" yaml:"faucet_body" toml:"faucet_body"`
+	// FaucetAmount is the coin one faucet request grants, e.g. 1000000adym.
+	FaucetAmount string `json:"faucet_amount" yaml:"faucet_amount" toml:"faucet_amount"`
+	// TreasuryKey is a funded key sending FaucetAmount when the faucet is unavailable.
+	TreasuryKey string `json:"treasury_key" yaml:"treasury_key" toml:"treasury_key"`
+	// BroadcastMode is one of commit (default), sync or async.
+	BroadcastMode string `json:"broadcast_mode" yaml:"broadcast_mode" toml:"broadcast_mode"`
+	// KeyringHome is the home directory of the binary's test keyring, e.g. a
+	// CI directory holding pre-funded keys. The binary's default if empty.
+	KeyringHome string `json:"keyring_home" yaml:"keyring_home" toml:"keyring_home"`
+	// KeyAlgo and HDPath select how keys are derived, e.g. eth_secp256k1 and
+	// m/44'/60'/0'/0/0. KeyAlgo defaults to eth_secp256k1 for evm rollapps.
+	KeyAlgo string `json:"key_algo" yaml:"key_algo" toml:"key_algo"`
+	HDPath  string `json:"hd_path" yaml:"hd_path" toml:"hd_path"`
+
+	// The fields below only apply to rollapps.
+
+	// Hub is the name, within the network, of the hub the rollapp settles on.
+	Hub string `json:"hub" yaml:"hub" toml:"hub"`
+	// RollappID is the name the rollapp is registered with on the hub, the chain ID if empty.
+	RollappID string `json:"rollapp_id" yaml:"rollapp_id" toml:"rollapp_id"`
+	// VM is evm or wasm.
+	VM string `json:"vm" yaml:"vm" toml:"vm"`
+}
+
+// CosmosChain converts the config into a chain ready to be used by tests.
+// The RPC client is not created; call NewClient on the result.
+func (c ChainConfig) CosmosChain() cosmos.CosmosChain {
+	return cosmos.CosmosChain{
+		RPCAddr:       c.RPCAddr,
+		JsonRPCAddr:   c.JsonRPCAddr,
+		GrpcAddr:      c.GrpcAddr,
+		GrpcTLS:       cosmos.GRPCTLS(c.GrpcTLS),
+		ChainID:       c.ChainID,
+		Bin:           c.Bin,
+		GasPrices:     c.GasPrices,
+		GasAdjustment: c.GasAdjustment,
+		Denom:         c.Denom,
+		Fees:          c.Fees,
+		Bech32Prefix:  c.Bech32Prefix,
+		BroadcastMode: cosmos.BroadcastMode(c.BroadcastMode),
+		KeyringHome:   c.KeyringHome,
+		KeyAlgo:       c.keyAlgo(),
+		HDPath:        c.HDPath,
+	}
+}
+
+func (c ChainConfig) keyAlgo() string {
+	if c.KeyAlgo == "" && rollapp.VM(c.VM) == rollapp.VMEVM {
+		return "eth_secp256k1"
+	}
+	return c.KeyAlgo
+}
+
+// Validate returns an error if a field required to talk to the chain is missing.
+func (c ChainConfig) Validate() error {
+	var errs []error
+	required := []struct{ name, value string }{
+		{"chain_id", c.ChainID},
+		{"rpc_addr", c.RPCAddr},
+		{"grpc_addr", c.GrpcAddr},
+		{"bin", c.Bin},
+		{"denom", c.Denom},
+	}
+	for _, f := range required {
+		if strings.TrimSpace(f.value) == "" {
+			errs = append(errs, fmt.Errorf("%s is required", f.name))
+		}
+	}
+	switch cosmos.BroadcastMode(c.BroadcastMode) {
+	case "", cosmos.BroadcastCommit, cosmos.BroadcastSync, cosmos.BroadcastAsync:
+	default:
+		errs = append(errs, fmt.Errorf("unknown broadcast_mode %q", c.BroadcastMode))
+	}
+	switch cosmos.GRPCTLS(c.GrpcTLS) {
+	case cosmos.GRPCTLSAuto, cosmos.GRPCTLSInsecure, cosmos.GRPCTLSEnabled, cosmos.GRPCTLSSkipVerify:
+	default:
+		errs = append(errs, fmt.Errorf("unknown grpc_tls %q", c.GrpcTLS))
+	}
+	if c.FaucetAmount != "" {
+		if _, err := sdk.ParseCoinNormalized(c.FaucetAmount); err != nil {
+			errs = append(errs, fmt.Errorf("faucet_amount: %w", err))
+		}
+	}
+	if c.TreasuryKey != "" && c.FaucetAmount == "" {
+		errs = append(errs, errors.New("treasury_key requires faucet_amount"))
+	}
+	switch c.KeyAlgo {
+	case "", "secp256k1", "eth_secp256k1":
+	default:
+		errs = append(errs, fmt.Errorf("unknown key_algo %q", c.KeyAlgo))
+	}
+	if c.HDPath != "" {
+		if _, err := hd.NewParamsFromPath(c.HDPath); err != nil {
+			errs = append(errs, fmt.Errorf("hd_path: %w", err))
+		}
+	}
+	switch rollapp.VM(c.VM) {
+	case "", rollapp.VMEVM, rollapp.VMWasm:
+	default:
+		errs = append(errs, fmt.Errorf("unknown vm %q", c.VM))
+	}
+	return errors.Join(errs...)
+}
+
+// ChannelPair is an IBC channel between two chains of the network, referenced by name.
+type ChannelPair struct {
+	ChainA   string `json:"chain_a" yaml:"chain_a" toml:"chain_a"`
+	ChainB   string `json:"chain_b" yaml:"chain_b" toml:"chain_b"`
+	ChannelA string `json:"channel_a" yaml:"channel_a" toml:"channel_a"`
+	ChannelB string `json:"channel_b" yaml:"channel_b" toml:"channel_b"`
+	PortA    string `json:"port_a" yaml:"port_a" toml:"port_a"`
+	PortB    string `json:"port_b" yaml:"port_b" toml:"port_b"`
+}
+
+// Reverse returns the same channel pair seen from ChainB.
+func (p ChannelPair) Reverse() ChannelPair {
+	return ChannelPair{
+		ChainA:   p.ChainB,
+		ChainB:   p.ChainA,
+		ChannelA: p.ChannelB,
+		ChannelB: p.ChannelA,
+		PortA:    p.PortB,
+		PortB:    p.PortA,
+	}
+}
+
+// Network is a named set of chains and the IBC channels linking them.
+type Network struct {
+	Name     string                 `json:"name" yaml:"name" toml:"name"`
+	Chains   map[string]ChainConfig `json:"chains" yaml:"chains" toml:"chains"`
+	Channels []ChannelPair          `json:"channels" yaml:"channels" toml:"channels"`
+	// Logger is given to every chain the network returns, see
+	// cosmos.CosmosChain.Logger.
+	Logger *zap.Logger `json:"-" yaml:"-" toml:"-"`
+}
+
+// Chain returns the chain registered under name.
+func (n *Network) Chain(name string) (cosmos.CosmosChain, error) {
+	cfg, ok := n.Chains[name]
+	if !ok {
+		return cosmos.CosmosChain{}, fmt.Errorf("network %q has no chain %q", n.Name, name)
+	}
+	chain := cfg.CosmosChain()
+	chain.Logger = n.Logger
+	return chain, nil
+}
+
+// Hub returns the chain registered under name as a Dymension hub.
+func (n *Network) Hub(name string) (*hub.Hub, error) {
+	chain, err := n.Chain(name)
+	if err != nil {
+		return nil, err
+	}
+	return hub.NewHub(chain), nil
+}
+
+// Rollapp returns the chain registered under name as a rollapp settling on h.
+// h must be the hub the profile assigns to the rollapp, if any.
+func (n *Network) Rollapp(name string, h *hub.Hub) (*rollapp.Rollapp, error) {
+	chain, err := n.Chain(name)
+	if err != nil {
+		return nil, err
+	}
+	cfg := n.Chains[name]
+	if cfg.VM == "" {
+		return nil, fmt.Errorf("chain %q has no vm, it is not a rollapp", name)
+	}
+	if cfg.Hub != "" && h != nil && n.Chains[cfg.Hub].ChainID != h.ChainID {
+		return nil, fmt.Errorf("rollapp %q settles on %q, not on %s", name, cfg.Hub, h.ChainID)
+	}
+	return rollapp.NewRollapp(chain, cfg.RollappID, rollapp.VM(cfg.VM), h), nil
+}
+
+// Faucet returns the faucet funding accounts of the chain registered under name.
+func (n *Network) Faucet(name string) (*cosmos.Faucet, error) {
+	chain, err := n.Chain(name)
+	if err != nil {
+		return nil, err
+	}
+	cfg := n.Chains[name]
+	if cfg.FaucetURL == "" && cfg.TreasuryKey == "" {
+		return nil, fmt.Errorf("chain %q has neither a faucet_url nor a treasury_key", name)
+	}
+	faucet := chain.NewFaucet(cfg.FaucetURL)
+	faucet.Body = cfg.FaucetBody
+	faucet.TreasuryKey = cfg.TreasuryKey
+	if cfg.FaucetAmount != "" {
+		if faucet.Amount, err = sdk.ParseCoinNormalized(cfg.FaucetAmount); err != nil {
+			return nil, fmt.Errorf("chain %q: faucet_amount: %w", name, err)
+		}
+	}
+	return faucet, nil
+}
+
+// Channel returns the channel pair between src and dst, oriented so that
+// ChainA is src.
+func (n *Network) Channel(src, dst string) (ChannelPair, error) {
+	for _, p := range n.Channels {
+		switch {
+		case p.ChainA == src && p.ChainB == dst:
+			return p, nil
+		case p.ChainA == dst && p.ChainB == src:
+			return p.Reverse(), nil
+		}
+	}
+	return ChannelPair{}, fmt.Errorf("network %q has no channel between %q and %q", n.Name, src, dst)
+}
+
+// Validate checks that every chain has its required fields and that every
+// channel pair references known chains with well-formed identifiers.
+func (n *Network) Validate() error {
+	var errs []error
+	if len(n.Chains) == 0 {
+		errs = append(errs, errors.New("no chains defined"))
+	}
+	for _, name := range n.chainNames() {
+		if err := n.Chains[name].Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("chain %q: %w", name, err))
+		}
+	}
+	for _, name := range n.chainNames() {
+		if h := n.Chains[name].Hub; h != "" {
+			if _, ok := n.Chains[h]; !ok {
+				errs = append(errs, fmt.Errorf("chain %q: unknown hub %q", name, h))
+			}
+		}
+	}
+	for i, p := range n.Channels {
+		if _, ok := n.Chains[p.ChainA]; !ok {
+			errs = append(errs, fmt.Errorf("channels[%d]: unknown chain_a %q", i, p.ChainA))
+		}
+		if _, ok := n.Chains[p.ChainB]; !ok {
+			errs = append(errs, fmt.Errorf("channels[%d]: unknown chain_b %q", i, p.ChainB))
+		}
+		if err := host.ChannelIdentifierValidator(p.ChannelA); err != nil {
+			errs = append(errs, fmt.Errorf("channels[%d]: channel_a: %w", i, err))
+		}
+		if err := host.ChannelIdentifierValidator(p.ChannelB); err != nil {
+			errs = append(errs, fmt.Errorf("channels[%d]: channel_b: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (n *Network) chainNames() []string {
+	names := make([]string, 0, len(n.Chains))
+	for name := range n.Chains {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (n *Network) setDefaults() {
+	for i := range n.Channels {
+		if n.Channels[i].PortA == "" {
+			n.Channels[i].PortA = DefaultPort
+		}
+		if n.Channels[i].PortB == "" {
+			n.Channels[i].PortB = DefaultPort
+		}
+	}
+}
+
+// LoadProfile loads the profile called name from dir, trying each supported
+// extension in turn (for example "blumbus" resolves to dir/blumbus.yaml).
+func LoadProfile(dir, name string) (*Network, error) {
+	for _, ext := range profileExtensions {
+		path := filepath.Join(dir, name+ext)
+		if _, err := os.Stat(path); err == nil {
+			return Load(path)
+		}
+	}
+	return nil, fmt.Errorf("network profile %q not found in %s", name, dir)
+}
+
+// Load reads a network profile from path. The format is chosen from the file
+// extension (.yaml, .yml, .json or .toml). Environment overrides are applied
+// before the result is validated; see ApplyEnv.
+func Load(path string) (*Network, error) {
+	bz, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	n, err := Parse(bz, filepath.Ext(path))
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if n.Name == "" {
+		n.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if err := ApplyEnv(n, os.LookupEnv); err != nil {
+		return nil, err
+	}
+	if err := n.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid network profile %s: %w", path, err)
+	}
+	return n, nil
+}
+
+// Parse decodes a network profile in the format given by ext. Unknown fields
+// are rejected so that typos do not silently fall back to empty values.
+func Parse(bz []byte, ext string) (*Network, error) {
+	var n Network
+	switch strings.ToLower(ext) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(bz))
+		dec.KnownFields(true)
+		if err := dec.Decode(&n); err != nil {
+			return nil, err
+		}
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(bz))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&n); err != nil {
+			return nil, err
+		}
+	case ".toml":
+		dec := toml.NewDecoder(bytes.NewReader(bz))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&n); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported network profile format %q", ext)
+	}
+	n.setDefaults()
+	return &n, nil
+}
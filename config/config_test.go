@@ -0,0 +1,151 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const yamlProfile = `
+chains:
+  hub:
+    chain_id: hub_1-1
+    rpc_addr: rpc.hub:443
+    grpc_addr: grpc.hub:9090
+    bin: dymd
+    denom: adym
+  ra:
+    chain_id: ra_2-1
+    rpc_addr: rpc.ra:443
+    grpc_addr: grpc.ra:9090
+    bin: rollapp-evm
+    denom: ara
+channels:
+  - chain_a: hub
+    chain_b: ra
+    channel_a: channel-3
+    channel_b: channel-0
+`
+
+func TestParseFormats(t *testing.T) {
+	const jsonProfile = `{"chains":{"hub":{"chain_id":"hub_1-1","rpc_addr":"rpc.hub:443","grpc_addr":"grpc.hub:9090","bin":"dymd","denom":"adym"}}}`
+	const tomlProfile = `
+[chains.hub]
+chain_id = "hub_1-1"
+rpc_addr = "rpc.hub:443"
+grpc_addr = "grpc.hub:9090"
+bin = "dymd"
+denom = "adym"
+`
+	for ext, profile := range map[string]string{".yaml": yamlProfile, ".json": jsonProfile, ".toml": tomlProfile} {
+		n, err := Parse([]byte(profile), ext)
+		require.NoError(t, err, ext)
+		require.NoError(t, n.Validate(), ext)
+		hub, err := n.Chain("hub")
+		require.NoError(t, err, ext)
+		require.Equal(t, "hub_1-1", hub.ChainID, ext)
+		require.Equal(t, "dymd", hub.Bin, ext)
+	}
+
+	_, err := Parse([]byte(`{"chains":{"hub":{"chainid":"x"}}}`), ".json")
+	require.Error(t, err, "unknown fields must be rejected")
+}
+
+func TestChannelOrientation(t *testing.T) {
+	n, err := Parse([]byte(yamlProfile), ".yaml")
+	require.NoError(t, err)
+
+	p, err := n.Channel("hub", "ra")
+	require.NoError(t, err)
+	require.Equal(t, "channel-3", p.ChannelA)
+	require.Equal(t, DefaultPort, p.PortA)
+
+	p, err = n.Channel("ra", "hub")
+	require.NoError(t, err)
+	require.Equal(t, "ra", p.ChainA)
+	require.Equal(t, "channel-0", p.ChannelA)
+	require.Equal(t, "channel-3", p.ChannelB)
+
+	_, err = n.Channel("hub", "other")
+	require.Error(t, err)
+}
+
+func TestApplyEnvAndValidate(t *testing.T) {
+	n, err := Parse([]byte(yamlProfile), ".yaml")
+	require.NoError(t, err)
+
+	env := map[string]string{
+		"E2E_HUB_RPC_ADDR": "http://localhost:26657",
+		"E2E_RA_BIN":       "",
+		"E2E_RA_GRPC_TLS":  "always",
+		"E2E_RA_HD_PATH":   "m/44'/60'",
+	}
+	require.NoError(t, ApplyEnv(n, func(k string) (string, bool) {
+		v, ok := env[k]
+		return v, ok
+	}))
+	require.Equal(t, "http://localhost:26657", n.Chains["hub"].RPCAddr)
+	err = n.Validate()
+	require.ErrorContains(t, err, `chain "ra": bin is required`)
+	require.ErrorContains(t, err, `unknown grpc_tls "always"`)
+	require.ErrorContains(t, err, "hd_path")
+}
+
+func TestLoadProfiles(t *testing.T) {
+	for _, name := range []string{"blumbus", "local"} {
+		n, err := LoadProfile("../networks", name)
+		require.NoError(t, err, name)
+		require.Equal(t, name, n.Name)
+	}
+}
+
+func TestNetworkRollapp(t *testing.T) {
+	n, err := LoadProfile("../networks", "blumbus")
+	require.NoError(t, err)
+
+	h, err := n.Hub("hub")
+	require.NoError(t, err)
+	x, err := n.Rollapp("rollappx", h)
+	require.NoError(t, err)
+	require.Equal(t, "rolx_100004-1", x.Name)
+	require.Same(t, h, x.Hub)
+	require.Equal(t, "eth_secp256k1", x.KeyAlgo)
+	require.Empty(t, h.KeyAlgo)
+	_, err = x.EVM()
+	require.NoError(t, err)
+
+	_, err = n.Rollapp("hub", h)
+	require.ErrorContains(t, err, "not a rollapp")
+
+	other, err := n.Hub("rollappy")
+	require.NoError(t, err)
+	_, err = n.Rollapp("rollappx", other)
+	require.ErrorContains(t, err, "settles on")
+}
+
+func TestNetworkFaucet(t *testing.T) {
+	n, err := Parse([]byte(yamlProfile), ".yaml")
+	require.NoError(t, err)
+	_, err = n.Faucet("hub")
+	require.ErrorContains(t, err, "neither a faucet_url nor a treasury_key")
+
+	hub := n.Chains["hub"]
+	hub.FaucetURL = "http://faucet.hub/api/get-dym"
+	hub.FaucetAmount = "1000adym"
+	hub.TreasuryKey = "treasury"
+	n.Chains["hub"] = hub
+	require.NoError(t, n.Validate())
+	n.Logger = zap.NewExample()
+	faucet, err := n.Faucet("hub")
+	require.NoError(t, err)
+	require.Equal(t, "http://faucet.hub/api/get-dym", faucet.URL)
+	require.Equal(t, "1000adym", faucet.Amount.String())
+	require.Equal(t, "treasury", faucet.TreasuryKey)
+	require.Equal(t, "hub_1-1", faucet.Chain.ChainID)
+	require.Same(t, n.Logger, faucet.Chain.Logger)
+
+	hub.FaucetAmount = ""
+	n.Chains["hub"] = hub
+	require.ErrorContains(t, n.Validate(), "treasury_key requires faucet_amount")
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// EnvPrefix prefixes every environment variable read by ApplyEnv.
+const EnvPrefix = "E2E_"
+
+// EnvKey returns the environment variable overriding field (its json name,
+// e.g. "rpc_addr") of the chain registered under chainName. For a chain named
+// "hub" and the field "rpc_addr" this is E2E_HUB_RPC_ADDR.
+func EnvKey(chainName, field string) string {
+	return EnvPrefix + envSegment(chainName) + "_" + envSegment(field)
+}
+
+// ApplyEnv overrides single chain fields from the environment, looked up via
+// lookup (normally os.LookupEnv). Variables that are set but empty clear the field.
+func ApplyEnv(n *Network, lookup func(string) (string, bool)) error {
+	for _, name := range n.chainNames() {
+		cfg := n.Chains[name]
+		v := reflect.ValueOf(&cfg).Elem()
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+			if field == "" || field == "-" {
+				continue
+			}
+			val, ok := lookup(EnvKey(name, field))
+			if !ok {
+				continue
+			}
+			if v.Field(i).Kind() != reflect.String {
+				return fmt.Errorf("cannot override non-string field %s of chain %q", field, name)
+			}
+			v.Field(i).SetString(val)
+		}
+		n.Chains[name] = cfg
+	}
+	return nil
+}
+
+func envSegment(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, s)
+}
@@ -1,40 +1,50 @@
 package cosmos
 
 import (
-	"bytes"
 	"context"
 	"encoding/hex"
-	"encoding/json"
 	"fmt"
-	"io"
 	"math/big"
 	"net/http"
 	"regexp"
 	"strings"
+	"sync"
 
 	sdkmath "cosmossdk.io/math"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	bankTypes "github.com/cosmos/cosmos-sdk/x/bank/types"
-	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"github.com/ethereum/go-ethereum/rpc"
+	"go.uber.org/zap"
 )
 
 type User struct {
-	Address string `json:"address"`
-	Denom   string `json:"denom"`
+	// KeyName is the name of the user's key in the chain binary's keyring.
+	KeyName string `json:"key_name"`
+	// Address is the bech32 address, HexAddress the same account in 0x form.
+	Address    string `json:"address"`
+	HexAddress string `json:"hex_address"`
+	// PubKey is the public key as printed by the binary, a JSON-encoded Any
+	// accepted by its --pubkey flags.
+	PubKey string `json:"pubkey"`
+	// Mnemonic recovers the key. It is only known for keys created or
+	// recovered by the tests.
+	Mnemonic string `json:"mnemonic,omitempty"`
+	Denom    string `json:"denom"`
+	// Logger receives the user's balance queries, DefaultLogger if nil. Users
+	// returned by a chain share its logger.
+	Logger *zap.Logger `json:"-"`
 }
 
-// GetBalance fetches the current balance for a specific account address and denom.
-func (user *User) GetBalance(ctx context.Context, denom, grpcAddr string) (sdkmath.Int, error) {
+// GetBalance fetches the current balance for a specific account address and
+// denom over the chain's shared gRPC connection, see CosmosChain.GRPCConn.
+func (user *User) GetBalance(ctx context.Context, denom string, chain CosmosChain) (sdkmath.Int, error) {
 	params := &bankTypes.QueryBalanceRequest{Address: user.Address, Denom: denom}
-	conn, err := grpc.NewClient(grpcAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := chain.GRPCConn()
 	if err != nil {
 		return sdkmath.Int{}, err
 	}
-	defer conn.Close()
 
 	queryClient := bankTypes.NewQueryClient(conn)
 	res, err := queryClient.Balance(ctx, params)
@@ -43,50 +53,10 @@ func (user *User) GetBalance(ctx context.Context, denom, grpcAddr string) (sdkma
 		return sdkmath.Int{}, err
 	}
 
+	user.log().Debug("balance", zap.String("address", user.Address), zap.Stringer("balance", res.Balance))
 	return res.Balance.Amount, nil
 }
 
-func (user *User) GetFaucet(api string) {
-	// Data to send in the POST request
-	data := map[string]string{
-		"address": user.Address,
-	}
-	jsonData, err := json.Marshal(data)
-	if err != nil {
-		fmt.Println("Error marshalling JSON:", err)
-		return
-	}
-
-	// Create a new POST request
-	req, err := http.NewRequest("POST", api, bytes.NewBuffer(jsonData))
-	if err != nil {
-		fmt.Println("Error creating request:", err)
-		return
-	}
-
-	// Set the request header to indicate that we're sending JSON data
-	req.Header.Set("Content-Type", "application/json")
-
-	// Create an HTTP client and send the request
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		fmt.Println("Error sending request:", err)
-		return
-	}
-	defer resp.Body.Close()
-
-	// Read the response
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		fmt.Println("Error reading response body:", err)
-		return
-	}
-
-	fmt.Println("Response Status:", resp.Status)
-	fmt.Println("Response Body:", string(body))
-}
-
 func GetEvmAddressFromAnyFormatAddress(addrs ...string) (evmAddrs []common.Address, err error) {
 	for _, addr := range addrs {
 		normalizedAddr := strings.ToLower(addr)
@@ -122,29 +92,72 @@ func GetEvmAddressFromAnyFormatAddress(addrs ...string) (evmAddrs []common.Addre
 	return
 }
 
+// GetERC20Balance returns the user's balance of the ERC20 contract at
+// height, the latest block when height is not positive.
 func (user *User) GetERC20Balance(jsonrpc, erc20Contract string, height int64) (*big.Int, error) {
+	ctx := context.Background()
+	var blockNumber *big.Int
+	if height > 0 {
+		blockNumber = big.NewInt(height)
+	}
 
-	contextHeight := big.NewInt(height)
+	erc20, err := NewERC20(ctx, jsonrpc, erc20Contract)
+	if err != nil {
+		return nil, err
+	}
+	tokenBalance, err := erc20.BalanceOf(ctx, user.Address, blockNumber)
+	if err != nil {
+		return nil, err
+	}
 
-	evmAddrs, err := GetEvmAddressFromAnyFormatAddress(user.Address, erc20Contract)
-	fmt.Println(err)
-	accountAddr := evmAddrs[0]
-	contractAddr := evmAddrs[1]
+	user.log().Debug("erc20 balance", zap.String("address", user.Address), zap.String("contract", erc20Contract), zap.Stringer("balance", tokenBalance))
+	return tokenBalance, nil
+}
 
-	ethClient8545, err := ethclient.Dial(jsonrpc)
-	if err != nil {
-		fmt.Println("Failed to connect to EVM Json-RPC:", err)
-		return big.NewInt(0), err
+// ethClients shares one JSON-RPC client per url, like grpcConns.
+var ethClients = struct {
+	sync.Mutex
+	clients map[string]*ethclient.Client
+}{clients: make(map[string]*ethclient.Client)}
+
+// EthClient returns the JSON-RPC client of url, dialed with DialEthClient on
+// first use and shared afterwards. It must not be closed by the caller; see
+// CloseEthClients.
+func EthClient(ctx context.Context, url string) (*ethclient.Client, error) {
+	ethClients.Lock()
+	defer ethClients.Unlock()
+	if client, ok := ethClients.clients[url]; ok {
+		return client, nil
 	}
-	bz, err := ethClient8545.CallContract(context.Background(), ethereum.CallMsg{
-		To:   &contractAddr,
-		Data: append([]byte{0x70, 0xa0, 0x82, 0x31}, common.BytesToHash(accountAddr.Bytes()).Bytes()...), // balanceOf(address)
-	}, contextHeight)
+	client, err := DialEthClient(ctx, url)
 	if err != nil {
-		return big.NewInt(0), err
+		return nil, fmt.Errorf("connect to evm json-rpc %s: %w", url, err)
 	}
+	ethClients.clients[url] = client
+	return client, nil
+}
 
-	tokenBalance := new(big.Int).SetBytes(bz)
+// CloseEthClients closes every shared JSON-RPC client. It is meant for
+// TestMain, once all tests are done.
+func CloseEthClients() {
+	ethClients.Lock()
+	defer ethClients.Unlock()
+	for url, client := range ethClients.clients {
+		client.Close()
+		delete(ethClients.clients, url)
+	}
+}
 
-	return tokenBalance, nil
+// DialEthClient dials the EVM JSON-RPC endpoint at url. HTTP endpoints go
+// through the cassette in use, if any. The caller closes the client; see
+// EthClient for a shared one.
+func DialEthClient(ctx context.Context, url string) (*ethclient.Client, error) {
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return ethclient.DialContext(ctx, url)
+	}
+	rpcClient, err := rpc.DialHTTPWithClient(url, &http.Client{Transport: CassetteTransport(nil)})
+	if err != nil {
+		return nil, err
+	}
+	return ethclient.NewClient(rpcClient), nil
 }
@@ -0,0 +1,473 @@
+package cosmos
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	rpcclient "github.com/cometbft/cometbft/rpc/client"
+	coretypes "github.com/cometbft/cometbft/rpc/core/types"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	grpcencoding "google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/encoding/proto"
+	"google.golang.org/grpc/status"
+)
+
+// CassetteMode selects whether a Cassette records interactions or replays them.
+type CassetteMode string
+
+const (
+	// CassetteRecord performs every interaction and writes it to the cassette.
+	CassetteRecord CassetteMode = "record"
+	// CassetteReplay answers every interaction from the cassette, offline.
+	CassetteReplay CassetteMode = "replay"
+)
+
+// Interaction kinds.
+const (
+	InteractionHTTP = "http"
+	InteractionGRPC = "grpc"
+	InteractionExec = "exec"
+)
+
+// Interaction is a request recorded on a Cassette with its response: a
+// CometBFT or EVM JSON-RPC call, a gRPC call or a chain binary command.
+type Interaction struct {
+	Kind string `json:"kind"`
+	// Op groups interactions that answer the same kind of request of the same
+	// endpoint: the JSON-RPC method, the gRPC method or the subcommand of the
+	// binary, with the host, target or binary.
+	Op string `json:"op"`
+	// Key and Request identify the request exactly: the URL, target or
+	// command line, and the request body, message or stdin. JSON-RPC ids are
+	// left out of the request and response bodies.
+	Key     string `json:"key"`
+	Request []byte `json:"request,omitempty"`
+	// Response is the response body, reply message or stdout.
+	Response []byte `json:"response,omitempty"`
+	Stderr   []byte `json:"stderr,omitempty"`
+	// Status is the HTTP status, gRPC code or exit code.
+	Status int `json:"status,omitempty"`
+	// Error is the error returned by the transport or the command.
+	Error string `json:"error,omitempty"`
+
+	used bool
+}
+
+// Cassette records the interactions of the chains with the network and the
+// chain binary to a file, or replays them from it, so that a failed live run
+// can be reproduced offline. It covers the CometBFT RPC Client created by
+// NewClient, the shared gRPC connections, the EVM JSON-RPC client of
+// DialEthClient, Faucet requests and the commands run by the executors. The
+// websocket is not recorded: while a cassette replays, the RPC Client created
+// by NewClient refuses to start it or subscribe with ErrWebsocketReplayed,
+// and the harness's subscribers fall back to polling; see Replaying.
+//
+// Replay answers a request with the first unused interaction recorded for
+// the same request, then with the first unused one of the same Op, since
+// signed txs and generated key names differ between runs. Once they are all
+// used the last answer to the same request is repeated, which keeps polling
+// loops going.
+//
+// The mnemonics and private keys handled by the keys commands are redacted
+// before being recorded, and replayed as placeholders of the same format.
+// Cassettes are still only readable by their owner.
+type Cassette struct {
+	Path string
+	Mode CassetteMode
+
+	mu           sync.Mutex
+	file         *os.File
+	w            *bufio.Writer
+	interactions []*Interaction
+	last         map[string]*Interaction
+}
+
+// OpenCassette opens the cassette at path: created, or truncated, when
+// recording, and read in full when replaying.
+func OpenCassette(path string, mode CassetteMode) (*Cassette, error) {
+	c := &Cassette{Path: path, Mode: mode, last: map[string]*Interaction{}}
+	switch mode {
+	case CassetteRecord:
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("create cassette: %w", err)
+		}
+		// The mode only applies to new files.
+		if err := f.Chmod(0o600); err != nil {
+			_ = f.Close()
+			return nil, fmt.Errorf("create cassette: %w", err)
+		}
+		c.file, c.w = f, bufio.NewWriter(f)
+	case CassetteReplay:
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("open cassette: %w", err)
+		}
+		defer f.Close()
+		dec := json.NewDecoder(f)
+		for {
+			var in Interaction
+			if err := dec.Decode(&in); errors.Is(err, io.EOF) {
+				break
+			} else if err != nil {
+				return nil, fmt.Errorf("read cassette %s: %w", path, err)
+			}
+			c.interactions = append(c.interactions, &in)
+		}
+	default:
+		return nil, fmt.Errorf("unknown cassette mode %q", mode)
+	}
+	return c, nil
+}
+
+// Close flushes a recording cassette to its file.
+func (c *Cassette) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.file == nil {
+		return nil
+	}
+	err := errors.Join(c.w.Flush(), c.file.Close())
+	c.file = nil
+	return err
+}
+
+func (c *Cassette) record(in Interaction) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.file == nil {
+		return
+	}
+	bz, err := json.Marshal(in)
+	if err == nil {
+		_, err = c.w.Write(append(bz, '\n'))
+	}
+	if err == nil {
+		// Flushed right away so that the cassette of a crashed run is usable.
+		err = c.w.Flush()
+	}
+	if err != nil {
+		DefaultLogger.Warn("record interaction", zap.String("cassette", c.Path), zap.Error(err))
+	}
+}
+
+func (c *Cassette) replay(kind, op, key string, request []byte) (*Interaction, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	exact := kind + "\x00" + key + "\x00" + string(request)
+	var found *Interaction
+	for _, in := range c.interactions {
+		if !in.used && in.Kind == kind && in.Key == key && bytes.Equal(in.Request, request) {
+			found = in
+			break
+		}
+	}
+	if found == nil {
+		for _, in := range c.interactions {
+			if !in.used && in.Kind == kind && in.Op == op {
+				found = in
+				break
+			}
+		}
+	}
+	if found == nil {
+		if last, ok := c.last[exact]; ok {
+			return last, nil
+		}
+		return nil, fmt.Errorf("cassette %s has no %s interaction left for %s", c.Path, kind, key)
+	}
+	found.used = true
+	c.last[exact] = found
+	return found, nil
+}
+
+var activeCassette struct {
+	sync.Mutex
+	c *Cassette
+}
+
+// UseCassette routes the interactions of every chain through c until the
+// returned function is called. Only one cassette can be in use at a time,
+// so tests using one must not run in parallel.
+func UseCassette(c *Cassette) (restore func(), _ error) {
+	activeCassette.Lock()
+	defer activeCassette.Unlock()
+	if activeCassette.c != nil {
+		return nil, fmt.Errorf("cassette %s is already in use", activeCassette.c.Path)
+	}
+	activeCassette.c = c
+	return func() {
+		activeCassette.Lock()
+		defer activeCassette.Unlock()
+		activeCassette.c = nil
+	}, nil
+}
+
+func currentCassette() *Cassette {
+	activeCassette.Lock()
+	defer activeCassette.Unlock()
+	return activeCassette.c
+}
+
+// Replaying reports whether the cassette in use replays interactions, in
+// which case nothing must reach the chains, the websocket included.
+func Replaying() bool {
+	c := currentCassette()
+	return c != nil && c.Mode == CassetteReplay
+}
+
+// ErrWebsocketReplayed is returned by websocket subscriptions while a
+// cassette replays, since the websocket is not recorded.
+var ErrWebsocketReplayed = errors.New("the websocket is not replayed from cassettes")
+
+// cassetteRPCClient is the RPC Client created by NewClient. Its websocket
+// stays closed while a cassette replays.
+type cassetteRPCClient struct {
+	rpcclient.Client
+}
+
+func (c *cassetteRPCClient) Start() error {
+	if Replaying() {
+		return ErrWebsocketReplayed
+	}
+	return c.Client.Start()
+}
+
+func (c *cassetteRPCClient) Subscribe(ctx context.Context, subscriber, query string, outCapacity ...int) (<-chan coretypes.ResultEvent, error) {
+	if Replaying() {
+		return nil, ErrWebsocketReplayed
+	}
+	return c.Client.Subscribe(ctx, subscriber, query, outCapacity...)
+}
+
+// exec runs a command of the chain binary through the cassette. The secrets
+// of keys commands are redacted from what is recorded, and from stdin before
+// it is matched.
+func (c *Cassette) exec(stdin []byte, bin string, args []string, run func() (ExecResult, error)) (ExecResult, error) {
+	key := strings.Join(append([]string{bin}, args...), " ")
+	op := bin + " " + execOp(args)
+	secret := len(args) > 0 && args[0] == "keys"
+	request := stdin
+	if secret && stdin != nil {
+		request = []byte(redactSecrets(string(stdin)))
+	}
+	if c.Mode == CassetteReplay {
+		in, err := c.replay(InteractionExec, op, key, request)
+		if err != nil {
+			return ExecResult{ExitCode: -1}, err
+		}
+		res := ExecResult{Stdout: unredact(in.Response), Stderr: unredact(in.Stderr), ExitCode: in.Status}
+		if in.Error != "" {
+			return res, errors.New(in.Error)
+		}
+		return res, nil
+	}
+	res, err := run()
+	in := Interaction{
+		Kind:     InteractionExec,
+		Op:       op,
+		Key:      key,
+		Request:  request,
+		Response: res.Stdout,
+		Stderr:   res.Stderr,
+		Status:   res.ExitCode,
+	}
+	if err != nil {
+		in.Error = err.Error()
+	}
+	if secret {
+		in.Response = []byte(redactSecrets(string(in.Response)))
+		in.Stderr = []byte(redactSecrets(string(in.Stderr)))
+		in.Error = redactSecrets(in.Error)
+	}
+	c.record(in)
+	return res, err
+}
+
+// Placeholders replayed for the secrets redacted from cassettes.
+const (
+	placeholderMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon " +
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon art"
+	placeholderKey = "0000000000000000000000000000000000000000000000000000000000000001"
+)
+
+var placeholders = strings.NewReplacer(
+	redactedMnemonicField, `"mnemonic":"`+placeholderMnemonic+`"`,
+	redactedMnemonic, placeholderMnemonic,
+	redactedKey, placeholderKey,
+)
+
+// unredact replaces the redacted secrets of a recorded output with
+// placeholders the harness can parse.
+func unredact(out []byte) []byte {
+	if out == nil {
+		return nil
+	}
+	return []byte(placeholders.Replace(string(out)))
+}
+
+// execOp returns the subcommand of args, e.g. "tx bank" or "keys add".
+func execOp(args []string) string {
+	var words []string
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") || len(words) == 2 {
+			break
+		}
+		words = append(words, arg)
+	}
+	return strings.Join(words, " ")
+}
+
+// cassetteTransport passes HTTP requests through the cassette in use, if any.
+type cassetteTransport struct {
+	base http.RoundTripper
+}
+
+// CassetteTransport wraps base, http.DefaultTransport if nil, so that its
+// requests are recorded to or replayed from the cassette in use.
+func CassetteTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return cassetteTransport{base: base}
+}
+
+func (t cassetteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	c := currentCassette()
+	if c == nil {
+		return t.base.RoundTrip(req)
+	}
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	key := req.Method + " " + req.URL.String()
+	request, id, method := splitJSONRPCID(body)
+	op := req.URL.Host + req.URL.Path
+	if method != "" {
+		op = req.URL.Host + " " + method
+	}
+
+	if c.Mode == CassetteReplay {
+		in, err := c.replay(InteractionHTTP, op, key, request)
+		if err != nil {
+			return nil, err
+		}
+		if in.Error != "" {
+			return nil, errors.New(in.Error)
+		}
+		respBody := withJSONRPCID(in.Response, id)
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", in.Status, http.StatusText(in.Status)),
+			StatusCode:    in.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{"Content-Type": []string{"application/json"}},
+			Body:          io.NopCloser(bytes.NewReader(respBody)),
+			ContentLength: int64(len(respBody)),
+			Request:       req,
+		}, nil
+	}
+
+	in := Interaction{Kind: InteractionHTTP, Op: op, Key: key, Request: request}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		in.Error = err.Error()
+		c.record(in)
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	if err != nil {
+		return resp, nil
+	}
+	in.Status = resp.StatusCode
+	in.Response, _, _ = splitJSONRPCID(respBody)
+	c.record(in)
+	return resp, nil
+}
+
+// splitJSONRPCID removes the id of a JSON-RPC message, which changes from run
+// to run, and returns it with the method of a request.
+func splitJSONRPCID(body []byte) (_ []byte, id json.RawMessage, method string) {
+	var msg map[string]json.RawMessage
+	if json.Unmarshal(body, &msg) != nil || msg["jsonrpc"] == nil {
+		return body, nil, ""
+	}
+	id = msg["id"]
+	delete(msg, "id")
+	_ = json.Unmarshal(msg["method"], &method)
+	stripped, err := json.Marshal(msg)
+	if err != nil {
+		return body, nil, ""
+	}
+	return stripped, id, method
+}
+
+func withJSONRPCID(body []byte, id json.RawMessage) []byte {
+	var msg map[string]json.RawMessage
+	if id == nil || json.Unmarshal(body, &msg) != nil {
+		return body
+	}
+	msg["id"] = id
+	bz, err := json.Marshal(msg)
+	if err != nil {
+		return body
+	}
+	return bz
+}
+
+// cassetteInterceptor passes unary gRPC calls through the cassette in use, if any.
+func cassetteInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	c := currentCassette()
+	if c == nil {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	codec := grpcencoding.GetCodec(proto.Name)
+	request, err := codec.Marshal(req)
+	if err != nil {
+		return err
+	}
+	key := cc.Target() + method
+
+	if c.Mode == CassetteReplay {
+		in, err := c.replay(InteractionGRPC, key, key, request)
+		if err != nil {
+			return status.Error(codes.Unavailable, err.Error())
+		}
+		if in.Status != int(codes.OK) {
+			return status.Error(codes.Code(in.Status), in.Error)
+		}
+		return codec.Unmarshal(in.Response, reply)
+	}
+
+	in := Interaction{Kind: InteractionGRPC, Op: key, Key: key, Request: request}
+	callErr := invoker(ctx, method, req, reply, cc, opts...)
+	if callErr != nil {
+		st := status.Convert(callErr)
+		in.Status, in.Error = int(st.Code()), st.Message()
+	} else if in.Response, err = codec.Marshal(reply); err != nil {
+		return err
+	}
+	c.record(in)
+	return callErr
+}
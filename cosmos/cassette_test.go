@@ -0,0 +1,181 @@
+package cosmos
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/require"
+)
+
+// cassetteRun queries a balance over gRPC and JSON-RPC and runs two commands,
+// the way a live test would.
+type cassetteRun struct {
+	balance, erc20Balance int64
+	keyOut, sendErr       string
+}
+
+func runWithCassette(t *testing.T, c *Cassette, chain CosmosChain, jsonrpc string) cassetteRun {
+	restore, err := UseCassette(c)
+	require.NoError(t, err)
+	defer restore()
+	_, err = UseCassette(c)
+	require.ErrorContains(t, err, "already in use")
+
+	ctx := context.Background()
+	var run cassetteRun
+	user := User{Address: sdk.MustBech32ifyAddressBytes("dym", make([]byte, 20))}
+	balance, err := user.GetBalance(ctx, "adym", chain)
+	require.NoError(t, err)
+	run.balance = balance.Int64()
+	erc20Balance, err := user.GetERC20Balance(jsonrpc, "0x00000000000000000000000000000000000000aa", 0)
+	require.NoError(t, err)
+	run.erc20Balance = erc20Balance.Int64()
+
+	res, err := chain.Exec(ctx, "keys", "show", "alice", "--output", "json")
+	require.NoError(t, err)
+	run.keyOut = string(res.Stdout)
+	_, err = chain.Exec(ctx, "tx", "bank", "send", "alice", "dym1bob", "1adym")
+	var cmdErr *CommandError
+	require.ErrorAs(t, err, &cmdErr)
+	run.sendErr = cmdErr.Stderr
+	return run
+}
+
+func TestCassetteRecordReplay(t *testing.T) {
+	server, grpcAddr := serveBank(t, deadlineLeft)
+
+	jsonrpc := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID json.RawMessage `json:"id"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"jsonrpc": "2.0",
+			"id":      req.ID,
+			"result":  hexutil.Encode(common.BigToHash(big.NewInt(40)).Bytes()),
+		})
+	}))
+	defer jsonrpc.Close()
+
+	executor := &ScriptedExecutor{}
+	executor.Expect("keys", "show", "alice").Return(`{"name":"alice","address":"dym1alice"}`)
+	executor.Expect("tx", "bank", "send").Fail(1, "Error: insufficient funds")
+	chain := CosmosChain{ChainID: "hub_1-1", Bin: "dymd", GrpcAddr: grpcAddr, Executor: executor}
+
+	path := filepath.Join(t.TempDir(), "run.cassette.jsonl")
+	recorder, err := OpenCassette(path, CassetteRecord)
+	require.NoError(t, err)
+	recorded := runWithCassette(t, recorder, chain, jsonrpc.URL)
+	require.NoError(t, recorder.Close())
+	require.Equal(t, cassetteRun{
+		balance:      int64(DefaultGRPCTimeout.Seconds()),
+		erc20Balance: 40,
+		keyOut:       `{"name":"alice","address":"dym1alice"}`,
+		sendErr:      "Error: insufficient funds",
+	}, recorded)
+	require.Len(t, executor.Calls(), 2)
+
+	// Offline: the servers are gone and the executor answers nothing.
+	server.Stop()
+	jsonrpc.Close()
+	CloseGRPCConns()
+	chain.Executor = &ScriptedExecutor{}
+	player, err := OpenCassette(path, CassetteReplay)
+	require.NoError(t, err)
+	require.Equal(t, recorded, runWithCassette(t, player, chain, jsonrpc.URL))
+
+	// Requests differing from the recorded ones get the answers recorded for
+	// the same operation, then the last answer to the same request.
+	player, err = OpenCassette(path, CassetteReplay)
+	require.NoError(t, err)
+	restore, err := UseCassette(player)
+	require.NoError(t, err)
+	defer restore()
+	ctx := context.Background()
+	res, err := chain.Exec(ctx, "keys", "show", "bob", "--output", "json")
+	require.NoError(t, err)
+	require.Equal(t, recorded.keyOut, string(res.Stdout))
+	_, err = chain.Exec(ctx, "keys", "show", "bob", "--output", "json")
+	require.NoError(t, err)
+	_, err = chain.Exec(ctx, "keys", "show", "carol", "--output", "json")
+	require.ErrorContains(t, err, "no exec interaction left for dymd keys show carol")
+}
+
+func TestNewClientWebsocketClosedWhileReplaying(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty.cassette.jsonl")
+	require.NoError(t, os.WriteFile(path, nil, 0o600))
+	player, err := OpenCassette(path, CassetteReplay)
+	require.NoError(t, err)
+	restore, err := UseCassette(player)
+	require.NoError(t, err)
+	defer restore()
+	require.True(t, Replaying())
+
+	chain := CosmosChain{ChainID: "hub_1-1"}
+	require.NoError(t, chain.NewClient("http://127.0.0.1:1"))
+	require.ErrorIs(t, chain.Client.Start(), ErrWebsocketReplayed)
+	_, err = chain.Client.Subscribe(context.Background(), "test", "tm.event='NewBlock'")
+	require.ErrorIs(t, err, ErrWebsocketReplayed)
+	_, err = chain.NewBlockHeights(context.Background())
+	require.ErrorIs(t, err, ErrWebsocketReplayed)
+}
+
+func TestCassetteRedactsKeys(t *testing.T) {
+	const (
+		mnemonic = "wolf juice proud gown wool unfair wall cliff insect more detail hub"
+		privKey  = "0a1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f9"
+	)
+	executor := &ScriptedExecutor{}
+	executor.Expect("keys", "add", "treasury", "--recover").Result = ExecResult{
+		Stderr: []byte(`{"name":"treasury","address":"dym1qqqsyqcyq5rqwzqfpg9scrgwpugpzysn3tau5h","mnemonic":"` + mnemonic + `"}` + "\n"),
+	}
+	executor.Expect("keys", "export", "treasury").Return("Continue? [y/N]:\n" + privKey + "\n")
+	chain := CosmosChain{ChainID: "hub_1-1", Bin: "dymd", Executor: executor}
+
+	path := filepath.Join(t.TempDir(), "keys.cassette.jsonl")
+	recorder, err := OpenCassette(path, CassetteRecord)
+	require.NoError(t, err)
+	restore, err := UseCassette(recorder)
+	require.NoError(t, err)
+	ctx := context.Background()
+	user, err := chain.RecoverKey(ctx, "treasury", mnemonic)
+	require.NoError(t, err)
+	require.Equal(t, mnemonic, user.Mnemonic)
+	exported, err := chain.ExportKey(ctx, "treasury")
+	require.NoError(t, err)
+	require.Equal(t, privKey, exported, "callers get the real key while recording")
+	restore()
+	require.NoError(t, recorder.Close())
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	recorded, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NotContains(t, string(recorded), "wolf juice")
+	require.NotContains(t, string(recorded), privKey)
+
+	// The placeholders replayed parse like the real secrets, and the mnemonic
+	// on stdin still matches the recorded command.
+	chain.Executor = &ScriptedExecutor{}
+	player, err := OpenCassette(path, CassetteReplay)
+	require.NoError(t, err)
+	restore, err = UseCassette(player)
+	require.NoError(t, err)
+	defer restore()
+	user, err = chain.RecoverKey(ctx, "treasury", mnemonic)
+	require.NoError(t, err)
+	require.Equal(t, "dym1qqqsyqcyq5rqwzqfpg9scrgwpugpzysn3tau5h", user.Address)
+	exported, err = chain.ExportKey(ctx, "treasury")
+	require.NoError(t, err)
+	require.Equal(t, placeholderKey, exported)
+}
@@ -0,0 +1,222 @@
+package cosmos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/cosmos/cosmos-sdk/types/query"
+	transfertypes "github.com/cosmos/ibc-go/v7/modules/apps/transfer/types"
+	clienttypes "github.com/cosmos/ibc-go/v7/modules/core/02-client/types"
+	conntypes "github.com/cosmos/ibc-go/v7/modules/core/03-connection/types"
+	chanTypes "github.com/cosmos/ibc-go/v7/modules/core/04-channel/types"
+	"github.com/cosmos/ibc-go/v7/modules/core/exported"
+	"google.golang.org/grpc"
+)
+
+// ChannelPair is an open IBC channel seen from one chain.
+type ChannelPair struct {
+	PortID                string
+	ChannelID             string
+	ConnectionID          string
+	ClientID              string
+	CounterpartyPortID    string
+	CounterpartyChannelID string
+}
+
+// Reverse returns the pair seen from the counterparty chain. The connection
+// and client IDs of the counterparty are not known and are left empty.
+func (p ChannelPair) Reverse() ChannelPair {
+	return ChannelPair{
+		PortID:                p.CounterpartyPortID,
+		ChannelID:             p.CounterpartyChannelID,
+		CounterpartyPortID:    p.PortID,
+		CounterpartyChannelID: p.ChannelID,
+	}
+}
+
+var channelCache = struct {
+	sync.Mutex
+	pairs map[[2]string]ChannelPair
+}{pairs: map[[2]string]ChannelPair{}}
+
+// ResetChannelCache forgets the channels found by FindTransferChannel.
+func ResetChannelCache() {
+	channelCache.Lock()
+	defer channelCache.Unlock()
+	channelCache.pairs = map[[2]string]ChannelPair{}
+}
+
+// FindTransferChannel discovers the open transfer channel from src to dst. A
+// channel qualifies when its client on src is active and tracks dst's chain
+// ID, and dst's end of the channel is open and points back at it over a
+// client tracking src. If several channels qualify the newest one wins.
+// Results are cached for the rest of the test run.
+func FindTransferChannel(ctx context.Context, src, dst CosmosChain) (ChannelPair, error) {
+	key := [2]string{src.ChainID, dst.ChainID}
+	channelCache.Lock()
+	pair, ok := channelCache.pairs[key]
+	channelCache.Unlock()
+	if ok {
+		return pair, nil
+	}
+
+	srcConn, err := src.GRPCConn()
+	if err != nil {
+		return ChannelPair{}, err
+	}
+	dstConn, err := dst.GRPCConn()
+	if err != nil {
+		return ChannelPair{}, err
+	}
+
+	// Channels whose client status could not be queried are not candidates,
+	// but their errors are reported if no other channel qualifies.
+	candidates, rejected, err := openChannelsTo(ctx, srcConn, transfertypes.PortID, dst.ChainID)
+	if err != nil {
+		return ChannelPair{}, fmt.Errorf("list channels of %s: %w", src.ChainID, err)
+	}
+	for _, c := range candidates {
+		if err := verifyCounterparty(ctx, dstConn, c, src.ChainID); err != nil {
+			rejected = append(rejected, fmt.Errorf("%s: %w", c.ChannelID, err))
+			continue
+		}
+		channelCache.Lock()
+		channelCache.pairs[key] = c
+		channelCache.pairs[[2]string{dst.ChainID, src.ChainID}] = c.Reverse()
+		channelCache.Unlock()
+		return c, nil
+	}
+	if len(rejected) > 0 {
+		return ChannelPair{}, fmt.Errorf("no usable transfer channel from %s to %s: %w", src.ChainID, dst.ChainID, errors.Join(rejected...))
+	}
+	return ChannelPair{}, fmt.Errorf("no open transfer channel from %s to %s", src.ChainID, dst.ChainID)
+}
+
+// openChannelsTo lists the open channels bound to port whose client is active
+// and tracks counterpartyChainID, newest first. The channels whose client
+// could not be queried or decoded are left out and returned as failed, one
+// error each.
+func openChannelsTo(ctx context.Context, conn *grpc.ClientConn, port, counterpartyChainID string) (_ []ChannelPair, failed []error, _ error) {
+	var (
+		channels []*chanTypes.IdentifiedChannel
+		nextKey  []byte
+	)
+	chanClient := chanTypes.NewQueryClient(conn)
+	for {
+		res, err := chanClient.Channels(ctx, &chanTypes.QueryChannelsRequest{Pagination: &query.PageRequest{Key: nextKey}})
+		if err != nil {
+			return nil, nil, err
+		}
+		channels = append(channels, res.Channels...)
+		if res.Pagination == nil || len(res.Pagination.NextKey) == 0 {
+			break
+		}
+		nextKey = res.Pagination.NextKey
+	}
+
+	var pairs []ChannelPair
+	for _, ch := range channels {
+		if ch.State != chanTypes.OPEN || ch.PortId != port || len(ch.ConnectionHops) == 0 {
+			continue
+		}
+		clientID, chainID, err := connectionClient(ctx, conn, ch.ConnectionHops[0])
+		if err != nil {
+			failed = append(failed, fmt.Errorf("%s: %w", ch.ChannelId, err))
+			continue
+		}
+		if chainID != counterpartyChainID {
+			continue
+		}
+		active, err := clientActive(ctx, conn, clientID)
+		if err != nil {
+			failed = append(failed, fmt.Errorf("%s: %w", ch.ChannelId, err))
+			continue
+		}
+		if !active {
+			continue
+		}
+		pairs = append(pairs, ChannelPair{
+			PortID:                ch.PortId,
+			ChannelID:             ch.ChannelId,
+			ConnectionID:          ch.ConnectionHops[0],
+			ClientID:              clientID,
+			CounterpartyPortID:    ch.Counterparty.PortId,
+			CounterpartyChannelID: ch.Counterparty.ChannelId,
+		})
+	}
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return channelSequence(pairs[i].ChannelID) > channelSequence(pairs[j].ChannelID)
+	})
+	return pairs, failed, nil
+}
+
+// verifyCounterparty checks that the counterparty end of pair is open, points
+// back at pair and is served by a client of chainID.
+func verifyCounterparty(ctx context.Context, conn *grpc.ClientConn, pair ChannelPair, chainID string) error {
+	res, err := chanTypes.NewQueryClient(conn).Channel(ctx, &chanTypes.QueryChannelRequest{
+		PortId:    pair.CounterpartyPortID,
+		ChannelId: pair.CounterpartyChannelID,
+	})
+	if err != nil {
+		return fmt.Errorf("query counterparty channel: %w", err)
+	}
+	ch := res.Channel
+	if ch.State != chanTypes.OPEN {
+		return fmt.Errorf("counterparty channel is %s", ch.State)
+	}
+	if ch.Counterparty.ChannelId != pair.ChannelID || ch.Counterparty.PortId != pair.PortID {
+		return fmt.Errorf("counterparty channel points at %s/%s", ch.Counterparty.PortId, ch.Counterparty.ChannelId)
+	}
+	if len(ch.ConnectionHops) == 0 {
+		return fmt.Errorf("counterparty channel has no connection")
+	}
+	_, counterpartyChainID, err := connectionClient(ctx, conn, ch.ConnectionHops[0])
+	if err != nil {
+		return err
+	}
+	if counterpartyChainID != chainID {
+		return fmt.Errorf("counterparty client tracks %s", counterpartyChainID)
+	}
+	return nil
+}
+
+// connectionClient returns the client of connectionID and the chain ID it tracks.
+func connectionClient(ctx context.Context, conn *grpc.ClientConn, connectionID string) (string, string, error) {
+	connRes, err := conntypes.NewQueryClient(conn).Connection(ctx, &conntypes.QueryConnectionRequest{ConnectionId: connectionID})
+	if err != nil {
+		return "", "", AsNotFound("connection "+connectionID, err)
+	}
+	clientID := connRes.Connection.ClientId
+	clientRes, err := clienttypes.NewQueryClient(conn).ClientState(ctx, &clienttypes.QueryClientStateRequest{ClientId: clientID})
+	if err != nil {
+		return "", "", AsNotFound("client state "+clientID, err)
+	}
+	var clientState exported.ClientState
+	if err := DefaultEncoding().InterfaceRegistry.UnpackAny(clientRes.ClientState, &clientState); err != nil {
+		return "", "", fmt.Errorf("unpack client state %s: %w", clientID, err)
+	}
+	chainIDer, ok := clientState.(interface{ GetChainID() string })
+	if !ok {
+		return clientID, "", nil
+	}
+	return clientID, chainIDer.GetChainID(), nil
+}
+
+func clientActive(ctx context.Context, conn *grpc.ClientConn, clientID string) (bool, error) {
+	res, err := clienttypes.NewQueryClient(conn).ClientStatus(ctx, &clienttypes.QueryClientStatusRequest{ClientId: clientID})
+	if err != nil {
+		return false, fmt.Errorf("query client status %s: %w", clientID, err)
+	}
+	return res.Status == exported.Active.String(), nil
+}
+
+func channelSequence(channelID string) uint64 {
+	seq, err := chanTypes.ParseChannelSequence(channelID)
+	if err != nil {
+		return 0
+	}
+	return seq
+}
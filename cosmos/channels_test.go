@@ -0,0 +1,137 @@
+package cosmos
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	transfertypes "github.com/cosmos/ibc-go/v7/modules/apps/transfer/types"
+	clienttypes "github.com/cosmos/ibc-go/v7/modules/core/02-client/types"
+	conntypes "github.com/cosmos/ibc-go/v7/modules/core/03-connection/types"
+	chanTypes "github.com/cosmos/ibc-go/v7/modules/core/04-channel/types"
+	"github.com/cosmos/ibc-go/v7/modules/core/exported"
+	ibctm "github.com/cosmos/ibc-go/v7/modules/light-clients/07-tendermint"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ibcChain serves channel-N over connection-N and client-N, the Nth of
+// clients.
+type ibcChain struct {
+	clients []fakeClient
+}
+
+type fakeClient struct {
+	state     *codectypes.Any
+	status    exported.Status
+	statusErr error
+}
+
+func (s *ibcChain) register(server *grpc.Server) {
+	chanTypes.RegisterQueryServer(server, &channelServer{ibcChain: s})
+	conntypes.RegisterQueryServer(server, &connectionServer{ibcChain: s})
+	clienttypes.RegisterQueryServer(server, &clientServer{ibcChain: s})
+}
+
+func (s *ibcChain) client(clientID string) (fakeClient, error) {
+	for i, c := range s.clients {
+		if clientID == fmt.Sprintf("client-%d", i) {
+			return c, nil
+		}
+	}
+	return fakeClient{}, status.Errorf(codes.NotFound, "%s: light client not found", clientID)
+}
+
+type channelServer struct {
+	*ibcChain
+	chanTypes.UnimplementedQueryServer
+}
+
+func (s channelServer) Channels(context.Context, *chanTypes.QueryChannelsRequest) (*chanTypes.QueryChannelsResponse, error) {
+	res := &chanTypes.QueryChannelsResponse{}
+	for i := range s.clients {
+		ch := chanTypes.NewIdentifiedChannel(transfertypes.PortID, fmt.Sprintf("channel-%d", i), chanTypes.NewChannel(
+			chanTypes.OPEN, chanTypes.UNORDERED, chanTypes.NewCounterparty(transfertypes.PortID, fmt.Sprintf("channel-%d", i)),
+			[]string{fmt.Sprintf("connection-%d", i)}, transfertypes.Version,
+		))
+		res.Channels = append(res.Channels, &ch)
+	}
+	return res, nil
+}
+
+type connectionServer struct {
+	*ibcChain
+	conntypes.UnimplementedQueryServer
+}
+
+func (s connectionServer) Connection(_ context.Context, req *conntypes.QueryConnectionRequest) (*conntypes.QueryConnectionResponse, error) {
+	var n int
+	if _, err := fmt.Sscanf(req.ConnectionId, "connection-%d", &n); err != nil || n >= len(s.clients) {
+		return nil, status.Errorf(codes.NotFound, "%s: connection not found", req.ConnectionId)
+	}
+	return &conntypes.QueryConnectionResponse{Connection: &conntypes.ConnectionEnd{ClientId: fmt.Sprintf("client-%d", n)}}, nil
+}
+
+type clientServer struct {
+	*ibcChain
+	clienttypes.UnimplementedQueryServer
+}
+
+func (s clientServer) ClientState(_ context.Context, req *clienttypes.QueryClientStateRequest) (*clienttypes.QueryClientStateResponse, error) {
+	c, err := s.client(req.ClientId)
+	if err != nil {
+		return nil, err
+	}
+	return &clienttypes.QueryClientStateResponse{ClientState: c.state}, nil
+}
+
+func (s clientServer) ClientStatus(_ context.Context, req *clienttypes.QueryClientStatusRequest) (*clienttypes.QueryClientStatusResponse, error) {
+	c, err := s.client(req.ClientId)
+	if err != nil {
+		return nil, err
+	}
+	if c.statusErr != nil {
+		return nil, c.statusErr
+	}
+	return &clienttypes.QueryClientStatusResponse{Status: c.status.String()}, nil
+}
+
+func tendermintClient(t *testing.T, chainID string) *codectypes.Any {
+	state, err := clienttypes.PackClientState(ibctm.NewClientState(chainID, ibctm.DefaultTrustLevel,
+		14*24*time.Hour, 21*24*time.Hour, 10*time.Second, clienttypes.NewHeight(1, 1), nil, nil))
+	require.NoError(t, err)
+	return state
+}
+
+func TestOpenChannelsTo(t *testing.T) {
+	ibc := &ibcChain{clients: []fakeClient{
+		{state: tendermintClient(t, "rolx_1-1"), status: exported.Active},
+		{state: tendermintClient(t, "roly_1-1"), status: exported.Active},
+		// A client type the codec does not know, such as a solo machine.
+		{state: &codectypes.Any{TypeUrl: "/ibc.lightclients.solomachine.v3.ClientState"}},
+		{state: tendermintClient(t, "rolx_1-1"), status: exported.Expired},
+		{state: tendermintClient(t, "rolx_1-1"), statusErr: status.Error(codes.Unavailable, "client store unavailable")},
+		{state: tendermintClient(t, "rolx_1-1"), status: exported.Active},
+	}}
+	_, addr := serveGRPC(t, ibc.register)
+	conn, err := CosmosChain{ChainID: "hub_1-1", GrpcAddr: addr}.GRPCConn()
+	require.NoError(t, err)
+
+	pairs, failed, err := openChannelsTo(context.Background(), conn, transfertypes.PortID, "rolx_1-1")
+	require.NoError(t, err, "odd channels do not fail the discovery")
+	require.Len(t, pairs, 2)
+	require.Equal(t, "channel-5", pairs[0].ChannelID, "newest first")
+	require.Equal(t, ChannelPair{
+		PortID: transfertypes.PortID, ChannelID: "channel-0", ConnectionID: "connection-0", ClientID: "client-0",
+		CounterpartyPortID: transfertypes.PortID, CounterpartyChannelID: "channel-0",
+	}, pairs[1])
+
+	require.Len(t, failed, 2)
+	require.ErrorContains(t, failed[0], "channel-2: unpack client state client-2")
+	require.ErrorContains(t, failed[1], "channel-4: query client status client-4")
+	require.Equal(t, codes.Unavailable, status.Code(failed[1]))
+}
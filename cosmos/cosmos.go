@@ -1,13 +1,12 @@
 package cosmos
 
 import (
-	"bytes"
 	"context"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"os/exec"
 	"strconv"
+	"strings"
 	"time"
 
 	rpcclient "github.com/cometbft/cometbft/rpc/client"
@@ -19,25 +18,44 @@ import (
 	"github.com/cosmos/cosmos-sdk/types"
 	chanTypes "github.com/cosmos/ibc-go/v7/modules/core/04-channel/types"
 	"github.com/decentrio/rollup-e2e-testing/blockdb"
-	"github.com/decentrio/rollup-e2e-testing/dymension"
 	"github.com/decentrio/rollup-e2e-testing/ibc"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
 )
 
 type CosmosChain struct {
-	RPCAddr       string `json:"rpc_addr"`
-	JsonRPCAddr   string `json:"json_rpc_addr"`
-	GrpcAddr      string `json:"grpc_addr"`
-	ChainID       string `json:"chain_id"`
-	Bin           string `json:"bin"`
-	GasPrices     string `json:"gas_prices"`
-	GasAdjustment string `json:"gas_adjustment"`
-	Denom         string `json:"denom"`
-	Client        rpcclient.Client
+	RPCAddr     string `json:"rpc_addr"`
+	JsonRPCAddr string `json:"json_rpc_addr"`
+	GrpcAddr    string `json:"grpc_addr"`
+	// GrpcTLS is the transport security of GrpcAddr, see GRPCConn.
+	GrpcTLS       GRPCTLS `json:"grpc_tls"`
+	ChainID       string  `json:"chain_id"`
+	Bin           string  `json:"bin"`
+	GasPrices     string  `json:"gas_prices"`
+	GasAdjustment string  `json:"gas_adjustment"`
+	Denom         string  `json:"denom"`
+	Fees          string  `json:"fees"`
+	Bech32Prefix  string  `json:"bech32_prefix"`
+	// KeyringHome is the home directory holding the binary's test keyring,
+	// the binary's default home if empty.
+	KeyringHome string `json:"keyring_home"`
+	// KeyAlgo and HDPath select how CreateKey and RecoverKey derive keys, the
+	// binary's defaults if empty. EVM rollapps need eth_secp256k1.
+	KeyAlgo string `json:"key_algo"`
+	HDPath  string `json:"hd_path"`
+	// BroadcastMode applies to every tx sent to the chain, BroadcastCommit if empty.
+	BroadcastMode BroadcastMode    `json:"broadcast_mode"`
+	Client        rpcclient.Client `json:"-"`
+	Executor      Executor         `json:"-"`
+	// Logger receives the commands run and the chain's warnings, DefaultLogger
+	// if nil. zaptest.NewLogger(t) routes them to t.Log.
+	Logger *zap.Logger `json:"-"`
+	// Keyring signs transactions built in-process, see BroadcastTx.
+	Keyring keyring.Keyring `json:"-"`
 }
 
-// NewClient creates and assigns a new Tendermint RPC client to the Node
+// NewClient creates and assigns a new Tendermint RPC client to the Node. Its
+// requests go through the cassette in use, if any; see Cassette.
 func (c *CosmosChain) NewClient(addr string) error {
 	httpClient, err := libclient.DefaultHTTPClient(addr)
 	if err != nil {
@@ -45,16 +63,81 @@ func (c *CosmosChain) NewClient(addr string) error {
 	}
 
 	httpClient.Timeout = 10 * time.Second
+	httpClient.Transport = CassetteTransport(httpClient.Transport)
 	rpcClient, err := rpchttp.NewWithClient(addr, "/websocket", httpClient)
 	if err != nil {
 		return err
 	}
 
-	c.Client = rpcClient
+	c.Client = &cassetteRPCClient{Client: rpcClient}
 	return nil
 }
 
+// RPCURL returns the RPC address with a scheme, defaulting to https when
+// RPCAddr is a bare host:port.
+func (c CosmosChain) RPCURL() string {
+	if strings.Contains(c.RPCAddr, "://") {
+		return c.RPCAddr
+	}
+	return "https://" + c.RPCAddr
+}
+
+// Exec runs the chain binary with args through the chain's Executor,
+// falling back to DefaultExecutor when none is set.
+func (c CosmosChain) Exec(ctx context.Context, args ...string) (ExecResult, error) {
+	return c.execStdin(ctx, nil, args...)
+}
+
+// execStdin is Exec feeding stdin to the command, which requires the
+// Executor to be a StdinExecutor when stdin is not nil.
+func (c CosmosChain) execStdin(ctx context.Context, stdin []byte, args ...string) (ExecResult, error) {
+	executor := c.Executor
+	if executor == nil {
+		executor = DefaultExecutor
+	}
+	run := func() (ExecResult, error) {
+		if stdin == nil {
+			return executor.Exec(ctx, c.Bin, args...)
+		}
+		if se, ok := executor.(StdinExecutor); ok {
+			return se.ExecStdin(ctx, stdin, c.Bin, args...)
+		}
+		return ExecResult{ExitCode: -1}, fmt.Errorf("executor %T cannot feed stdin to %s %s", executor, c.Bin, args[0])
+	}
+	var (
+		res ExecResult
+		err error
+	)
+	if cassette := currentCassette(); cassette != nil {
+		res, err = cassette.exec(stdin, c.Bin, args, run)
+	} else {
+		res, err = run()
+	}
+	fields := []zap.Field{
+		zap.String("cmd", c.Bin+" "+strings.Join(args, " ")),
+		zap.Duration("duration", res.Duration),
+		zap.Int("exit_code", res.ExitCode),
+	}
+	if stderr := redact(string(res.Stderr)); stderr != "" {
+		fields = append(fields, zap.String("stderr", stderr))
+	}
+	if err != nil {
+		fields = append(fields, zap.Error(err))
+	}
+	c.Log().Debug("exec", fields...)
+	if err != nil && res.ExitCode > 0 {
+		err = &CommandError{
+			Args:     append([]string{c.Bin}, args...),
+			Stderr:   string(res.Stderr),
+			ExitCode: res.ExitCode,
+			Err:      err,
+		}
+	}
+	return res, err
+}
+
 func SendIBCTransfer(
+	ctx context.Context,
 	srcChain CosmosChain,
 	channelID string,
 	keyName string,
@@ -65,7 +148,6 @@ func SendIBCTransfer(
 	command := []string{
 		"ibc-transfer", "transfer", "transfer", channelID,
 		toWallet.Address, fmt.Sprintf("%s%s", toWallet.Amount.String(), toWallet.Denom),
-		"--fees", fees, "--node", "https://" + srcChain.RPCAddr,
 	}
 	if options.Timeout != nil {
 		if options.Timeout.NanoSeconds > 0 {
@@ -77,100 +159,57 @@ func SendIBCTransfer(
 	if options.Memo != "" {
 		command = append(command, "--memo", options.Memo)
 	}
+	return srcChain.ExecTx(ctx, keyName, fees, command...)
+}
 
-	command = append([]string{"tx"}, command...)
-	// var gasPriceFound, gasAdjustmentFound = false, false
-	// for i := 0; i < len(command); i++ {
-	// 	if command[i] == "--gas-prices" {
-	// 		gasPriceFound = true
-	// 	}
-	// 	if command[i] == "--gas-adjustment" {
-	// 		gasAdjustmentFound = true
-	// 	}
-	// }
-
-	// if !gasPriceFound {
-	// 	command = append(command, "--gas-prices", srcChain.GasPrices)
-	// }
-	// if !gasAdjustmentFound {
-	// 	command = append(command, "--gas-adjustment", srcChain.GasAdjustment)
-	// }
-
-	command = append(command,
-		"--chain-id", srcChain.ChainID,
-		"--gas", "auto",
-		"--gas-adjustment", "1.5",
-		"--from", keyName,
-		"--keyring-backend", keyring.BackendTest,
-		"--output", "json",
-		"--broadcast-mode", "async",
-		"-y")
-
-	// Create the command
-	cmd := exec.Command(srcChain.Bin, command...)
-	fmt.Println(cmd)
-	// Run the command and get the output
-	output, err := cmd.Output()
-	if err != nil {
-		fmt.Println("Error executing command:", err)
-		return nil, err
-	}
+// ExecQuery runs "q <command...>" with the chain binary against the chain's
+// node and decodes the JSON output into out.
+func (c CosmosChain) ExecQuery(ctx context.Context, out any, command ...string) error {
+	resource := strings.Join(command, " ")
+	command = append([]string{"q"}, command...)
+	command = append(command, "--node", c.RPCURL(), "--output", "json")
 
-	txResponse := TxResponse{}
-	err = json.Unmarshal(output, &txResponse)
+	res, err := c.Exec(ctx, command...)
 	if err != nil {
-		return nil, err
+		return AsNotFound(resource, err)
 	}
-
-	result, err := GetTxResponse(srcChain, txResponse.TxHash)
-	if err != nil {
-		return nil, err
+	if err := json.Unmarshal(res.Stdout, out); err != nil {
+		return fmt.Errorf("decode %s output: %w", strings.Join(command[:len(command)-4], " "), err)
 	}
-
-	return result, nil
+	return nil
 }
 
-// TODO: refactor this to dym_hub
-func FullfillDemandOrder(
-	dymHub *CosmosChain,
-	orderId string,
-	keyName string,
-	fees string,
-) (*TxResponse, error) {
-	command := []string{
-		"eibc", "fulfill-order", orderId,
-		"--fees", fees, "--node", "https://" + dymHub.RPCAddr,
-	}
+// ExecTx runs "tx <command...>" with the chain binary, signing with keyName
+// from the test keyring, and waits for the tx according to the chain's
+// broadcast mode.
+func (c CosmosChain) ExecTx(ctx context.Context, keyName, fees string, command ...string) (*TxResponse, error) {
+	mode := c.broadcastMode("")
 
 	command = append([]string{"tx"}, command...)
-
 	command = append(command,
-		"--chain-id", dymHub.ChainID,
+		"--fees", fees, "--node", c.RPCURL(),
+		"--chain-id", c.ChainID,
 		"--gas", "auto",
 		"--gas-adjustment", "1.5",
-		"--from", keyName,
-		"--keyring-backend", keyring.BackendTest,
+		"--from", keyName)
+	command = append(command, c.keyringFlags()...)
+	command = append(command,
 		"--output", "json",
-		"--broadcast-mode", "async",
+		"--broadcast-mode", mode.cliMode(),
 		"-y")
 
-	// Create the command
-	cmd := exec.Command(dymHub.Bin, command...)
-	fmt.Println(cmd)
-	// Run the command and get the output
-	output, err := cmd.Output()
+	res, err := c.Exec(ctx, command...)
 	if err != nil {
-		fmt.Println("Error executing command:", err)
 		return nil, err
 	}
 
 	txResponse := TxResponse{}
-	err = json.Unmarshal(output, &txResponse)
+	err = json.Unmarshal(res.Stdout, &txResponse)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("decode tx output: %w", err)
 	}
 
-	return &txResponse, nil
+	return c.awaitTx(ctx, mode, &txResponse)
 }
 
 func GetIbcTxFromTxResponse(txResp TxResponse) (tx ibc.Tx, _ error) {
@@ -187,38 +226,14 @@ func GetIbcTxFromTxResponse(txResp TxResponse) (tx ibc.Tx, _ error) {
 	}
 	tx.GasSpent = gasWanted
 
-	const evType = "send_packet"
-	events := txResp.Events
-
-	var (
-		seq, _           = AttributeValue(events, evType, "packet_sequence")
-		srcPort, _       = AttributeValue(events, evType, "packet_src_port")
-		srcChan, _       = AttributeValue(events, evType, "packet_src_channel")
-		dstPort, _       = AttributeValue(events, evType, "packet_dst_port")
-		dstChan, _       = AttributeValue(events, evType, "packet_dst_channel")
-		timeoutHeight, _ = AttributeValue(events, evType, "packet_timeout_height")
-		timeoutTs, _     = AttributeValue(events, evType, "packet_timeout_timestamp")
-		data, _          = AttributeValue(events, evType, "packet_data")
-	)
-
-	tx.Packet.SourcePort = srcPort
-	tx.Packet.SourceChannel = srcChan
-	tx.Packet.DestPort = dstPort
-	tx.Packet.DestChannel = dstChan
-	tx.Packet.TimeoutHeight = timeoutHeight
-	tx.Packet.Data = []byte(data)
-
-	seqNum, err := strconv.Atoi(seq)
+	packets, err := SendPacketEvents(txResp.Events)
 	if err != nil {
-		return tx, fmt.Errorf("invalid packet sequence from events %s: %w", seq, err)
+		return tx, err
 	}
-	tx.Packet.Sequence = uint64(seqNum)
-
-	timeoutNano, err := strconv.ParseUint(timeoutTs, 10, 64)
-	if err != nil {
-		return tx, fmt.Errorf("invalid packet timestamp timeout %s: %w", timeoutTs, err)
+	if len(packets) == 0 {
+		return tx, fmt.Errorf("tx %s sent no packet", txResp.TxHash)
 	}
-	tx.Packet.TimeoutTimestamp = ibc.Nanoseconds(timeoutNano)
+	tx.Packet = packets[0].Packet
 
 	return tx, nil
 }
@@ -233,98 +248,35 @@ func (c CosmosChain) Height(ctx context.Context) (uint64, error) {
 }
 
 func GetTxResponse(
+	ctx context.Context,
 	chain CosmosChain,
 	txHash string,
 
 ) (*TxResponse, error) {
 	command := []string{
-		"q", "tx", txHash, "--node", "https://" + chain.RPCAddr,
+		"q", "tx", txHash, "--node", chain.RPCURL(),
 	}
 
 	command = append(command,
 		"--chain-id", chain.ChainID,
 		"--output", "json",
 	)
-	
-	// Create the command
-	cmd := exec.Command(chain.Bin, command...)
-	fmt.Println(cmd)
 
-	output, err := cmd.Output()
+	res, err := chain.Exec(ctx, command...)
 	if err != nil {
-		fmt.Println("Error executing command:", err)
-		return nil, err
+		return nil, AsNotFound("tx "+txHash, err)
 	}
 
 	tx := TxResponse{}
 
-	err = json.Unmarshal(output, &tx)
+	err = json.Unmarshal(res.Stdout, &tx)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("decode tx %s output: %w", txHash, err)
 	}
 
 	return &tx, nil
 }
 
-func (c *CosmosChain) CreateUser(keyName string) (User, error) {
-	user := User{}
-	if err := c.CreateKey(keyName); err != nil {
-		return user, err
-	}
-	addr, err := c.KeyBech32(keyName)
-	if err != nil {
-		return user, err
-	}
-
-	user = User{
-		Address: addr,
-		Denom:   c.Denom,
-	}
-	return user, nil
-}
-
-func (c *CosmosChain) CreateKey(name string) error {
-
-	command := []string{
-		"keys", "add", name, "--keyring-backend", keyring.BackendTest,
-	}
-
-	// Create the command
-	cmd := exec.Command(c.Bin, command...)
-	fmt.Println(cmd)
-	// Run the command and get the output
-	output, err := cmd.Output()
-	if err != nil {
-		fmt.Println("Error executing command:", err)
-		return err
-	}
-
-	// Print the output
-	fmt.Println(string(output))
-	return err
-}
-
-func (c *CosmosChain) KeyBech32(name string) (string, error) {
-	command := []string{"keys", "show", "--address", name,
-		"--keyring-backend", keyring.BackendTest,
-	}
-
-	// Create the command
-	cmd := exec.Command(c.Bin, command...)
-	fmt.Println(cmd)
-	// Run the command and get the output
-	output, err := cmd.Output()
-	if err != nil {
-		fmt.Println("Error executing command:", err)
-		return "", err
-	}
-
-	// Print the output
-	fmt.Println(string(output))
-
-	return string(bytes.TrimSuffix(output, []byte("\n"))), nil
-}
-
 // Acknowledgements implements ibc.Chain, returning all acknowledgments in block at height
 func (c CosmosChain) Acknowledgements(ctx context.Context, interfaceRegistry codectypes.InterfaceRegistry, height uint64) ([]ibc.PacketAcknowledgement, error) {
 	var acks []*chanTypes.MsgAcknowledgement
@@ -344,21 +296,61 @@ func (c CosmosChain) Acknowledgements(ctx context.Context, interfaceRegistry cod
 		ack := ack
 		ibcAcks[i] = ibc.PacketAcknowledgement{
 			Acknowledgement: ack.Acknowledgement,
-			Packet: ibc.Packet{
-				Sequence:         ack.Packet.Sequence,
-				SourcePort:       ack.Packet.SourcePort,
-				SourceChannel:    ack.Packet.SourceChannel,
-				DestPort:         ack.Packet.DestinationPort,
-				DestChannel:      ack.Packet.DestinationChannel,
-				Data:             ack.Packet.Data,
-				TimeoutHeight:    ack.Packet.TimeoutHeight.String(),
-				TimeoutTimestamp: ibc.Nanoseconds(ack.Packet.TimeoutTimestamp),
-			},
+			Packet:          ibcPacket(ack.Packet),
 		}
 	}
 	return ibcAcks, nil
 }
 
+// Timeouts implements ibc.Chain, returning all timeouts in block at height
+func (c CosmosChain) Timeouts(ctx context.Context, height uint64) ([]ibc.PacketTimeout, error) {
+	var timeouts []ibc.PacketTimeout
+
+	err := rangeBlockMessages(ctx, DefaultEncoding().InterfaceRegistry, c.Client, height, func(msg types.Msg) bool {
+		switch found := msg.(type) {
+		case *chanTypes.MsgTimeout:
+			timeouts = append(timeouts, ibc.PacketTimeout{Packet: ibcPacket(found.Packet)})
+		case *chanTypes.MsgTimeoutOnClose:
+			timeouts = append(timeouts, ibc.PacketTimeout{Packet: ibcPacket(found.Packet)})
+		}
+		return false
+	})
+	if err != nil {
+		return nil, fmt.Errorf("find timeouts at height %d: %w", height, err)
+	}
+	return timeouts, nil
+}
+
+// RecvPackets returns all packets received by the chain in block at height
+func (c CosmosChain) RecvPackets(ctx context.Context, height uint64) ([]ibc.Packet, error) {
+	var packets []ibc.Packet
+
+	err := rangeBlockMessages(ctx, DefaultEncoding().InterfaceRegistry, c.Client, height, func(msg types.Msg) bool {
+		found, ok := msg.(*chanTypes.MsgRecvPacket)
+		if ok {
+			packets = append(packets, ibcPacket(found.Packet))
+		}
+		return false
+	})
+	if err != nil {
+		return nil, fmt.Errorf("find received packets at height %d: %w", height, err)
+	}
+	return packets, nil
+}
+
+func ibcPacket(packet chanTypes.Packet) ibc.Packet {
+	return ibc.Packet{
+		Sequence:         packet.Sequence,
+		SourcePort:       packet.SourcePort,
+		SourceChannel:    packet.SourceChannel,
+		DestPort:         packet.DestinationPort,
+		DestChannel:      packet.DestinationChannel,
+		Data:             packet.Data,
+		TimeoutHeight:    packet.TimeoutHeight.String(),
+		TimeoutTimestamp: ibc.Nanoseconds(packet.TimeoutTimestamp),
+	}
+}
+
 func (c CosmosChain) FindTxs(ctx context.Context, height uint64, interfaceRegistry codectypes.InterfaceRegistry) ([]blockdb.Tx, error) {
 	h := int64(height)
 	var eg errgroup.Group
@@ -383,12 +375,12 @@ func (c CosmosChain) FindTxs(ctx context.Context, height uint64, interfaceRegist
 
 		sdkTx, err := decodeTX(interfaceRegistry, tx)
 		if err != nil {
-			fmt.Println("Failed to decode tx", zap.Uint64("height", height), zap.Error(err))
+			c.Log().Info("Failed to decode tx", zap.Uint64("height", height), zap.Error(err))
 			continue
 		}
 		b, err := encodeTxToJSON(interfaceRegistry, sdkTx)
 		if err != nil {
-			fmt.Println("Failed to marshal tx to json", zap.Uint64("height", height), zap.Error(err))
+			c.Log().Info("Failed to marshal tx to json", zap.Uint64("height", height), zap.Error(err))
 			continue
 		}
 		newTx.Data = b
@@ -454,103 +446,3 @@ func (c CosmosChain) FindTxs(ctx context.Context, height uint64, interfaceRegist
 
 	return txs, nil
 }
-
-func (c *CosmosChain) QueryRollappState(rollappName string, onlyFinalized bool) (*dymension.RollappState, error) {
-
-	command := []string{
-		"q", "rollapp", "state", rollappName, "--node", "https://" + c.RPCAddr, "--output", "json"}
-
-	if onlyFinalized {
-		command = append(command, "--finalized")
-	}
-
-	// Create the command
-	cmd := exec.Command(c.Bin, command...)
-	fmt.Println(cmd)
-	// Run the command and get the output
-	output, err := cmd.Output()
-	if err != nil {
-		fmt.Println("Error executing command:", err)
-		return nil, err
-	}
-
-	// Print the output
-	fmt.Println(string(output))
-	var rollappState dymension.RollappState
-	err = json.Unmarshal(output, &rollappState)
-	if err != nil {
-		return nil, err
-	}
-	return &rollappState, nil
-}
-
-func (c *CosmosChain) FinalizedRollappStateHeight(rollappName string) (uint64, error) {
-	rollappState, err := c.QueryRollappState(rollappName, true)
-	if err != nil {
-		return 0, err
-	}
-
-	if len(rollappState.StateInfo.BlockDescriptors.BD) == 0 {
-		return 0, fmt.Errorf("no block descriptors found for rollapp %s", rollappName)
-	}
-
-	lastBD := rollappState.StateInfo.BlockDescriptors.BD[len(rollappState.StateInfo.BlockDescriptors.BD)-1]
-	parsedHeight, err := strconv.ParseUint(lastBD.Height, 10, 64)
-	if err != nil {
-		return 0, err
-	}
-	return parsedHeight, nil
-}
-
-func (c *CosmosChain) FinalizedRollappDymHeight(rollappName string) (uint64, error) {
-	rollappState, err := c.QueryRollappState(rollappName, true)
-	if err != nil {
-		return 0, err
-	}
-
-	parsedHeight, err := strconv.ParseUint(rollappState.StateInfo.CreationHeight, 10, 64)
-	if err != nil {
-		return 0, err
-	}
-	return parsedHeight, nil
-}
-
-func (c *CosmosChain) WaitUntilRollappHeightIsFinalized(ctx context.Context, rollappChainID string, targetHeight uint64, timeoutSecs int) (bool, error) {
-	startTime := time.Now()
-	timeout := time.Duration(timeoutSecs) * time.Second
-
-	for {
-		select {
-		case <-ctx.Done():
-			return false, ctx.Err()
-		case <-time.After(timeout):
-			return false, fmt.Errorf("specified rollapp height %d not found within the timeout", targetHeight)
-		default:
-			rollappState, err := c.QueryRollappState(rollappChainID, true)
-			if err != nil {
-				if time.Since(startTime) < timeout {
-					time.Sleep(10 * time.Second)
-					continue
-				} else {
-					return false, fmt.Errorf("error querying rollapp state: %v", err)
-				}
-			}
-
-			for _, bd := range rollappState.StateInfo.BlockDescriptors.BD {
-				height, err := strconv.ParseUint(bd.Height, 10, 64)
-				if err != nil {
-					continue
-				}
-				if height == targetHeight {
-					return true, nil
-				}
-			}
-
-			if time.Since(startTime)+2*time.Second < timeout {
-				time.Sleep(10 * time.Second)
-			} else {
-				return false, fmt.Errorf("specified rollapp height %d not found within the timeout", targetHeight)
-			}
-		}
-	}
-}
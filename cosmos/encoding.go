@@ -0,0 +1,72 @@
+package cosmos
+
+import (
+	"sync"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/codec"
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	"github.com/cosmos/cosmos-sdk/std"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	authtx "github.com/cosmos/cosmos-sdk/x/auth/tx"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	vestingtypes "github.com/cosmos/cosmos-sdk/x/auth/vesting/types"
+	bankTypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	transfertypes "github.com/cosmos/ibc-go/v7/modules/apps/transfer/types"
+	ibctypes "github.com/cosmos/ibc-go/v7/modules/core/types"
+	ibctm "github.com/cosmos/ibc-go/v7/modules/light-clients/07-tendermint"
+	hubtypes "github.com/decentrio/e2e-testing-live/cosmos/hub/types"
+	ethcodec "github.com/evmos/ethermint/crypto/codec"
+	ethermint "github.com/evmos/ethermint/types"
+)
+
+// EncodingConfig bundles the codecs needed to build, sign and decode
+// transactions of the hub and its rollapps.
+type EncodingConfig struct {
+	InterfaceRegistry codectypes.InterfaceRegistry
+	Codec             codec.Codec
+	TxConfig          client.TxConfig
+	Amino             *codec.LegacyAmino
+}
+
+var (
+	encodingOnce sync.Once
+	encoding     EncodingConfig
+)
+
+// DefaultEncoding returns a process-wide EncodingConfig built by MakeEncodingConfig.
+func DefaultEncoding() EncodingConfig {
+	encodingOnce.Do(func() {
+		encoding = MakeEncodingConfig()
+	})
+	return encoding
+}
+
+// MakeEncodingConfig registers the sdk, ibc, ethermint and hub types used by the tests.
+func MakeEncodingConfig() EncodingConfig {
+	amino := codec.NewLegacyAmino()
+	registry := codectypes.NewInterfaceRegistry()
+
+	// ethermint's RegisterCrypto also registers the sdk key types, so it
+	// replaces std.RegisterLegacyAminoCodec here.
+	sdk.RegisterLegacyAminoCodec(amino)
+	ethcodec.RegisterCrypto(amino)
+	std.RegisterInterfaces(registry)
+	authtypes.RegisterInterfaces(registry)
+	vestingtypes.RegisterInterfaces(registry)
+	bankTypes.RegisterInterfaces(registry)
+	transfertypes.RegisterInterfaces(registry)
+	ibctypes.RegisterInterfaces(registry)
+	ibctm.RegisterInterfaces(registry)
+	ethcodec.RegisterInterfaces(registry)
+	ethermint.RegisterInterfaces(registry)
+	hubtypes.RegisterInterfaces(registry)
+
+	cdc := codec.NewProtoCodec(registry)
+	return EncodingConfig{
+		InterfaceRegistry: registry,
+		Codec:             cdc,
+		TxConfig:          authtx.NewTxConfig(cdc, authtx.DefaultSignModes),
+		Amino:             amino,
+	}
+}
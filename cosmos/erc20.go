@@ -0,0 +1,417 @@
+package cosmos
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/evmos/ethermint/crypto/ethsecp256k1"
+)
+
+// erc20ABI is the standard ERC20 interface.
+const erc20ABI = `[
+	{"type":"function","name":"name","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"string"}]},
+	{"type":"function","name":"symbol","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"string"}]},
+	{"type":"function","name":"decimals","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint8"}]},
+	{"type":"function","name":"totalSupply","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"balanceOf","stateMutability":"view","inputs":[{"name":"account","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"allowance","stateMutability":"view","inputs":[{"name":"owner","type":"address"},{"name":"spender","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"transfer","stateMutability":"nonpayable","inputs":[{"name":"to","type":"address"},{"name":"amount","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]},
+	{"type":"function","name":"approve","stateMutability":"nonpayable","inputs":[{"name":"spender","type":"address"},{"name":"amount","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]},
+	{"type":"function","name":"transferFrom","stateMutability":"nonpayable","inputs":[{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"amount","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]},
+	{"type":"event","name":"Transfer","anonymous":false,"inputs":[{"name":"from","type":"address","indexed":true},{"name":"to","type":"address","indexed":true},{"name":"value","type":"uint256","indexed":false}]},
+	{"type":"event","name":"Approval","anonymous":false,"inputs":[{"name":"owner","type":"address","indexed":true},{"name":"spender","type":"address","indexed":true},{"name":"value","type":"uint256","indexed":false}]}
+]`
+
+// ERC20ABI is the parsed standard ERC20 interface.
+var ERC20ABI = func() abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(erc20ABI))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}()
+
+// ERC20 is a client of an ERC20 contract over the EVM JSON-RPC of a chain.
+// Addresses may be given in hex or bech32 form. Reads take the height to
+// query, the latest block when nil.
+type ERC20 struct {
+	Contract common.Address
+
+	client   *ethclient.Client
+	shared   bool
+	contract *bind.BoundContract
+}
+
+// NewERC20 binds the ERC20 contract over the shared client of jsonrpc, see
+// EthClient.
+func NewERC20(ctx context.Context, jsonrpc, contract string) (*ERC20, error) {
+	addr, err := evmAddress(contract)
+	if err != nil {
+		return nil, fmt.Errorf("erc20 contract: %w", err)
+	}
+	client, err := EthClient(ctx, jsonrpc)
+	if err != nil {
+		return nil, err
+	}
+	erc20 := NewERC20WithClient(client, addr)
+	erc20.shared = true
+	return erc20, nil
+}
+
+// NewERC20WithClient binds the ERC20 contract at addr over client. Closing
+// the ERC20 closes client.
+func NewERC20WithClient(client *ethclient.Client, addr common.Address) *ERC20 {
+	return &ERC20{
+		Contract: addr,
+		client:   client,
+		contract: bind.NewBoundContract(addr, ERC20ABI, client, client, client),
+	}
+}
+
+// Close closes the JSON-RPC client, unless it is shared.
+func (e *ERC20) Close() {
+	if !e.shared {
+		e.client.Close()
+	}
+}
+
+// Client returns the JSON-RPC client of the ERC20.
+func (e *ERC20) Client() *ethclient.Client {
+	return e.client
+}
+
+func (e *ERC20) call(ctx context.Context, height *big.Int, method string, args ...any) (any, error) {
+	var out []any
+	err := e.contract.Call(&bind.CallOpts{Context: ctx, BlockNumber: height}, &out, method, args...)
+	if errors.Is(err, bind.ErrNoCode) {
+		return nil, fmt.Errorf("%s of %s: %w, is the contract deployed?", method, e.Contract, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s of %s: %w", method, e.Contract, err)
+	}
+	if len(out) != 1 {
+		return nil, fmt.Errorf("%s of %s: %d results, expected 1", method, e.Contract, len(out))
+	}
+	return out[0], nil
+}
+
+func (e *ERC20) callBig(ctx context.Context, height *big.Int, method string, args ...any) (*big.Int, error) {
+	out, err := e.call(ctx, height, method, args...)
+	if err != nil {
+		return nil, err
+	}
+	return *abi.ConvertType(out, new(*big.Int)).(**big.Int), nil
+}
+
+// BalanceOf returns holder's balance.
+func (e *ERC20) BalanceOf(ctx context.Context, holder string, height *big.Int) (*big.Int, error) {
+	addr, err := evmAddress(holder)
+	if err != nil {
+		return nil, err
+	}
+	return e.callBig(ctx, height, "balanceOf", addr)
+}
+
+// TotalSupply returns the amount of tokens in existence.
+func (e *ERC20) TotalSupply(ctx context.Context, height *big.Int) (*big.Int, error) {
+	return e.callBig(ctx, height, "totalSupply")
+}
+
+// Allowance returns how many of owner's tokens spender may still transfer.
+func (e *ERC20) Allowance(ctx context.Context, owner, spender string, height *big.Int) (*big.Int, error) {
+	addrs, err := evmAddresses(owner, spender)
+	if err != nil {
+		return nil, err
+	}
+	return e.callBig(ctx, height, "allowance", addrs[0], addrs[1])
+}
+
+// Decimals returns the number of decimals of the token's display unit.
+func (e *ERC20) Decimals(ctx context.Context) (uint8, error) {
+	out, err := e.call(ctx, nil, "decimals")
+	if err != nil {
+		return 0, err
+	}
+	return *abi.ConvertType(out, new(uint8)).(*uint8), nil
+}
+
+// Symbol returns the token's symbol.
+func (e *ERC20) Symbol(ctx context.Context) (string, error) {
+	out, err := e.call(ctx, nil, "symbol")
+	if err != nil {
+		return "", err
+	}
+	return *abi.ConvertType(out, new(string)).(*string), nil
+}
+
+// Name returns the token's name.
+func (e *ERC20) Name(ctx context.Context) (string, error) {
+	out, err := e.call(ctx, nil, "name")
+	if err != nil {
+		return "", err
+	}
+	return *abi.ConvertType(out, new(string)).(*string), nil
+}
+
+// Transfer sends amount of the signer's tokens to to.
+func (e *ERC20) Transfer(ctx context.Context, key *ecdsa.PrivateKey, to string, amount *big.Int) (*ethtypes.Receipt, error) {
+	addr, err := evmAddress(to)
+	if err != nil {
+		return nil, err
+	}
+	return e.transact(ctx, key, "transfer", addr, amount)
+}
+
+// Approve allows spender to transfer up to amount of the signer's tokens.
+func (e *ERC20) Approve(ctx context.Context, key *ecdsa.PrivateKey, spender string, amount *big.Int) (*ethtypes.Receipt, error) {
+	addr, err := evmAddress(spender)
+	if err != nil {
+		return nil, err
+	}
+	return e.transact(ctx, key, "approve", addr, amount)
+}
+
+// TransferFrom sends amount of from's tokens to to, out of the allowance
+// from gave the signer.
+func (e *ERC20) TransferFrom(ctx context.Context, key *ecdsa.PrivateKey, from, to string, amount *big.Int) (*ethtypes.Receipt, error) {
+	addrs, err := evmAddresses(from, to)
+	if err != nil {
+		return nil, err
+	}
+	return e.transact(ctx, key, "transferFrom", addrs[0], addrs[1], amount)
+}
+
+// TransferAs is Transfer signed with keyName's key in chain's keyring, see
+// EthKey.
+func (e *ERC20) TransferAs(ctx context.Context, chain CosmosChain, keyName, to string, amount *big.Int) (*ethtypes.Receipt, error) {
+	key, err := chain.EthKey(keyName)
+	if err != nil {
+		return nil, err
+	}
+	return e.Transfer(ctx, key, to, amount)
+}
+
+// ApproveAs is Approve signed with keyName's key in chain's keyring.
+func (e *ERC20) ApproveAs(ctx context.Context, chain CosmosChain, keyName, spender string, amount *big.Int) (*ethtypes.Receipt, error) {
+	key, err := chain.EthKey(keyName)
+	if err != nil {
+		return nil, err
+	}
+	return e.Approve(ctx, key, spender, amount)
+}
+
+// TransferFromAs is TransferFrom signed with keyName's key in chain's
+// keyring.
+func (e *ERC20) TransferFromAs(ctx context.Context, chain CosmosChain, keyName, from, to string, amount *big.Int) (*ethtypes.Receipt, error) {
+	key, err := chain.EthKey(keyName)
+	if err != nil {
+		return nil, err
+	}
+	return e.TransferFrom(ctx, key, from, to, amount)
+}
+
+// transact signs a call of method with key, sends it and waits up to
+// DefaultTxTimeout for its receipt. It fails with an *EVMTxFailedError if
+// the tx reverted.
+func (e *ERC20) transact(ctx context.Context, key *ecdsa.PrivateKey, method string, args ...any) (*ethtypes.Receipt, error) {
+	if key == nil {
+		return nil, fmt.Errorf("%s of %s: no signing key", method, e.Contract)
+	}
+	chainID, err := e.client.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("query evm chain id: %w", err)
+	}
+	opts, err := bind.NewKeyedTransactorWithChainID(key, chainID)
+	if err != nil {
+		return nil, err
+	}
+	opts.Context = ctx
+	tx, err := e.contract.Transact(opts, method, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s of %s: %w", method, e.Contract, err)
+	}
+	receipt, err := WaitForEVMReceipt(ctx, e.client, tx.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("%s of %s: %w", method, e.Contract, err)
+	}
+	if receipt.Status != ethtypes.ReceiptStatusSuccessful {
+		return receipt, &EVMTxFailedError{TxHash: tx.Hash(), Method: method, Receipt: receipt}
+	}
+	return receipt, nil
+}
+
+// EVMTxFailedError is returned when an EVM tx is included but reverted.
+type EVMTxFailedError struct {
+	TxHash  common.Hash
+	Method  string
+	Receipt *ethtypes.Receipt
+}
+
+func (e *EVMTxFailedError) Error() string {
+	return fmt.Sprintf("evm tx %s (%s) reverted in block %s", e.TxHash, e.Method, e.Receipt.BlockNumber)
+}
+
+// WaitForEVMReceipt polls the receipt of the EVM tx hash every
+// TxPollInterval until it is found, for up to DefaultTxTimeout.
+func WaitForEVMReceipt(ctx context.Context, client *ethclient.Client, hash common.Hash) (*ethtypes.Receipt, error) {
+	ctx, cancel := context.WithTimeout(ctx, DefaultTxTimeout)
+	defer cancel()
+	for {
+		receipt, err := client.TransactionReceipt(ctx, hash)
+		if err == nil {
+			return receipt, nil
+		}
+		if !errors.Is(err, ethereum.NotFound) {
+			return nil, fmt.Errorf("evm tx %s receipt: %w", hash, err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("evm tx %s not included: %w", hash, ctx.Err())
+		case <-time.After(TxPollInterval):
+		}
+	}
+}
+
+// ERC20Transfer is a Transfer event emitted by an ERC20 contract.
+type ERC20Transfer struct {
+	From  common.Address
+	To    common.Address
+	Value *big.Int
+
+	BlockNumber uint64
+	TxHash      common.Hash
+	LogIndex    uint
+}
+
+// TransferFilter selects Transfer events. Empty fields match any account
+// and a nil ToBlock the latest block.
+type TransferFilter struct {
+	FromBlock, ToBlock *big.Int
+	// From and To are the sender and recipient, in hex or bech32 form.
+	From, To []string
+}
+
+// Transfers returns the Transfer events of the contract matching filter,
+// in the order they were emitted.
+func (e *ERC20) Transfers(ctx context.Context, filter TransferFilter) ([]ERC20Transfer, error) {
+	from, err := evmAddresses(filter.From...)
+	if err != nil {
+		return nil, err
+	}
+	to, err := evmAddresses(filter.To...)
+	if err != nil {
+		return nil, err
+	}
+	topics, err := abi.MakeTopics(
+		[]any{ERC20ABI.Events["Transfer"].ID},
+		addressesAny(from),
+		addressesAny(to),
+	)
+	if err != nil {
+		return nil, err
+	}
+	fromBlock := filter.FromBlock
+	if fromBlock == nil {
+		fromBlock = new(big.Int)
+	}
+	logs, err := e.client.FilterLogs(ctx, ethereum.FilterQuery{
+		FromBlock: fromBlock,
+		ToBlock:   filter.ToBlock,
+		Addresses: []common.Address{e.Contract},
+		Topics:    topics,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("transfer logs of %s: %w", e.Contract, err)
+	}
+
+	transfers := make([]ERC20Transfer, 0, len(logs))
+	for _, log := range logs {
+		transfer, err := ParseERC20Transfer(log)
+		if err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, transfer)
+	}
+	return transfers, nil
+}
+
+// ParseERC20Transfer decodes an ERC20 Transfer log, e.g. one of a receipt.
+func ParseERC20Transfer(log ethtypes.Log) (ERC20Transfer, error) {
+	event := ERC20ABI.Events["Transfer"]
+	if len(log.Topics) != 3 || log.Topics[0] != event.ID {
+		return ERC20Transfer{}, fmt.Errorf("log %d of tx %s is not an ERC20 Transfer", log.Index, log.TxHash)
+	}
+	values, err := event.Inputs.NonIndexed().Unpack(log.Data)
+	if err != nil || len(values) != 1 {
+		return ERC20Transfer{}, fmt.Errorf("decode Transfer log %d of tx %s: %v", log.Index, log.TxHash, err)
+	}
+	return ERC20Transfer{
+		From:        common.BytesToAddress(log.Topics[1].Bytes()),
+		To:          common.BytesToAddress(log.Topics[2].Bytes()),
+		Value:       *abi.ConvertType(values[0], new(*big.Int)).(**big.Int),
+		BlockNumber: log.BlockNumber,
+		TxHash:      log.TxHash,
+		LogIndex:    log.Index,
+	}, nil
+}
+
+// EthKey returns the private key of keyName from the chain's keyring, for
+// signing EVM txs. The key must be an eth_secp256k1 key.
+func (c CosmosChain) EthKey(keyName string) (*ecdsa.PrivateKey, error) {
+	if c.Keyring == nil {
+		return nil, fmt.Errorf("chain %s has no keyring", c.ChainID)
+	}
+	record, err := c.Keyring.Key(keyName)
+	if err != nil {
+		return nil, err
+	}
+	local := record.GetLocal()
+	if local == nil || local.PrivKey == nil {
+		return nil, fmt.Errorf("key %s has no private key in the keyring", keyName)
+	}
+	privKey, ok := local.PrivKey.GetCachedValue().(*ethsecp256k1.PrivKey)
+	if !ok {
+		return nil, fmt.Errorf("key %s is a %s key, not %s", keyName, local.PrivKey.TypeUrl, ethsecp256k1.KeyType)
+	}
+	return privKey.ToECDSA()
+}
+
+// evmAddress converts a hex or bech32 address to its EVM form.
+func evmAddress(addr string) (common.Address, error) {
+	addrs, err := GetEvmAddressFromAnyFormatAddress(addr)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return addrs[0], nil
+}
+
+func evmAddresses(addrs ...string) ([]common.Address, error) {
+	out := make([]common.Address, 0, len(addrs))
+	for _, addr := range addrs {
+		evmAddr, err := evmAddress(addr)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, evmAddr)
+	}
+	return out, nil
+}
+
+func addressesAny(addrs []common.Address) []any {
+	out := make([]any, len(addrs))
+	for i, addr := range addrs {
+		out[i] = addr
+	}
+	return out
+}
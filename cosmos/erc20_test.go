@@ -0,0 +1,388 @@
+package cosmos
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	"github.com/cosmos/cosmos-sdk/crypto/keyring"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	ethhd "github.com/evmos/ethermint/crypto/hd"
+	ethermint "github.com/evmos/ethermint/types"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeEVM serves the eth namespace of a chain holding a single ERC20
+// contract, mining every tx in a block of its own.
+type fakeEVM struct {
+	t        *testing.T
+	chainID  *big.Int
+	contract common.Address
+
+	mu         sync.Mutex
+	height     uint64
+	balances   map[common.Address]*big.Int
+	allowances map[[2]common.Address]*big.Int
+	nonces     map[common.Address]uint64
+	receipts   map[common.Hash]*ethtypes.Receipt
+	logs       []*ethtypes.Log
+}
+
+type fakeCallArgs struct {
+	From *common.Address `json:"from"`
+	To   *common.Address `json:"to"`
+	Data hexutil.Bytes   `json:"data"`
+}
+
+type fakeFilter struct {
+	FromBlock *hexutil.Big     `json:"fromBlock"`
+	ToBlock   string           `json:"toBlock"`
+	Address   []common.Address `json:"address"`
+	Topics    [][]common.Hash  `json:"topics"`
+}
+
+func serveFakeEVM(t *testing.T, contract common.Address, balances map[common.Address]int64) (*fakeEVM, string) {
+	evm := &fakeEVM{
+		t:          t,
+		chainID:    big.NewInt(100004),
+		contract:   contract,
+		height:     1,
+		balances:   map[common.Address]*big.Int{},
+		allowances: map[[2]common.Address]*big.Int{},
+		nonces:     map[common.Address]uint64{},
+		receipts:   map[common.Hash]*ethtypes.Receipt{},
+	}
+	for addr, balance := range balances {
+		evm.balances[addr] = big.NewInt(balance)
+	}
+	server := rpc.NewServer()
+	require.NoError(t, server.RegisterName("eth", evm))
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(func() {
+		httpServer.Close()
+		server.Stop()
+	})
+	return evm, httpServer.URL
+}
+
+func (e *fakeEVM) balance(addr common.Address) *big.Int {
+	if b, ok := e.balances[addr]; ok {
+		return b
+	}
+	return new(big.Int)
+}
+
+func (e *fakeEVM) allowance(owner, spender common.Address) *big.Int {
+	if a, ok := e.allowances[[2]common.Address{owner, spender}]; ok {
+		return a
+	}
+	return new(big.Int)
+}
+
+func (e *fakeEVM) ChainId() *hexutil.Big { return (*hexutil.Big)(e.chainID) }
+
+func (e *fakeEVM) GasPrice() *hexutil.Big { return (*hexutil.Big)(big.NewInt(7)) }
+
+func (e *fakeEVM) EstimateGas(fakeCallArgs) hexutil.Uint64 { return 50_000 }
+
+func (e *fakeEVM) GetCode(addr common.Address, _ string) hexutil.Bytes {
+	if addr == e.contract {
+		return hexutil.Bytes{0x60, 0x80}
+	}
+	return nil
+}
+
+func (e *fakeEVM) GetTransactionCount(addr common.Address, _ string) hexutil.Uint64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return hexutil.Uint64(e.nonces[addr])
+}
+
+// GetBlockByNumber returns a header without base fee, so that txs are legacy.
+func (e *fakeEVM) GetBlockByNumber(string, bool) *ethtypes.Header {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return &ethtypes.Header{Number: new(big.Int).SetUint64(e.height), Difficulty: new(big.Int), Time: uint64(time.Now().Unix())}
+}
+
+func (e *fakeEVM) Call(args fakeCallArgs, _ string) (hexutil.Bytes, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if args.To == nil || *args.To != e.contract {
+		return nil, nil
+	}
+	method, err := ERC20ABI.MethodById(args.Data)
+	if err != nil {
+		return nil, err
+	}
+	in, err := method.Inputs.Unpack(args.Data[4:])
+	if err != nil {
+		return nil, err
+	}
+	var out any
+	switch method.Name {
+	case "balanceOf":
+		out = e.balance(in[0].(common.Address))
+	case "allowance":
+		out = e.allowance(in[0].(common.Address), in[1].(common.Address))
+	case "totalSupply":
+		total := new(big.Int)
+		for _, b := range e.balances {
+			total.Add(total, b)
+		}
+		out = total
+	case "decimals":
+		out = uint8(18)
+	case "symbol":
+		out = "WROLX"
+	case "name":
+		out = "Wrapped rolx"
+	default:
+		return nil, errors.New("execution reverted")
+	}
+	return method.Outputs.Pack(out)
+}
+
+func (e *fakeEVM) SendRawTransaction(raw hexutil.Bytes) (common.Hash, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	tx := new(ethtypes.Transaction)
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		return common.Hash{}, err
+	}
+	sender, err := ethtypes.Sender(ethtypes.LatestSignerForChainID(e.chainID), tx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if tx.Nonce() != e.nonces[sender] {
+		return common.Hash{}, errors.New("invalid nonce")
+	}
+	e.nonces[sender]++
+	e.height++
+
+	receipt := &ethtypes.Receipt{
+		Type:        tx.Type(),
+		Status:      ethtypes.ReceiptStatusSuccessful,
+		TxHash:      tx.Hash(),
+		GasUsed:     tx.Gas(),
+		Logs:        []*ethtypes.Log{},
+		BlockNumber: new(big.Int).SetUint64(e.height),
+	}
+	if logs, ok := e.execute(sender, tx.Data()); ok {
+		for _, log := range logs {
+			log.TxHash = tx.Hash()
+		}
+		receipt.Logs = logs
+		e.logs = append(e.logs, logs...)
+	} else {
+		receipt.Status = ethtypes.ReceiptStatusFailed
+	}
+	receipt.Bloom = ethtypes.CreateBloom(ethtypes.Receipts{receipt})
+	e.receipts[tx.Hash()] = receipt
+	return tx.Hash(), nil
+}
+
+// execute applies an ERC20 tx of sender, reporting false if it reverts.
+func (e *fakeEVM) execute(sender common.Address, data []byte) ([]*ethtypes.Log, bool) {
+	method, err := ERC20ABI.MethodById(data)
+	if err != nil {
+		return nil, false
+	}
+	in, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return nil, false
+	}
+	move := func(from, to common.Address, amount *big.Int) bool {
+		if e.balance(from).Cmp(amount) < 0 {
+			return false
+		}
+		e.balances[from] = new(big.Int).Sub(e.balance(from), amount)
+		e.balances[to] = new(big.Int).Add(e.balance(to), amount)
+		return true
+	}
+	event := func(name string, a, b common.Address, value *big.Int) []*ethtypes.Log {
+		data, err := ERC20ABI.Events[name].Inputs.NonIndexed().Pack(value)
+		require.NoError(e.t, err)
+		return []*ethtypes.Log{{
+			Address:     e.contract,
+			Topics:      []common.Hash{ERC20ABI.Events[name].ID, common.BytesToHash(a.Bytes()), common.BytesToHash(b.Bytes())},
+			Data:        data,
+			BlockNumber: e.height,
+		}}
+	}
+
+	switch method.Name {
+	case "transfer":
+		to, amount := in[0].(common.Address), in[1].(*big.Int)
+		if !move(sender, to, amount) {
+			return nil, false
+		}
+		return event("Transfer", sender, to, amount), true
+	case "approve":
+		spender, amount := in[0].(common.Address), in[1].(*big.Int)
+		e.allowances[[2]common.Address{sender, spender}] = amount
+		return event("Approval", sender, spender, amount), true
+	case "transferFrom":
+		from, to, amount := in[0].(common.Address), in[1].(common.Address), in[2].(*big.Int)
+		allowance := e.allowance(from, sender)
+		if allowance.Cmp(amount) < 0 || !move(from, to, amount) {
+			return nil, false
+		}
+		e.allowances[[2]common.Address{from, sender}] = new(big.Int).Sub(allowance, amount)
+		return event("Transfer", from, to, amount), true
+	}
+	return nil, false
+}
+
+func (e *fakeEVM) GetTransactionReceipt(hash common.Hash) *ethtypes.Receipt {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.receipts[hash]
+}
+
+func (e *fakeEVM) GetLogs(filter fakeFilter) []*ethtypes.Log {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	logs := []*ethtypes.Log{}
+	for _, log := range e.logs {
+		if filter.FromBlock != nil && log.BlockNumber < filter.FromBlock.ToInt().Uint64() {
+			continue
+		}
+		if to, err := hexutil.DecodeUint64(filter.ToBlock); err == nil && log.BlockNumber > to {
+			continue
+		}
+		if !topicsMatch(filter.Topics, log.Topics) {
+			continue
+		}
+		logs = append(logs, log)
+	}
+	return logs
+}
+
+func topicsMatch(filter [][]common.Hash, topics []common.Hash) bool {
+	for i, alternatives := range filter {
+		if len(alternatives) == 0 {
+			continue
+		}
+		if i >= len(topics) {
+			return false
+		}
+		found := false
+		for _, topic := range alternatives {
+			found = found || topic == topics[i]
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func TestERC20(t *testing.T) {
+	defer func(interval time.Duration) { TxPollInterval = interval }(TxPollInterval)
+	TxPollInterval = 5 * time.Millisecond
+
+	kr := NewInMemoryKeyring()
+	for _, name := range []string{"alice", "bob"} {
+		_, _, err := kr.NewMnemonic(name, keyring.English, ethermint.BIP44HDPath, keyring.DefaultBIP39Passphrase, ethhd.EthSecp256k1)
+		require.NoError(t, err)
+	}
+	_, _, err := kr.NewMnemonic("hub", keyring.English, sdk.FullFundraiserPath, keyring.DefaultBIP39Passphrase, hd.Secp256k1)
+	require.NoError(t, err)
+	chain := CosmosChain{ChainID: "rolx_100004-1", Keyring: kr}
+	aliceKey, err := chain.EthKey("alice")
+	require.NoError(t, err)
+	bobKey, err := chain.EthKey("bob")
+	require.NoError(t, err)
+	_, err = chain.EthKey("hub")
+	require.ErrorContains(t, err, "key hub is a /cosmos.crypto.secp256k1.PrivKey key, not eth_secp256k1")
+
+	alice := ethcrypto.PubkeyToAddress(aliceKey.PublicKey)
+	bob := ethcrypto.PubkeyToAddress(bobKey.PublicKey)
+	carol := common.HexToAddress("0x00000000000000000000000000000000000000c0")
+	bobBech32 := sdk.MustBech32ifyAddressBytes("rolx", bob.Bytes())
+	contract := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	_, url := serveFakeEVM(t, contract, map[common.Address]int64{alice: 1000})
+
+	ctx := context.Background()
+	t.Cleanup(CloseEthClients)
+	_, err = NewERC20(ctx, url, "not-an-address")
+	require.Error(t, err)
+	erc20, err := NewERC20(ctx, url, contract.Hex())
+	require.NoError(t, err)
+	again, err := NewERC20(ctx, url, contract.Hex())
+	require.NoError(t, err)
+	require.Same(t, erc20.Client(), again.Client(), "one client per url")
+	again.Close()
+
+	symbol, err := erc20.Symbol(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "WROLX", symbol)
+	decimals, err := erc20.Decimals(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint8(18), decimals)
+	supply, err := erc20.TotalSupply(ctx, nil)
+	require.NoError(t, err)
+	require.Equal(t, int64(1000), supply.Int64())
+	_, err = erc20.BalanceOf(ctx, "rolx1bad", nil)
+	require.Error(t, err, "bad addresses fail instead of panicking")
+
+	receipt, err := erc20.Transfer(ctx, aliceKey, bobBech32, big.NewInt(300))
+	require.NoError(t, err)
+	require.Len(t, receipt.Logs, 1)
+	start := receipt.BlockNumber
+	transfer, err := ParseERC20Transfer(*receipt.Logs[0])
+	require.NoError(t, err)
+	require.Equal(t, ERC20Transfer{From: alice, To: bob, Value: big.NewInt(300), BlockNumber: receipt.BlockNumber.Uint64(), TxHash: receipt.TxHash}, transfer)
+
+	_, err = erc20.ApproveAs(ctx, chain, "bob", alice.Hex(), big.NewInt(100))
+	require.NoError(t, err)
+	allowance, err := erc20.Allowance(ctx, bobBech32, alice.Hex(), nil)
+	require.NoError(t, err)
+	require.Equal(t, int64(100), allowance.Int64())
+	_, err = erc20.TransferFromAs(ctx, chain, "alice", bob.Hex(), carol.Hex(), big.NewInt(60))
+	require.NoError(t, err)
+	_, err = erc20.TransferAs(ctx, chain, "hub", carol.Hex(), big.NewInt(1))
+	require.ErrorContains(t, err, "not eth_secp256k1")
+
+	_, err = erc20.TransferFrom(ctx, aliceKey, bob.Hex(), carol.Hex(), big.NewInt(60))
+	var reverted *EVMTxFailedError
+	require.ErrorAs(t, err, &reverted)
+	require.Equal(t, "transferFrom", reverted.Method)
+
+	for addr, want := range map[common.Address]int64{alice: 700, bob: 240, carol: 60} {
+		balance, err := erc20.BalanceOf(ctx, addr.Hex(), nil)
+		require.NoError(t, err)
+		require.Equal(t, want, balance.Int64(), addr.Hex())
+	}
+
+	transfers, err := erc20.Transfers(ctx, TransferFilter{FromBlock: start})
+	require.NoError(t, err)
+	require.Len(t, transfers, 2)
+	require.Equal(t, []common.Address{alice, bob}, []common.Address{transfers[0].From, transfers[1].From})
+	require.Equal(t, int64(60), transfers[1].Value.Int64())
+
+	transfers, err = erc20.Transfers(ctx, TransferFilter{To: []string{carol.Hex()}})
+	require.NoError(t, err)
+	require.Len(t, transfers, 1)
+	require.Equal(t, carol, transfers[0].To)
+
+	transfers, err = erc20.Transfers(ctx, TransferFilter{FromBlock: big.NewInt(1), ToBlock: start})
+	require.NoError(t, err)
+	require.Len(t, transfers, 1, "the block range bounds the logs")
+
+	user := User{Address: bobBech32}
+	balance, err := user.GetERC20Balance(url, contract.Hex(), 0)
+	require.NoError(t, err)
+	require.Equal(t, int64(240), balance.Int64())
+}
@@ -0,0 +1,130 @@
+package cosmos
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	errorsmod "cosmossdk.io/errors"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TxFailedError is returned for a tx that was rejected by CheckTx or failed
+// during execution (non-zero Code). It matches, with errors.Is, the
+// registered cosmos-sdk error of the same codespace and code, e.g.
+// sdkerrors.ErrInsufficientFunds.
+type TxFailedError struct {
+	TxHash    string
+	Height    string
+	Codespace string
+	Code      uint32
+	RawLog    string
+}
+
+func (e *TxFailedError) Error() string {
+	return fmt.Sprintf("tx %s failed with code %d (codespace %s): %s", e.TxHash, e.Code, e.Codespace, e.RawLog)
+}
+
+func (e *TxFailedError) Is(target error) bool {
+	sdkErr, ok := target.(*errorsmod.Error)
+	return ok && sdkErr.Codespace() == e.Codespace && sdkErr.ABCICode() == e.Code
+}
+
+// CommandError is returned when the chain binary exits with a non-zero
+// code. Transactions failing simulation end up here rather than in a
+// TxFailedError, so errors.Is also matches a registered cosmos-sdk error
+// whose description the binary printed, such as ": insufficient funds".
+type CommandError struct {
+	// Args is the command line, binary first.
+	Args     []string
+	Stderr   string
+	ExitCode int
+	Err      error
+}
+
+func (e *CommandError) Error() string {
+	msg := fmt.Sprintf("%s: exit code %d", strings.Join(e.Args, " "), e.ExitCode)
+	if stderr := redact(e.Stderr); stderr != "" {
+		msg += ": " + stderr
+	}
+	return msg
+}
+
+func (e *CommandError) Unwrap() error { return e.Err }
+
+func (e *CommandError) Is(target error) bool {
+	sdkErr, ok := target.(*errorsmod.Error)
+	if !ok {
+		return false
+	}
+	desc := sdkErrorPattern(sdkErr)
+	return desc != nil && desc.MatchString(e.Stderr)
+}
+
+// sdkErrorPatterns caches the pattern of each registered sdk error matched
+// by CommandError.Is, compiled on first use.
+var sdkErrorPatterns sync.Map // *errorsmod.Error -> *regexp.Regexp
+
+// sdkErrorPattern returns the pattern matching sdkErr printed by the binary,
+// nil if it cannot be compiled. sdk errors are printed as "<context>:
+// <description>", followed by a stack reference, another ": <context>" or
+// the end of the line.
+func sdkErrorPattern(sdkErr *errorsmod.Error) *regexp.Regexp {
+	if desc, ok := sdkErrorPatterns.Load(sdkErr); ok {
+		return desc.(*regexp.Regexp)
+	}
+	desc, err := regexp.Compile(`(?m): ` + regexp.QuoteMeta(sdkErr.Error()) + `(\s*\[|:|$)`)
+	if err != nil {
+		return nil
+	}
+	actual, _ := sdkErrorPatterns.LoadOrStore(sdkErr, desc)
+	return actual.(*regexp.Regexp)
+}
+
+// NotFoundError is returned by queries for something the chain does not
+// have. It matches sdkerrors.ErrNotFound with errors.Is, and Kind if set.
+type NotFoundError struct {
+	// Resource is what was looked up, e.g. "tx 7E2F…".
+	Resource string
+	// Kind is a more specific registered error, e.g. sdkerrors.ErrKeyNotFound.
+	Kind error
+	Err  error
+}
+
+func (e *NotFoundError) Error() string {
+	if e.Err == nil {
+		return e.Resource + " not found"
+	}
+	return fmt.Sprintf("%s not found: %v", e.Resource, e.Err)
+}
+
+func (e *NotFoundError) Unwrap() error { return e.Err }
+
+func (e *NotFoundError) Is(target error) bool {
+	return target == sdkerrors.ErrNotFound || (e.Kind != nil && target == e.Kind)
+}
+
+// AsNotFound returns err as a *NotFoundError for resource if it is a gRPC
+// NotFound status or a command that printed "not found", and err unchanged
+// otherwise.
+func AsNotFound(resource string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var cmdErr *CommandError
+	switch {
+	case status.Code(err) == codes.NotFound:
+	case errors.As(err, &cmdErr) && isNotFoundOutput(cmdErr.Stderr):
+	default:
+		return err
+	}
+	return &NotFoundError{Resource: resource, Err: err}
+}
+
+func isNotFoundOutput(stderr string) bool {
+	return strings.Contains(stderr, "not found") || strings.Contains(stderr, "code = NotFound")
+}
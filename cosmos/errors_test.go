@@ -0,0 +1,79 @@
+package cosmos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestTxErrorsMatchSDKErrors(t *testing.T) {
+	fake := &ScriptedExecutor{}
+	chain := CosmosChain{Bin: "dymd", ChainID: "hub_1-1", RPCAddr: "rpc:443", Executor: fake, BroadcastMode: BroadcastSync}
+	ctx := context.Background()
+
+	// Rejected by CheckTx.
+	fake.Expect("tx", "bank", "send").Return(`{"txhash":"AB","code":5,"codespace":"sdk","raw_log":"0adym is smaller than 10adym: insufficient funds"}`)
+	res, err := chain.ExecTx(ctx, "alice", "1adym", "bank", "send", "alice", "dym1bob", "10adym")
+	require.ErrorIs(t, err, sdkerrors.ErrInsufficientFunds)
+	require.NotErrorIs(t, err, sdkerrors.ErrInsufficientFee)
+	var txErr *TxFailedError
+	require.ErrorAs(t, err, &txErr)
+	require.Equal(t, "AB", txErr.TxHash)
+	require.Equal(t, "AB", res.TxHash, "the response is returned with the error")
+
+	// Failed gas simulation: the binary exits before broadcasting.
+	fake.Expect("tx", "bank", "send").Fail(1, "Error: rpc error: code = Unknown desc = failed to execute message; message index: 0: "+
+		"spendable balance 0adym is smaller than 10adym: insufficient funds [cosmos/cosmos-sdk@v0.47.13/baseapp/baseapp.go:856] With gas wanted: '0'\n")
+	_, err = chain.ExecTx(ctx, "alice", "1adym", "bank", "send", "alice", "dym1bob", "10adym")
+	require.ErrorIs(t, err, sdkerrors.ErrInsufficientFunds)
+	require.NotErrorIs(t, err, sdkerrors.ErrInsufficientFee)
+	var cmdErr *CommandError
+	require.ErrorAs(t, err, &cmdErr)
+	require.Equal(t, 1, cmdErr.ExitCode)
+	require.Equal(t, []string{"dymd", "tx", "bank", "send"}, cmdErr.Args[:4])
+	require.Contains(t, cmdErr.Stderr, "smaller than 10adym")
+	require.ErrorContains(t, err, "dymd tx bank send alice dym1bob 10adym")
+	require.ErrorContains(t, err, "exit code 1: Error: rpc error")
+}
+
+func TestNotFoundErrors(t *testing.T) {
+	fake := &ScriptedExecutor{}
+	chain := CosmosChain{Bin: "dymd", RPCAddr: "rpc:443", Executor: fake}
+	ctx := context.Background()
+
+	fake.Expect("q", "rollapp", "show", "rolx").Fail(1, "Error: rpc error: code = NotFound desc = rpc error: code = NotFound desc = not found: key not found\n")
+	err := chain.ExecQuery(ctx, &struct{}{}, "rollapp", "show", "rolx")
+	require.ErrorIs(t, err, sdkerrors.ErrNotFound)
+	var notFound *NotFoundError
+	require.ErrorAs(t, err, &notFound)
+	require.Equal(t, "rollapp show rolx", notFound.Resource)
+	var cmdErr *CommandError
+	require.ErrorAs(t, err, &cmdErr, "the command error is kept")
+
+	fake.Expect("q", "bank", "balances").Fail(1, "Error: post failed: connection refused\n")
+	err = chain.ExecQuery(ctx, &struct{}{}, "bank", "balances", "dym1bob")
+	require.NotErrorIs(t, err, sdkerrors.ErrNotFound)
+	require.ErrorAs(t, err, &cmdErr)
+
+	fake.Expect("keys", "show", "ci").Fail(1, "Error: ci.info: key not found\n")
+	_, err = chain.Key(ctx, "ci")
+	require.ErrorIs(t, err, sdkerrors.ErrKeyNotFound)
+	require.ErrorIs(t, err, sdkerrors.ErrNotFound)
+	require.ErrorContains(t, err, "key ci not found")
+
+	err = AsNotFound("token pair of arolx", fmt.Errorf("query: %w", status.Error(codes.NotFound, "token pair not found")))
+	require.ErrorIs(t, err, sdkerrors.ErrNotFound)
+	require.ErrorAs(t, err, &notFound)
+	require.Equal(t, "token pair of arolx", notFound.Resource)
+
+	unavailable := status.Error(codes.Unavailable, "connection refused")
+	require.Same(t, unavailable, AsNotFound("token pair of arolx", unavailable))
+	require.NoError(t, AsNotFound("token pair of arolx", nil))
+	require.False(t, errors.Is(unavailable, sdkerrors.ErrNotFound))
+}
@@ -2,31 +2,180 @@ package cosmos
 
 import (
 	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
 	abcitypes "github.com/cometbft/cometbft/abci/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
 )
 
+// DecodeEvent returns e with plain-text attribute keys and values. CometBFT
+// 0.34 nodes base64 encode them, 0.37+ nodes and the blockdb path emit plain
+// strings. An event is taken as base64 when every key decodes to printable
+// text and every value decodes, so plain values that happen to be valid
+// base64 are left alone.
+func DecodeEvent(e abcitypes.Event) abcitypes.Event {
+	decoded := abcitypes.Event{Type: e.Type, Attributes: make([]abcitypes.EventAttribute, len(e.Attributes))}
+	for i, attr := range e.Attributes {
+		key, err := base64.StdEncoding.DecodeString(attr.Key)
+		if err != nil || !isPrintable(key) {
+			return e
+		}
+		value, err := base64.StdEncoding.DecodeString(attr.Value)
+		if err != nil {
+			return e
+		}
+		decoded.Attributes[i] = abcitypes.EventAttribute{Key: string(key), Value: string(value), Index: attr.Index}
+	}
+	return decoded
+}
+
+// DecodeEvents applies DecodeEvent to every event.
+func DecodeEvents(events []abcitypes.Event) []abcitypes.Event {
+	decoded := make([]abcitypes.Event, len(events))
+	for i, e := range events {
+		decoded[i] = DecodeEvent(e)
+	}
+	return decoded
+}
+
 // AttributeValue returns an event attribute value given the eventType and attribute key tuple.
 // In the event of duplicate types and keys, returns the first attribute value found.
 // If not found, returns empty string and false.
 func AttributeValue(events []abcitypes.Event, eventType, attrKey string) (string, bool) {
+	values := AttributeValues(events, eventType, attrKey)
+	if len(values) == 0 {
+		return "", false
+	}
+	return values[0], true
+}
+
+// AttributeValues returns, in order, every value of attrKey in the events of eventType.
+func AttributeValues(events []abcitypes.Event, eventType, attrKey string) []string {
+	var values []string
 	for _, event := range events {
 		if event.Type != eventType {
 			continue
 		}
-		for _, attr := range event.Attributes {
-			key, err := base64.StdEncoding.DecodeString(attr.Key)
-			if err != nil {
-				return "", false
+		for _, attr := range DecodeEvent(event).Attributes {
+			if attr.Key == attrKey {
+				values = append(values, attr.Value)
 			}
-			if string(key) == attrKey {
-				value, err := base64.StdEncoding.DecodeString(attr.Value)
-				if err != nil {
-					return "", false
-				}
-				return string(value), true
+		}
+	}
+	return values
+}
+
+// EventAttributes flattens an event's attributes into a map, see DecodeEvent.
+// The last value wins for duplicate keys.
+func EventAttributes(e abcitypes.Event) map[string]string {
+	attrs := make(map[string]string, len(e.Attributes))
+	for _, attr := range DecodeEvent(e).Attributes {
+		attrs[attr.Key] = attr.Value
+	}
+	return attrs
+}
+
+// EventsOfType returns the events of eventType, decoded.
+func EventsOfType(events []abcitypes.Event, eventType string) []abcitypes.Event {
+	var matched []abcitypes.Event
+	for _, e := range events {
+		if e.Type == eventType {
+			matched = append(matched, DecodeEvent(e))
+		}
+	}
+	return matched
+}
+
+// MsgEvents returns the decoded events emitted by the message at msgIndex of
+// a tx. SDK 0.50+ tags events with a msg_index attribute; on older SDKs the
+// events are taken from the tx logs, which group them per message.
+func MsgEvents(resp TxResponse, msgIndex int) ([]abcitypes.Event, error) {
+	index := strconv.Itoa(msgIndex)
+	var tagged []abcitypes.Event
+	taggedAny := false
+	for _, e := range DecodeEvents(resp.Events) {
+		if v, ok := attributeOf(e, msgIndexKey); ok {
+			taggedAny = true
+			if v == index {
+				tagged = append(tagged, e)
+			}
+		}
+	}
+	if taggedAny {
+		return tagged, nil
+	}
+
+	for _, log := range resp.Logs {
+		if int(log.MsgIndex) != msgIndex {
+			continue
+		}
+		events := make([]abcitypes.Event, 0, len(log.Events))
+		for _, e := range log.Events {
+			event := abcitypes.Event{Type: e.Type}
+			for _, attr := range e.Attributes {
+				event.Attributes = append(event.Attributes, abcitypes.EventAttribute{Key: attr.Key, Value: attr.Value})
 			}
+			events = append(events, event)
 		}
+		return events, nil
 	}
+	return nil, fmt.Errorf("tx %s has no events for message %d", resp.TxHash, msgIndex)
+}
+
+// msgIndexKey is the attribute SDK 0.50+ adds to the events of a message.
+const msgIndexKey = "msg_index"
 
+func attributeOf(e abcitypes.Event, key string) (string, bool) {
+	for _, attr := range e.Attributes {
+		if attr.Key == key {
+			return attr.Value, true
+		}
+	}
 	return "", false
 }
+
+func isPrintable(bz []byte) bool {
+	if len(bz) == 0 {
+		return false
+	}
+	for _, b := range bz {
+		if b < 0x20 || b > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// TxFee returns the fee paid by a tx and who paid it, read from the events
+// emitted by the ante handler. Without a fee_payer attribute, as on older
+// SDKs, the first signer is taken as the payer.
+func TxFee(resp TxResponse) (payer string, fee sdk.Coins, err error) {
+	found := false
+	for _, e := range resp.Events {
+		if e.Type != sdk.EventTypeTx {
+			continue
+		}
+		attrs := EventAttributes(e)
+		if v, ok := attrs[sdk.AttributeKeyFee]; ok {
+			if fee, err = sdk.ParseCoinsNormalized(v); err != nil {
+				return "", nil, fmt.Errorf("parse fee of tx %s: %w", resp.TxHash, err)
+			}
+			found = true
+			if p := attrs[sdk.AttributeKeyFeePayer]; p != "" {
+				payer = p
+			}
+		}
+		if accSeq, ok := attrs[sdk.AttributeKeyAccountSequence]; ok && payer == "" {
+			payer, _, _ = strings.Cut(accSeq, "/")
+		}
+	}
+	if !found {
+		return "", nil, fmt.Errorf("tx %s has no fee event", resp.TxHash)
+	}
+	if payer == "" {
+		return "", nil, fmt.Errorf("tx %s has no fee payer", resp.TxHash)
+	}
+	return payer, fee, nil
+}
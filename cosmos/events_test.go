@@ -0,0 +1,149 @@
+package cosmos
+
+import (
+	"encoding/base64"
+	"testing"
+
+	abcitypes "github.com/cometbft/cometbft/abci/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+)
+
+func b64(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) }
+
+func sendPacketAttrs(seq string) []abcitypes.EventAttribute {
+	return []abcitypes.EventAttribute{
+		{Key: "packet_data", Value: `{"amount":"10"}`},
+		{Key: "packet_timeout_height", Value: "0-0"},
+		{Key: "packet_timeout_timestamp", Value: "1700000000000000000"},
+		{Key: "packet_sequence", Value: seq},
+		{Key: "packet_src_port", Value: "transfer"},
+		{Key: "packet_src_channel", Value: "channel-0"},
+		{Key: "packet_dst_port", Value: "transfer"},
+		{Key: "packet_dst_channel", Value: "channel-7"},
+		{Key: "packet_connection", Value: "connection-0"},
+	}
+}
+
+func base64Event(e abcitypes.Event) abcitypes.Event {
+	encoded := abcitypes.Event{Type: e.Type}
+	for _, attr := range e.Attributes {
+		encoded.Attributes = append(encoded.Attributes, abcitypes.EventAttribute{Key: b64(attr.Key), Value: b64(attr.Value)})
+	}
+	return encoded
+}
+
+func TestDecodeEventEncodings(t *testing.T) {
+	plain := abcitypes.Event{Type: "message", Attributes: []abcitypes.EventAttribute{
+		{Key: "module", Value: "transfer"},
+		// A plain value that is valid base64 on its own.
+		{Key: "action", Value: "abcd"},
+	}}
+	require.Equal(t, plain, DecodeEvent(plain))
+	require.Equal(t, plain, DecodeEvent(base64Event(plain)))
+
+	// Keys that decode to binary are not base64 text.
+	binary := abcitypes.Event{Type: "x", Attributes: []abcitypes.EventAttribute{{Key: "abcd", Value: "abcd"}}}
+	require.Equal(t, binary, DecodeEvent(binary))
+
+	events := []abcitypes.Event{
+		base64Event(abcitypes.Event{Type: "transfer", Attributes: []abcitypes.EventAttribute{{Key: "amount", Value: "1adym"}}}),
+		{Type: "transfer", Attributes: []abcitypes.EventAttribute{{Key: "amount", Value: "2adym"}}},
+	}
+	require.Equal(t, []string{"1adym", "2adym"}, AttributeValues(events, "transfer", "amount"))
+	v, ok := AttributeValue(events, "transfer", "amount")
+	require.True(t, ok)
+	require.Equal(t, "1adym", v)
+	_, ok = AttributeValue(events, "transfer", "sender")
+	require.False(t, ok)
+}
+
+func TestGetIbcTxFromTxResponseEncodings(t *testing.T) {
+	event := abcitypes.Event{Type: "send_packet", Attributes: sendPacketAttrs("9")}
+	for name, e := range map[string]abcitypes.Event{"plain": event, "base64": base64Event(event)} {
+		t.Run(name, func(t *testing.T) {
+			tx, err := GetIbcTxFromTxResponse(TxResponse{Height: "12", TxHash: "AB", GasWanted: "100", Events: []abcitypes.Event{e}})
+			require.NoError(t, err)
+			require.Equal(t, uint64(9), tx.Packet.Sequence)
+			require.Equal(t, "channel-7", tx.Packet.DestChannel)
+			require.Equal(t, `{"amount":"10"}`, string(tx.Packet.Data))
+			require.EqualValues(t, 1700000000000000000, tx.Packet.TimeoutTimestamp)
+		})
+	}
+	_, err := GetIbcTxFromTxResponse(TxResponse{Height: "12", TxHash: "AB", GasWanted: "100"})
+	require.ErrorContains(t, err, "tx AB sent no packet")
+}
+
+func TestMsgEvents(t *testing.T) {
+	tagged := TxResponse{TxHash: "AB", Events: []abcitypes.Event{
+		{Type: "tx", Attributes: []abcitypes.EventAttribute{{Key: "fee", Value: "1adym"}}},
+		{Type: "send_packet", Attributes: append(sendPacketAttrs("1"), abcitypes.EventAttribute{Key: "msg_index", Value: "0"})},
+		{Type: "send_packet", Attributes: append(sendPacketAttrs("2"), abcitypes.EventAttribute{Key: "msg_index", Value: "1"})},
+	}}
+	events, err := MsgEvents(tagged, 1)
+	require.NoError(t, err)
+	packets, err := SendPacketEvents(events)
+	require.NoError(t, err)
+	require.Len(t, packets, 1)
+	require.Equal(t, uint64(2), packets[0].Packet.Sequence)
+	require.Equal(t, "connection-0", packets[0].Connection)
+
+	// SDK 0.47 groups events per message in the logs, merging events of the
+	// same type.
+	var merged sdk.StringEvent
+	merged.Type = "send_packet"
+	for _, seq := range []string{"3", "4"} {
+		for _, attr := range sendPacketAttrs(seq) {
+			merged.Attributes = append(merged.Attributes, sdk.Attribute{Key: attr.Key, Value: attr.Value})
+		}
+	}
+	logged := TxResponse{TxHash: "CD", Logs: sdk.ABCIMessageLogs{
+		{MsgIndex: 0},
+		{MsgIndex: 1, Events: sdk.StringEvents{merged}},
+	}}
+	events, err = MsgEvents(logged, 1)
+	require.NoError(t, err)
+	packets, err = SendPacketEvents(events)
+	require.NoError(t, err)
+	require.Len(t, packets, 2)
+	require.Equal(t, uint64(3), packets[0].Packet.Sequence)
+	require.Equal(t, uint64(4), packets[1].Packet.Sequence)
+
+	_, err = MsgEvents(logged, 2)
+	require.ErrorContains(t, err, "no events for message 2")
+}
+
+func TestFungibleTokenPacketEvents(t *testing.T) {
+	transfer := func(extra ...abcitypes.EventAttribute) abcitypes.Event {
+		return abcitypes.Event{Type: "fungible_token_packet", Attributes: append([]abcitypes.EventAttribute{
+			{Key: "module", Value: "transfer"},
+			{Key: "sender", Value: "dym1alice"},
+			{Key: "receiver", Value: "rolx1bob"},
+			{Key: "denom", Value: "adym"},
+			{Key: "amount", Value: "1000"},
+			{Key: "memo", Value: ""},
+		}, extra...)}
+	}
+
+	// Receiving chain.
+	recv, err := FungibleTokenPacketEvents([]abcitypes.Event{base64Event(transfer(abcitypes.EventAttribute{Key: "success", Value: "true"}))})
+	require.NoError(t, err)
+	require.Len(t, recv, 1)
+	require.True(t, recv[0].Success)
+	require.Equal(t, int64(1000), recv[0].Amount.Int64())
+	require.Equal(t, "rolx1bob", recv[0].Receiver)
+
+	// Sending chain: the outcome of the ack comes in a second event.
+	acked, err := FungibleTokenPacketEvents([]abcitypes.Event{
+		transfer(abcitypes.EventAttribute{Key: "acknowledgement", Value: "error:\"ABCI code: 5\""}),
+		{Type: "fungible_token_packet", Attributes: []abcitypes.EventAttribute{{Key: "error", Value: "ABCI code: 5"}}},
+	})
+	require.NoError(t, err)
+	require.Len(t, acked, 1)
+	require.False(t, acked[0].Success)
+	require.Equal(t, "ABCI code: 5", acked[0].Error)
+	require.NotEmpty(t, acked[0].Acknowledgement)
+
+	_, err = FungibleTokenPacketEvents([]abcitypes.Event{{Type: "fungible_token_packet", Attributes: []abcitypes.EventAttribute{{Key: "receiver", Value: "x"}}}})
+	require.ErrorContains(t, err, "invalid amount")
+}
@@ -0,0 +1,221 @@
+package cosmos
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ExecResult holds everything captured from running a chain binary.
+type ExecResult struct {
+	Stdout   []byte
+	Stderr   []byte
+	ExitCode int
+	Duration time.Duration
+}
+
+// Executor runs a chain binary with the given arguments. Implementations must
+// populate ExecResult even when the command fails; a non-zero exit code is
+// reported as an error.
+type Executor interface {
+	Exec(ctx context.Context, bin string, args ...string) (ExecResult, error)
+}
+
+// StdinExecutor is an Executor that can also feed the command's standard
+// input, which prompting subcommands such as `keys add --recover` read from.
+type StdinExecutor interface {
+	Executor
+	ExecStdin(ctx context.Context, stdin []byte, bin string, args ...string) (ExecResult, error)
+}
+
+// DefaultExecutor is used by chains that do not set an Executor.
+var DefaultExecutor Executor = LocalExecutor{}
+
+// LocalExecutor runs commands as processes on the test host.
+type LocalExecutor struct {
+	// Env is appended to the current process environment, in "KEY=value" form.
+	Env []string
+}
+
+func (e LocalExecutor) Exec(ctx context.Context, bin string, args ...string) (ExecResult, error) {
+	return e.ExecStdin(ctx, nil, bin, args...)
+}
+
+func (e LocalExecutor) ExecStdin(ctx context.Context, stdin []byte, bin string, args ...string) (ExecResult, error) {
+	cmd := exec.CommandContext(ctx, bin, args...)
+	if len(e.Env) > 0 {
+		cmd.Env = append(cmd.Environ(), e.Env...)
+	}
+	return runCmd(cmd, stdin)
+}
+
+// DockerExecutor runs commands inside an already running container via `docker exec`.
+type DockerExecutor struct {
+	Container string
+	// DockerBin is the docker CLI to use, "docker" if empty.
+	DockerBin string
+	// User optionally sets the user the command runs as inside the container.
+	User string
+}
+
+func (e DockerExecutor) Exec(ctx context.Context, bin string, args ...string) (ExecResult, error) {
+	return e.ExecStdin(ctx, nil, bin, args...)
+}
+
+func (e DockerExecutor) ExecStdin(ctx context.Context, stdin []byte, bin string, args ...string) (ExecResult, error) {
+	dockerBin := e.DockerBin
+	if dockerBin == "" {
+		dockerBin = "docker"
+	}
+	dockerArgs := []string{"exec"}
+	if stdin != nil {
+		dockerArgs = append(dockerArgs, "-i")
+	}
+	if e.User != "" {
+		dockerArgs = append(dockerArgs, "--user", e.User)
+	}
+	dockerArgs = append(dockerArgs, e.Container, bin)
+	dockerArgs = append(dockerArgs, args...)
+	return runCmd(exec.CommandContext(ctx, dockerBin, dockerArgs...), stdin)
+}
+
+func runCmd(cmd *exec.Cmd, stdin []byte) (ExecResult, error) {
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	start := time.Now()
+	err := cmd.Run()
+	res := ExecResult{
+		Stdout:   stdout.Bytes(),
+		Stderr:   stderr.Bytes(),
+		Duration: time.Since(start),
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		res.ExitCode = exitErr.ExitCode()
+	} else if err != nil {
+		res.ExitCode = -1
+	}
+	return res, err
+}
+
+// ScriptedExecutor is a fake Executor for unit tests. Each expected command is
+// registered with Expect and answered in registration order; any command that
+// was not expected fails the call.
+type ScriptedExecutor struct {
+	mu     sync.Mutex
+	steps  []*ScriptedStep
+	calls  [][]string
+	stdins [][]byte
+}
+
+// ScriptedStep is a canned answer to a command whose arguments start with Prefix.
+type ScriptedStep struct {
+	Prefix []string
+	Result ExecResult
+	Err    error
+
+	repeat bool
+	used   bool
+}
+
+// Expect registers an answer for the next command whose arguments (binary
+// excluded) start with prefix. The step answers with empty output until
+// Return or Fail is called on it.
+func (e *ScriptedExecutor) Expect(prefix ...string) *ScriptedStep {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	s := &ScriptedStep{Prefix: prefix}
+	e.steps = append(e.steps, s)
+	return s
+}
+
+// Return makes the step succeed with stdout.
+func (s *ScriptedStep) Return(stdout string) *ScriptedStep {
+	s.Result = ExecResult{Stdout: []byte(stdout)}
+	s.Err = nil
+	return s
+}
+
+// Fail makes the step exit with exitCode and stderr.
+func (s *ScriptedStep) Fail(exitCode int, stderr string) *ScriptedStep {
+	s.Result = ExecResult{Stderr: []byte(stderr), ExitCode: exitCode}
+	s.Err = fmt.Errorf("exit status %d", exitCode)
+	return s
+}
+
+// Repeatedly lets the step answer any number of matching commands.
+func (s *ScriptedStep) Repeatedly() *ScriptedStep {
+	s.repeat = true
+	return s
+}
+
+func (e *ScriptedExecutor) Exec(ctx context.Context, bin string, args ...string) (ExecResult, error) {
+	return e.ExecStdin(ctx, nil, bin, args...)
+}
+
+func (e *ScriptedExecutor) ExecStdin(ctx context.Context, stdin []byte, bin string, args ...string) (ExecResult, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.calls = append(e.calls, append([]string{bin}, args...))
+	e.stdins = append(e.stdins, stdin)
+	if err := ctx.Err(); err != nil {
+		return ExecResult{ExitCode: -1}, err
+	}
+	for _, s := range e.steps {
+		if s.used || !hasPrefix(args, s.Prefix) {
+			continue
+		}
+		s.used = !s.repeat
+		return s.Result, s.Err
+	}
+	return ExecResult{ExitCode: -1}, fmt.Errorf("unexpected command: %s %s", bin, strings.Join(args, " "))
+}
+
+// Calls returns every command run so far, binary first.
+func (e *ScriptedExecutor) Calls() [][]string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([][]string(nil), e.calls...)
+}
+
+// Stdin returns what the i-th command run received on its standard input.
+func (e *ScriptedExecutor) Stdin(i int) []byte {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.stdins[i]
+}
+
+// Pending returns the prefixes of steps that have not been used yet.
+func (e *ScriptedExecutor) Pending() [][]string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var pending [][]string
+	for _, s := range e.steps {
+		if !s.used && !s.repeat {
+			pending = append(pending, s.Prefix)
+		}
+	}
+	return pending
+}
+
+func hasPrefix(args, prefix []string) bool {
+	if len(prefix) > len(args) {
+		return false
+	}
+	for i := range prefix {
+		if args[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,65 @@
+package cosmos
+
+import (
+	"context"
+	"testing"
+
+	sdkmath "cosmossdk.io/math"
+	"github.com/decentrio/rollup-e2e-testing/ibc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalExecutorCapturesOutput(t *testing.T) {
+	res, err := LocalExecutor{}.Exec(context.Background(), "sh", "-c", "echo out; echo err >&2; exit 3")
+	require.Error(t, err)
+	require.Equal(t, "out\n", string(res.Stdout))
+	require.Equal(t, "err\n", string(res.Stderr))
+	require.Equal(t, 3, res.ExitCode)
+	require.Positive(t, res.Duration)
+}
+
+func TestCreateUserWithScriptedExecutor(t *testing.T) {
+	fake := &ScriptedExecutor{}
+	fake.Expect("keys", "add", "alice").Return(keyJSON("alice", 1, "word word"))
+
+	chain := CosmosChain{Bin: "dymd", Denom: "adym", Executor: fake}
+	user, err := chain.CreateUser(context.Background(), "alice")
+	require.NoError(t, err)
+	require.Equal(t, "alice", user.KeyName)
+	require.Equal(t, "adym", user.Denom)
+	require.Equal(t, "word word", user.Mnemonic)
+	require.Empty(t, fake.Pending())
+	require.Len(t, fake.Calls(), 1)
+	require.Equal(t, "dymd", fake.Calls()[0][0])
+}
+
+func TestSendIBCTransferWithScriptedExecutor(t *testing.T) {
+	fake := &ScriptedExecutor{}
+	fake.Expect("tx", "ibc-transfer", "transfer", "transfer", "channel-0").Return(`{"txhash":"ABC","code":0}`)
+
+	chain := CosmosChain{Bin: "dymd", ChainID: "hub_1-1", RPCAddr: "rpc:443", Executor: fake, BroadcastMode: BroadcastSync}
+	res, err := SendIBCTransfer(context.Background(), chain, "channel-0", "alice", ibc.WalletData{
+		Address: "rol1bob",
+		Denom:   "adym",
+		Amount:  sdkmath.NewInt(10),
+	}, "1adym", ibc.TransferOptions{Memo: "hi"})
+	require.NoError(t, err)
+	require.Equal(t, "ABC", res.TxHash)
+
+	send := fake.Calls()[0]
+	require.Contains(t, send, "10adym")
+	require.Contains(t, send, "https://rpc:443")
+	require.Contains(t, send, "hi")
+	require.Equal(t, "sync", send[len(send)-2])
+}
+
+func TestScriptedExecutorFailure(t *testing.T) {
+	fake := &ScriptedExecutor{}
+	fake.Expect("keys", "add").Fail(1, "key exists")
+
+	chain := CosmosChain{Bin: "dymd", Executor: fake}
+	_, err := chain.CreateKey(context.Background(), "alice")
+	require.Error(t, err)
+	_, err = chain.KeyBech32(context.Background(), "alice")
+	require.ErrorContains(t, err, "unexpected command")
+}
@@ -0,0 +1,384 @@
+package cosmos
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	sdkmath "cosmossdk.io/math"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	bankTypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultFaucetBody is the faucet request body used when none is configured.
+	DefaultFaucetBody = `{"address":"{address}"}`
+	// DefaultFaucetRetries is how many times a faucet request is retried on
+	// rate limiting or server errors.
+	DefaultFaucetRetries = 5
+	// DefaultFaucetConfirmTimeout bounds the wait for a funded balance to show up.
+	DefaultFaucetConfirmTimeout = 2 * time.Minute
+)
+
+// ErrFaucetUnavailable is wrapped by the errors of Faucet requests that
+// failed even after retrying.
+var ErrFaucetUnavailable = errors.New("faucet unavailable")
+
+// FaucetError is a faucet answer with an unexpected HTTP status.
+type FaucetError struct {
+	URL        string
+	StatusCode int
+	Body       string
+}
+
+func (e *FaucetError) Error() string {
+	return fmt.Sprintf("faucet %s answered %d: %s", e.URL, e.StatusCode, e.Body)
+}
+
+// Temporary reports whether the request is worth retrying.
+func (e *FaucetError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// Faucet funds accounts of a chain from an HTTP faucet, falling back to a
+// bank send from a treasury key when the faucet is unavailable.
+type Faucet struct {
+	Chain CosmosChain
+	// URL of the HTTP faucet. Without it only the treasury is used.
+	URL string
+	// Body is the JSON body POSTed to URL; {address} and {denom} are
+	// substituted. Defaults to DefaultFaucetBody.
+	Body string
+	// Amount is what one funding grants. Fund waits for the balance of
+	// Amount's denom to grow by Amount, or by anything in the chain's denom
+	// when Amount is unset. The treasury needs it to know what to send.
+	Amount sdk.Coin
+	// TreasuryKey is a funded key of the chain binary's keyring.
+	TreasuryKey string
+
+	// Retries defaults to DefaultFaucetRetries.
+	Retries int
+	// Backoff is the delay before the first retry, doubled on every retry
+	// unless the faucet sends Retry-After. Defaults to 2s.
+	Backoff time.Duration
+	// ConfirmTimeout defaults to DefaultFaucetConfirmTimeout.
+	ConfirmTimeout time.Duration
+	// HTTPClient defaults to a client with a 30s timeout.
+	HTTPClient *http.Client
+
+	// treasuryMu serializes treasury txs, which would otherwise race for
+	// the treasury's account sequence.
+	treasuryMu   sync.Mutex
+	treasuryAddr string
+}
+
+// NewFaucet returns a faucet of the chain requesting url with the default body.
+func (c CosmosChain) NewFaucet(url string) *Faucet {
+	return &Faucet{Chain: c, URL: url}
+}
+
+func (f *Faucet) hasAmount() bool {
+	return !f.Amount.Amount.IsNil() && f.Amount.IsPositive()
+}
+
+func (f *Faucet) denom() string {
+	if f.Amount.Denom != "" {
+		return f.Amount.Denom
+	}
+	return f.Chain.Denom
+}
+
+// Fund gets address funded and waits until its balance reflects it. The HTTP
+// faucet is tried first; when it stays unavailable, or pays nothing, the
+// treasury sends Amount instead.
+func (f *Faucet) Fund(ctx context.Context, address string) error {
+	before, err := f.balance(ctx, address)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	if f.URL != "" {
+		err := f.request(ctx, address)
+		if err == nil {
+			err = f.confirm(ctx, address, before)
+		}
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+
+	if f.TreasuryKey == "" {
+		if len(errs) == 0 {
+			errs = append(errs, errors.New("neither a faucet url nor a treasury key is configured"))
+		}
+		return fmt.Errorf("fund %s on %s: %w", address, f.Chain.ChainID, errors.Join(errs...))
+	}
+	if f.URL != "" {
+		f.Chain.Log().Warn("faucet failed, funding from treasury",
+			zap.String("faucet", f.URL), zap.String("address", address), zap.String("treasury", f.TreasuryKey), zap.Error(errs[0]))
+	}
+	err = f.fundFromTreasury(ctx, address)
+	if err == nil {
+		err = f.confirm(ctx, address, before)
+	}
+	if err != nil {
+		errs = append(errs, fmt.Errorf("treasury: %w", err))
+		return fmt.Errorf("fund %s on %s: %w", address, f.Chain.ChainID, errors.Join(errs...))
+	}
+	return nil
+}
+
+// request POSTs the faucet request, retrying with backoff on rate limiting,
+// server errors and transport failures.
+func (f *Faucet) request(ctx context.Context, address string) error {
+	body := f.Body
+	if body == "" {
+		body = DefaultFaucetBody
+	}
+	body = strings.NewReplacer("{address}", address, "{denom}", f.denom()).Replace(body)
+
+	client := f.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second, Transport: CassetteTransport(nil)}
+	}
+	retries := f.Retries
+	if retries <= 0 {
+		retries = DefaultFaucetRetries
+	}
+	backoff := f.Backoff
+	if backoff <= 0 {
+		backoff = 2 * time.Second
+	}
+
+	var err error
+	for attempt := 0; ; attempt++ {
+		var retryAfter time.Duration
+		retryAfter, err = f.post(ctx, client, body)
+		if err == nil {
+			return nil
+		}
+		var faucetErr *FaucetError
+		if errors.As(err, &faucetErr) && !faucetErr.Temporary() {
+			return err
+		}
+		if attempt == retries {
+			break
+		}
+
+		delay := backoff << attempt
+		if retryAfter > 0 {
+			delay = retryAfter
+		}
+		f.Chain.Log().Info("faucet request failed, retrying",
+			zap.String("faucet", f.URL), zap.Duration("delay", delay), zap.Error(err))
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(delay):
+		}
+	}
+	return fmt.Errorf("%w after %d attempts: %w", ErrFaucetUnavailable, retries+1, err)
+}
+
+// post sends a single request. The Retry-After delay of a rejected request
+// is returned alongside the error.
+func (f *Faucet) post(ctx context.Context, client *http.Client, body string) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.URL, strings.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return 0, nil
+	}
+	var retryAfter time.Duration
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		retryAfter = time.Duration(secs) * time.Second
+	}
+	return retryAfter, &FaucetError{
+		URL:        f.URL,
+		StatusCode: resp.StatusCode,
+		Body:       string(bytes.TrimSpace(respBody)),
+	}
+}
+
+// FundMany funds every address. With a treasury and an Amount they are all
+// paid by a single multi-send; otherwise each one goes through Fund.
+func (f *Faucet) FundMany(ctx context.Context, addresses ...string) error {
+	if f.TreasuryKey == "" || !f.hasAmount() || len(addresses) < 2 {
+		var errs []error
+		for _, address := range addresses {
+			if err := f.Fund(ctx, address); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	}
+
+	before := make([]sdkmath.Int, len(addresses))
+	for i, address := range addresses {
+		balance, err := f.balance(ctx, address)
+		if err != nil {
+			return err
+		}
+		before[i] = balance
+	}
+	command := append([]string{"bank", "multi-send", f.TreasuryKey}, addresses...)
+	if err := f.treasuryTx(ctx, append(command, f.Amount.String())...); err != nil {
+		return fmt.Errorf("fund %d accounts on %s from treasury: %w", len(addresses), f.Chain.ChainID, err)
+	}
+	for i, address := range addresses {
+		if err := f.confirm(ctx, address, before[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Sweep sends every balance of keyName, at address, back to the treasury,
+// keeping what the tx fee needs. Balances too small to pay the fee are left.
+func (f *Faucet) Sweep(ctx context.Context, keyName, address string) error {
+	if f.TreasuryKey == "" {
+		return errors.New("no treasury to sweep funds to")
+	}
+	treasury, err := f.treasuryAddress(ctx)
+	if err != nil {
+		return err
+	}
+	bank, err := f.Chain.BankQuery()
+	if err != nil {
+		return err
+	}
+	res, err := bank.AllBalances(ctx, &bankTypes.QueryAllBalancesRequest{Address: address})
+	if err != nil {
+		return fmt.Errorf("query balances of %s: %w", address, err)
+	}
+	fees, err := sdk.ParseCoinsNormalized(f.Chain.Fees)
+	if err != nil {
+		return fmt.Errorf("parse fees of %s: %w", f.Chain.ChainID, err)
+	}
+	leftover, insufficient := res.Balances.SafeSub(fees...)
+	if insufficient || leftover.IsZero() {
+		return nil
+	}
+	f.treasuryMu.Lock()
+	err = f.ensureClient()
+	f.treasuryMu.Unlock()
+	if err != nil {
+		return err
+	}
+	_, err = f.Chain.ExecTx(ctx, keyName, f.Chain.Fees, "bank", "send", keyName, treasury, leftover.String())
+	return err
+}
+
+func (f *Faucet) fundFromTreasury(ctx context.Context, address string) error {
+	if !f.hasAmount() {
+		return errors.New("no amount configured for the treasury to send")
+	}
+	return f.treasuryTx(ctx, "bank", "send", f.TreasuryKey, address, f.Amount.String())
+}
+
+func (f *Faucet) treasuryTx(ctx context.Context, command ...string) error {
+	f.treasuryMu.Lock()
+	defer f.treasuryMu.Unlock()
+	if err := f.ensureClient(); err != nil {
+		return err
+	}
+	_, err := f.Chain.ExecTx(ctx, f.TreasuryKey, f.Chain.Fees, command...)
+	return err
+}
+
+func (f *Faucet) treasuryAddress(ctx context.Context) (string, error) {
+	f.treasuryMu.Lock()
+	defer f.treasuryMu.Unlock()
+	if f.treasuryAddr == "" {
+		addr, err := f.Chain.KeyBech32(ctx, f.TreasuryKey)
+		if err != nil {
+			return "", fmt.Errorf("treasury address: %w", err)
+		}
+		f.treasuryAddr = addr
+	}
+	return f.treasuryAddr, nil
+}
+
+// ensureClient creates the RPC client txs are awaited with, for faucets built
+// from a bare chain config. Callers hold treasuryMu.
+func (f *Faucet) ensureClient() error {
+	if f.Chain.Client != nil {
+		return nil
+	}
+	return f.Chain.NewClient(f.Chain.RPCURL())
+}
+
+// confirm waits until the balance of address grew from before by Amount, or
+// by anything when Amount is unset.
+func (f *Faucet) confirm(ctx context.Context, address string, before sdkmath.Int) error {
+	timeout := f.ConfirmTimeout
+	if timeout <= 0 {
+		timeout = DefaultFaucetConfirmTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	want := before.AddRaw(1)
+	if f.hasAmount() {
+		want = before.Add(f.Amount.Amount)
+	}
+	var (
+		last    sdkmath.Int
+		lastErr error
+	)
+	ticker := time.NewTicker(TxPollInterval)
+	defer ticker.Stop()
+	for {
+		balance, err := f.balance(ctx, address)
+		if err == nil {
+			if balance.GTE(want) {
+				return nil
+			}
+			last = balance
+		}
+		lastErr = err
+		select {
+		case <-ctx.Done():
+			if last.IsNil() {
+				return fmt.Errorf("balance of %s never reached %s%s: %w", address, want, f.denom(), errors.Join(ctx.Err(), lastErr))
+			}
+			return fmt.Errorf("balance of %s is %s%s, never reached %s%s: %w", address, last, f.denom(), want, f.denom(), ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+func (f *Faucet) balance(ctx context.Context, address string) (sdkmath.Int, error) {
+	bank, err := f.Chain.BankQuery()
+	if err != nil {
+		return sdkmath.Int{}, err
+	}
+	res, err := bank.Balance(ctx, &bankTypes.QueryBalanceRequest{Address: address, Denom: f.denom()})
+	if err != nil {
+		return sdkmath.Int{}, fmt.Errorf("query balance of %s: %w", address, err)
+	}
+	if res.Balance == nil {
+		return sdkmath.ZeroInt(), nil
+	}
+	return res.Balance.Amount, nil
+}
@@ -0,0 +1,122 @@
+package cosmos
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+)
+
+// serveBalance serves the current value of balance as every balance.
+func serveBalance(t *testing.T, balance func() int64) string {
+	_, addr := serveBank(t, func(context.Context) int64 { return balance() })
+	return addr
+}
+
+func TestFaucetRetriesAndConfirms(t *testing.T) {
+	withTxPollInterval(t, time.Millisecond)
+	var requests, paid atomic.Int64
+	faucetServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		require.JSONEq(t, `{"to":"dym1alice","denom":"adym"}`, string(body))
+		switch requests.Add(1) {
+		case 1:
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+		case 2:
+			http.Error(w, "upstream down", http.StatusBadGateway)
+		default:
+			paid.Store(1000)
+		}
+	}))
+	t.Cleanup(faucetServer.Close)
+
+	chain := CosmosChain{ChainID: "hub_1-1", Denom: "adym", GrpcAddr: serveBalance(t, func() int64 { return 5 + paid.Load() })}
+	faucet := chain.NewFaucet(faucetServer.URL)
+	faucet.Body = `{"to":"{address}","denom":"{denom}"}`
+	faucet.Amount = sdk.NewInt64Coin("adym", 1000)
+	faucet.Backoff = time.Millisecond
+
+	require.NoError(t, faucet.Fund(context.Background(), "dym1alice"))
+	require.Equal(t, int64(3), requests.Load())
+}
+
+func TestFaucetErrors(t *testing.T) {
+	withTxPollInterval(t, time.Millisecond)
+	faucetServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/bad" {
+			http.Error(w, "invalid address", http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(faucetServer.Close)
+	chain := CosmosChain{ChainID: "hub_1-1", Denom: "adym", GrpcAddr: serveBalance(t, func() int64 { return 0 })}
+
+	faucet := chain.NewFaucet(faucetServer.URL + "/bad")
+	faucet.Backoff = time.Millisecond
+	err := faucet.Fund(context.Background(), "dym1alice")
+	var faucetErr *FaucetError
+	require.ErrorAs(t, err, &faucetErr)
+	require.Equal(t, http.StatusBadRequest, faucetErr.StatusCode)
+	require.Equal(t, "invalid address", faucetErr.Body)
+	require.NotErrorIs(t, err, ErrFaucetUnavailable)
+
+	faucet = chain.NewFaucet(faucetServer.URL)
+	faucet.Backoff = time.Millisecond
+	faucet.Retries = 2
+	err = faucet.Fund(context.Background(), "dym1alice")
+	require.ErrorIs(t, err, ErrFaucetUnavailable)
+	require.ErrorContains(t, err, "after 3 attempts")
+
+	// The faucet answers but the balance never moves.
+	okServer := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	t.Cleanup(okServer.Close)
+	faucet = chain.NewFaucet(okServer.URL)
+	faucet.ConfirmTimeout = 20 * time.Millisecond
+	err = faucet.Fund(context.Background(), "dym1alice")
+	require.ErrorContains(t, err, "balance of dym1alice is 0adym, never reached 1adym")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestFaucetTreasuryFallback(t *testing.T) {
+	withTxPollInterval(t, time.Millisecond)
+	faucetServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(faucetServer.Close)
+
+	exec := &ScriptedExecutor{}
+	exec.Expect("tx", "bank", "send", "treasury", "dym1alice", "500adym").Return(`{"txhash":"AB","code":0}`)
+	chain := CosmosChain{
+		ChainID:       "hub_1-1",
+		Bin:           "dymd",
+		Denom:         "adym",
+		RPCAddr:       "http://127.0.0.1:26657",
+		BroadcastMode: BroadcastSync,
+		Executor:      exec,
+	}
+	chain.GrpcAddr = serveBalance(t, func() int64 {
+		if len(exec.Calls()) > 0 {
+			return 500
+		}
+		return 0
+	})
+
+	faucet := chain.NewFaucet(faucetServer.URL)
+	faucet.Amount = sdk.NewInt64Coin("adym", 500)
+	faucet.TreasuryKey = "treasury"
+	faucet.Retries = 1
+	faucet.Backoff = time.Millisecond
+	require.NoError(t, faucet.Fund(context.Background(), "dym1alice"))
+	require.Empty(t, exec.Pending())
+
+	faucet.URL = ""
+	faucet.TreasuryKey = ""
+	require.ErrorContains(t, faucet.Fund(context.Background(), "dym1alice"), "neither a faucet url nor a treasury key")
+}
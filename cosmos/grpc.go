@@ -0,0 +1,172 @@
+package cosmos
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	transfertypes "github.com/cosmos/ibc-go/v7/modules/apps/transfer/types"
+	chanTypes "github.com/cosmos/ibc-go/v7/modules/core/04-channel/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+)
+
+// DefaultGRPCTimeout bounds gRPC calls made with a context without deadline.
+const DefaultGRPCTimeout = 15 * time.Second
+
+// GRPCTLS selects the transport security of the connection to GrpcAddr.
+type GRPCTLS string
+
+const (
+	// GRPCTLSAuto uses TLS for addresses with an https scheme or on port 443.
+	GRPCTLSAuto GRPCTLS = ""
+	// GRPCTLSInsecure disables transport security.
+	GRPCTLSInsecure GRPCTLS = "insecure"
+	// GRPCTLSEnabled uses TLS, verifying the server certificate.
+	GRPCTLSEnabled GRPCTLS = "tls"
+	// GRPCTLSSkipVerify uses TLS without verifying the server certificate.
+	GRPCTLSSkipVerify GRPCTLS = "skip-verify"
+)
+
+// grpcConns shares one connection per address and TLS mode across every copy
+// of a CosmosChain, and with the helpers only given an address.
+var grpcConns = struct {
+	sync.Mutex
+	conns map[grpcConnKey]*grpc.ClientConn
+}{conns: make(map[grpcConnKey]*grpc.ClientConn)}
+
+type grpcConnKey struct {
+	addr string
+	tls  GRPCTLS
+}
+
+// GRPCConn returns the chain's gRPC connection, created on first use and
+// shared afterwards. It must not be closed by the caller; see CloseGRPCConns.
+// Calls without a deadline are bounded by DefaultGRPCTimeout.
+func (c CosmosChain) GRPCConn() (*grpc.ClientConn, error) {
+	if c.GrpcAddr == "" {
+		return nil, fmt.Errorf("chain %s has no grpc_addr", c.ChainID)
+	}
+	return grpcConn(c.GrpcAddr, c.GrpcTLS)
+}
+
+// CloseGRPCConns closes every shared gRPC connection. It is meant for
+// TestMain, once all tests are done.
+func CloseGRPCConns() {
+	grpcConns.Lock()
+	defer grpcConns.Unlock()
+	for key, conn := range grpcConns.conns {
+		_ = conn.Close()
+		delete(grpcConns.conns, key)
+	}
+}
+
+func grpcConn(addr string, mode GRPCTLS) (*grpc.ClientConn, error) {
+	key := grpcConnKey{addr, mode}
+	grpcConns.Lock()
+	defer grpcConns.Unlock()
+	if conn, ok := grpcConns.conns[key]; ok {
+		return conn, nil
+	}
+
+	target, creds, err := grpcTransport(addr, mode)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := grpc.NewClient(target,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:    time.Minute,
+			Timeout: 20 * time.Second,
+		}),
+		grpc.WithChainUnaryInterceptor(deadlineInterceptor, cassetteInterceptor),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("grpc client for %s: %w", addr, err)
+	}
+	grpcConns.conns[key] = conn
+	return conn, nil
+}
+
+// grpcTransport strips any http(s) scheme from addr and picks the transport
+// credentials of mode.
+func grpcTransport(addr string, mode GRPCTLS) (string, credentials.TransportCredentials, error) {
+	target := addr
+	scheme := ""
+	if i := strings.Index(addr, "://"); i >= 0 {
+		scheme, target = addr[:i], addr[i+3:]
+	}
+	target = strings.TrimSuffix(target, "/")
+
+	if mode == GRPCTLSAuto {
+		_, port, _ := net.SplitHostPort(target)
+		mode = GRPCTLSInsecure
+		if scheme == "https" || port == "443" {
+			mode = GRPCTLSEnabled
+		}
+	}
+	switch mode {
+	case GRPCTLSInsecure:
+		return target, insecure.NewCredentials(), nil
+	case GRPCTLSEnabled:
+		return target, credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12}), nil
+	case GRPCTLSSkipVerify:
+		return target, credentials.NewTLS(&tls.Config{InsecureSkipVerify: true}), nil
+	default:
+		return "", nil, fmt.Errorf("unknown grpc tls mode %q", mode)
+	}
+}
+
+// deadlineInterceptor applies DefaultGRPCTimeout to calls whose context has no deadline.
+func deadlineInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultGRPCTimeout)
+		defer cancel()
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+// BankQuery returns a bank query client over the chain's shared connection.
+func (c CosmosChain) BankQuery() (banktypes.QueryClient, error) {
+	conn, err := c.GRPCConn()
+	if err != nil {
+		return nil, err
+	}
+	return banktypes.NewQueryClient(conn), nil
+}
+
+// AuthQuery returns an auth query client over the chain's shared connection.
+func (c CosmosChain) AuthQuery() (authtypes.QueryClient, error) {
+	conn, err := c.GRPCConn()
+	if err != nil {
+		return nil, err
+	}
+	return authtypes.NewQueryClient(conn), nil
+}
+
+// TransferQuery returns an ICS-20 transfer query client over the chain's shared connection.
+func (c CosmosChain) TransferQuery() (transfertypes.QueryClient, error) {
+	conn, err := c.GRPCConn()
+	if err != nil {
+		return nil, err
+	}
+	return transfertypes.NewQueryClient(conn), nil
+}
+
+// ChannelQuery returns an IBC channel query client over the chain's shared connection.
+func (c CosmosChain) ChannelQuery() (chanTypes.QueryClient, error) {
+	conn, err := c.GRPCConn()
+	if err != nil {
+		return nil, err
+	}
+	return chanTypes.NewQueryClient(conn), nil
+}
@@ -0,0 +1,114 @@
+package cosmos
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	sdkmath "cosmossdk.io/math"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// bankServer answers every Balance query with balance(ctx).
+type bankServer struct {
+	banktypes.UnimplementedQueryServer
+	balance func(ctx context.Context) int64
+}
+
+func (s *bankServer) Balance(ctx context.Context, req *banktypes.QueryBalanceRequest) (*banktypes.QueryBalanceResponse, error) {
+	coin := sdk.NewCoin(req.Denom, sdkmath.NewInt(s.balance(ctx)))
+	return &banktypes.QueryBalanceResponse{Balance: &coin}, nil
+}
+
+// serveGRPC serves the services registered by register over gRPC until the
+// test ends, closing the shared connections then, and returns the server and
+// its address.
+func serveGRPC(t *testing.T, register func(*grpc.Server)) (*grpc.Server, string) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := grpc.NewServer()
+	register(server)
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(server.Stop)
+	t.Cleanup(CloseGRPCConns)
+	return server, lis.Addr().String()
+}
+
+// serveBank serves a bankServer answering with balance, see serveGRPC.
+func serveBank(t *testing.T, balance func(ctx context.Context) int64) (*grpc.Server, string) {
+	return serveGRPC(t, func(server *grpc.Server) {
+		banktypes.RegisterQueryServer(server, &bankServer{balance: balance})
+	})
+}
+
+// deadlineLeft reports the deadline left to ctx, in seconds.
+func deadlineLeft(ctx context.Context) int64 {
+	if deadline, ok := ctx.Deadline(); ok {
+		return int64(time.Until(deadline).Round(time.Second) / time.Second)
+	}
+	return 0
+}
+
+func TestGRPCConnShared(t *testing.T) {
+	_, addr := serveBank(t, deadlineLeft)
+
+	chain := CosmosChain{ChainID: "hub_1-1", GrpcAddr: addr, GrpcTLS: GRPCTLSInsecure}
+	conn, err := chain.GRPCConn()
+	require.NoError(t, err)
+	copied := chain
+	again, err := copied.GRPCConn()
+	require.NoError(t, err)
+	require.Same(t, conn, again)
+
+	bank, err := chain.BankQuery()
+	require.NoError(t, err)
+	res, err := bank.Balance(context.Background(), &banktypes.QueryBalanceRequest{Address: "dym1alice", Denom: "adym"})
+	require.NoError(t, err)
+	require.Equal(t, int64(DefaultGRPCTimeout/time.Second), res.Balance.Amount.Int64())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	res, err = bank.Balance(ctx, &banktypes.QueryBalanceRequest{Address: "dym1alice", Denom: "adym"})
+	require.NoError(t, err)
+	require.Equal(t, int64(3), res.Balance.Amount.Int64())
+
+	user := User{Address: "dym1alice"}
+	_, err = user.GetBalance(context.Background(), "adym", chain)
+	require.NoError(t, err)
+	require.Len(t, grpcConns.conns, 1, "balances are queried over the chain's connection")
+	require.Same(t, conn, grpcConns.conns[grpcConnKey{chain.GrpcAddr, GRPCTLSInsecure}])
+
+	_, err = CosmosChain{ChainID: "hub_1-1"}.GRPCConn()
+	require.ErrorContains(t, err, "no grpc_addr")
+}
+
+func TestGRPCTransport(t *testing.T) {
+	for _, tc := range []struct {
+		addr, target string
+		mode         GRPCTLS
+		tls          bool
+	}{
+		{"grpc.hub:9090", "grpc.hub:9090", GRPCTLSAuto, false},
+		{"grpc.hub:443", "grpc.hub:443", GRPCTLSAuto, true},
+		{"https://grpc.hub/", "grpc.hub", GRPCTLSAuto, true},
+		{"http://grpc.hub:443", "grpc.hub:443", GRPCTLSInsecure, false},
+		{"grpc.hub:9090", "grpc.hub:9090", GRPCTLSEnabled, true},
+		{"grpc.hub:9090", "grpc.hub:9090", GRPCTLSSkipVerify, true},
+	} {
+		target, creds, err := grpcTransport(tc.addr, tc.mode)
+		require.NoError(t, err, tc.addr)
+		require.Equal(t, tc.target, target)
+		require.Equal(t, tc.tls, creds.Info().SecurityProtocol == "tls", "%s %q", tc.addr, tc.mode)
+		if !tc.tls {
+			require.Equal(t, insecure.NewCredentials().Info(), creds.Info())
+		}
+	}
+
+	_, _, err := grpcTransport("grpc.hub:9090", "mtls")
+	require.ErrorContains(t, err, `unknown grpc tls mode "mtls"`)
+}
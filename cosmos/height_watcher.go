@@ -0,0 +1,318 @@
+package cosmos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/cometbft/cometbft/libs/service"
+	tmtypes "github.com/cometbft/cometbft/types"
+)
+
+var (
+	// HeightPollInterval is the delay between two height queries of a chain
+	// whose new blocks cannot be subscribed to. Chains followed over the
+	// websocket are still queried, ten times less often, in case events are
+	// missed.
+	HeightPollInterval = time.Second
+	// StallTimeout is how long a chain may go without a new block before
+	// waits for its heights fail with a *ChainStalledError. Zero disables
+	// stall detection.
+	StallTimeout = time.Minute
+)
+
+// subscribedPollFactor slows down the height queries of a chain followed over
+// the websocket.
+const subscribedPollFactor = 10
+
+// HeightWatcher follows the height of a chain in the background, so that
+// any number of waiters block on it without querying the chain themselves.
+type HeightWatcher struct {
+	chainID string
+	height  func(ctx context.Context) (uint64, error)
+	cancel  context.CancelFunc
+	done    chan struct{}
+
+	mu      sync.Mutex
+	current uint64
+	// advanced is when current last increased, or when watching started.
+	advanced time.Time
+	// err is the error of the last height query, if it failed.
+	err     error
+	changed chan struct{}
+}
+
+// NewHeightWatcher starts following the height of chainID, as reported by
+// height every HeightPollInterval and by the heights sent by subscribe, if
+// not nil. Heights are polled alone when subscribe fails or its channel is
+// closed. The watcher runs until Stop is called.
+func NewHeightWatcher(chainID string, height func(ctx context.Context) (uint64, error), subscribe func(ctx context.Context) (<-chan uint64, error)) *HeightWatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &HeightWatcher{
+		chainID:  chainID,
+		height:   height,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+		advanced: time.Now(),
+		changed:  make(chan struct{}),
+	}
+	go w.run(ctx, subscribe)
+	return w
+}
+
+func (w *HeightWatcher) run(ctx context.Context, subscribe func(ctx context.Context) (<-chan uint64, error)) {
+	defer close(w.done)
+	var blocks <-chan uint64
+	if subscribe != nil {
+		// Subscribing before the first query, so that no block is missed
+		// in between.
+		if sub, err := subscribe(ctx); err == nil {
+			blocks = sub
+		}
+	}
+	interval := func() time.Duration {
+		if blocks != nil {
+			return subscribedPollFactor * HeightPollInterval
+		}
+		return HeightPollInterval
+	}
+
+	w.poll(ctx)
+	timer := time.NewTimer(interval())
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case h, ok := <-blocks:
+			if !ok {
+				blocks = nil
+				w.poll(ctx)
+				timer.Reset(interval())
+				continue
+			}
+			w.update(h, nil)
+		case <-timer.C:
+			w.poll(ctx)
+			timer.Reset(interval())
+		}
+	}
+}
+
+func (w *HeightWatcher) poll(ctx context.Context) {
+	h, err := w.height(ctx)
+	if ctx.Err() != nil {
+		return
+	}
+	w.update(h, err)
+}
+
+func (w *HeightWatcher) update(h uint64, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.err = err
+	if err != nil || h <= w.current {
+		return
+	}
+	w.current = h
+	w.advanced = time.Now()
+	close(w.changed)
+	w.changed = make(chan struct{})
+}
+
+// Stop stops following the chain. Waits in progress fail.
+func (w *HeightWatcher) Stop() {
+	w.cancel()
+	<-w.done
+}
+
+// Height returns the last height seen, zero until the chain was first reached.
+func (w *HeightWatcher) Height() uint64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.current
+}
+
+// WaitForHeight blocks until the chain reaches height and returns the height
+// seen then. It fails with a *ChainStalledError if the chain produces no
+// block for StallTimeout, unless timeout is longer, and once timeout elapses
+// if it is not zero. Every error names the chain.
+func (w *HeightWatcher) WaitForHeight(ctx context.Context, height uint64, timeout time.Duration) (uint64, error) {
+	var timedOut <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		timedOut = timer.C
+	}
+	stallTimeout := StallTimeout
+	if timeout > stallTimeout {
+		stallTimeout = 0
+	}
+	stallTimer := time.NewTimer(stallTimeout)
+	defer stallTimer.Stop()
+	for {
+		w.mu.Lock()
+		cur, advanced, lastErr, changed := w.current, w.advanced, w.err, w.changed
+		w.mu.Unlock()
+		if cur >= height {
+			return cur, nil
+		}
+
+		var stalled <-chan time.Time
+		if stallTimeout > 0 {
+			idle := time.Since(advanced)
+			if idle >= stallTimeout {
+				return cur, &ChainStalledError{ChainID: w.chainID, Height: cur, Idle: idle.Round(time.Second), Err: lastErr}
+			}
+			if !stallTimer.Stop() {
+				select {
+				case <-stallTimer.C:
+				default:
+				}
+			}
+			stallTimer.Reset(stallTimeout - idle)
+			stalled = stallTimer.C
+		}
+		select {
+		case <-changed:
+		case <-stalled:
+		case <-timedOut:
+			return cur, fmt.Errorf("%s did not reach height %d within %s (height %d)", chainName(w.chainID), height, timeout, cur)
+		case <-w.done:
+			return cur, fmt.Errorf("height watcher of %s stopped", chainName(w.chainID))
+		case <-ctx.Done():
+			return cur, fmt.Errorf("wait for height %d of %s: %w", height, chainName(w.chainID), ctx.Err())
+		}
+	}
+}
+
+// ChainStalledError is returned when a chain has not produced a block for
+// StallTimeout.
+type ChainStalledError struct {
+	ChainID string
+	// Height is the last height seen, zero if the chain was never reached.
+	Height uint64
+	Idle   time.Duration
+	// Err is the error of the last height query, if it failed.
+	Err error
+}
+
+func (e *ChainStalledError) Error() string {
+	msg := fmt.Sprintf("%s has not produced a block for %s (height %d)", chainName(e.ChainID), e.Idle, e.Height)
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *ChainStalledError) Unwrap() error { return e.Err }
+
+func chainName(chainID string) string {
+	if chainID == "" {
+		return "chain"
+	}
+	return "chain " + chainID
+}
+
+// heightWatchers holds the watchers shared by the chains using the same RPC
+// client, with how many users each has.
+var heightWatchers = struct {
+	sync.Mutex
+	watchers map[any]*sharedHeightWatcher
+}{watchers: map[any]*sharedHeightWatcher{}}
+
+type sharedHeightWatcher struct {
+	*HeightWatcher
+	users int
+}
+
+// WatchHeight returns the height watcher of the chain, shared with every
+// caller using the same RPC client. It follows NewBlock events over the
+// websocket, falling back to polling the chain's status. The watcher stops
+// once all its users called release.
+func (c CosmosChain) WatchHeight() (w *HeightWatcher, release func()) {
+	height := c.Height
+	if c.Client == nil {
+		height = func(context.Context) (uint64, error) {
+			return 0, fmt.Errorf("chain %s has no rpc client", c.ChainID)
+		}
+	}
+	if c.Client == nil || !reflect.TypeOf(c.Client).Comparable() {
+		w := NewHeightWatcher(c.ChainID, height, c.NewBlockHeights)
+		return w, w.Stop
+	}
+
+	heightWatchers.Lock()
+	defer heightWatchers.Unlock()
+	shared, ok := heightWatchers.watchers[c.Client]
+	if !ok {
+		shared = &sharedHeightWatcher{HeightWatcher: NewHeightWatcher(c.ChainID, height, c.NewBlockHeights)}
+		heightWatchers.watchers[c.Client] = shared
+	}
+	shared.users++
+	var once sync.Once
+	return shared.HeightWatcher, func() {
+		once.Do(func() {
+			heightWatchers.Lock()
+			shared.users--
+			last := shared.users == 0
+			if last {
+				delete(heightWatchers.watchers, c.Client)
+			}
+			heightWatchers.Unlock()
+			if last {
+				shared.Stop()
+			}
+		})
+	}
+}
+
+// NewBlockHeights subscribes to the NewBlock events of the chain over the
+// websocket and sends their heights until ctx is done or the subscription
+// is dropped. The websocket is not recorded by cassettes, so it is not used
+// while one is replayed.
+func (c CosmosChain) NewBlockHeights(ctx context.Context) (<-chan uint64, error) {
+	if c.Client == nil {
+		return nil, fmt.Errorf("chain %s has no rpc client", c.ChainID)
+	}
+	if Replaying() {
+		return nil, ErrWebsocketReplayed
+	}
+	if err := c.Client.Start(); err != nil && !errors.Is(err, service.ErrAlreadyStarted) {
+		return nil, fmt.Errorf("start websocket: %w", err)
+	}
+	subscriber := fmt.Sprintf("e2e-heights-%d", time.Now().UnixNano())
+	events, err := c.Client.Subscribe(ctx, subscriber, tmtypes.EventQueryNewBlock.String())
+	if err != nil {
+		return nil, fmt.Errorf("subscribe to new blocks of %s: %w", c.ChainID, err)
+	}
+
+	out := make(chan uint64)
+	go func() {
+		defer close(out)
+		defer func() { _ = c.Client.UnsubscribeAll(context.Background(), subscriber) }()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case ev, ok := <-events:
+				if !ok {
+					return
+				}
+				block, ok := ev.Data.(tmtypes.EventDataNewBlock)
+				if !ok || block.Block == nil {
+					continue
+				}
+				select {
+				case out <- uint64(block.Block.Height):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
@@ -0,0 +1,113 @@
+package cosmos
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeightWatcherPolls(t *testing.T) {
+	defer func(interval time.Duration) { HeightPollInterval = interval }(HeightPollInterval)
+	HeightPollInterval = 5 * time.Millisecond
+
+	var height, queries atomic.Uint64
+	height.Store(3)
+	w := NewHeightWatcher("hub_1-1", func(ctx context.Context) (uint64, error) {
+		queries.Add(1)
+		return height.Load(), nil
+	}, nil)
+	defer w.Stop()
+
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h, err := w.WaitForHeight(ctx, 5, 5*time.Second)
+			assert.NoError(t, err)
+			assert.GreaterOrEqual(t, h, uint64(5))
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	before := queries.Load()
+	height.Store(5)
+	wg.Wait()
+	require.Less(t, before, uint64(20), "waiters do not query the chain themselves")
+	require.Equal(t, uint64(5), w.Height())
+
+	_, err := w.WaitForHeight(ctx, 6, 20*time.Millisecond)
+	require.EqualError(t, err, "chain hub_1-1 did not reach height 6 within 20ms (height 5)")
+}
+
+func TestHeightWatcherFollowsSubscription(t *testing.T) {
+	defer func(interval time.Duration) { HeightPollInterval = interval }(HeightPollInterval)
+	HeightPollInterval = time.Hour
+
+	blocks := make(chan uint64)
+	w := NewHeightWatcher("hub_1-1", func(context.Context) (uint64, error) { return 1, nil },
+		func(context.Context) (<-chan uint64, error) { return blocks, nil })
+	defer w.Stop()
+
+	go func() {
+		for h := uint64(2); h <= 4; h++ {
+			blocks <- h
+		}
+	}()
+	h, err := w.WaitForHeight(context.Background(), 4, 5*time.Second)
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), h)
+}
+
+func TestHeightWatcherStall(t *testing.T) {
+	defer func(interval, stall time.Duration) {
+		HeightPollInterval, StallTimeout = interval, stall
+	}(HeightPollInterval, StallTimeout)
+	HeightPollInterval = 5 * time.Millisecond
+	StallTimeout = 50 * time.Millisecond
+
+	queryErr := errors.New("connection refused")
+	var calls atomic.Int32
+	w := NewHeightWatcher("rolx_1-1", func(context.Context) (uint64, error) {
+		if calls.Add(1) > 1 {
+			return 0, queryErr
+		}
+		return 7, nil
+	}, func(context.Context) (<-chan uint64, error) { return nil, errors.New("no websocket") })
+	defer w.Stop()
+
+	_, err := w.WaitForHeight(context.Background(), 8, 0)
+	var stalled *ChainStalledError
+	require.ErrorAs(t, err, &stalled)
+	require.Equal(t, "rolx_1-1", stalled.ChainID)
+	require.Equal(t, uint64(7), stalled.Height)
+	require.ErrorIs(t, err, queryErr)
+	require.ErrorContains(t, err, "chain rolx_1-1 has not produced a block for")
+
+	w.Stop()
+	_, err = w.WaitForHeight(context.Background(), 7, 0)
+	require.NoError(t, err, "heights already seen are still answered")
+}
+
+func TestHeightWatcherTimeoutOverridesStall(t *testing.T) {
+	defer func(interval, stall time.Duration) {
+		HeightPollInterval, StallTimeout = interval, stall
+	}(HeightPollInterval, StallTimeout)
+	HeightPollInterval = 5 * time.Millisecond
+	StallTimeout = 50 * time.Millisecond
+
+	w := NewHeightWatcher("rolx_1-1", func(context.Context) (uint64, error) { return 7, nil },
+		func(context.Context) (<-chan uint64, error) { return nil, errors.New("no websocket") })
+	defer w.Stop()
+
+	_, err := w.WaitForHeight(context.Background(), 8, 200*time.Millisecond)
+	var stalled *ChainStalledError
+	require.False(t, errors.As(err, &stalled), "stalled after %v", err)
+	require.ErrorContains(t, err, "chain rolx_1-1 did not reach height 8 within 200ms (height 7)")
+}
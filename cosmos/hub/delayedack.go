@@ -0,0 +1,99 @@
+package hub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	chanTypes "github.com/cosmos/ibc-go/v7/modules/core/04-channel/types"
+	"github.com/decentrio/e2e-testing-live/cosmos"
+)
+
+// RollappPacket is an IBC packet from or to a rollapp whose handling is
+// delayed by the hub until the rollapp state that carries it is finalized.
+type RollappPacket struct {
+	RollappID       string
+	Packet          chanTypes.Packet
+	Acknowledgement []byte
+	Status          PacketStatus
+	ProofHeight     uint64
+	Relayer         sdk.AccAddress
+	// Type is ON_RECV, ON_ACK or ON_TIMEOUT.
+	Type  string
+	Error string
+}
+
+// UnmarshalJSON decodes a rollapp packet as printed by the hub CLI.
+func (p *RollappPacket) UnmarshalJSON(bz []byte) error {
+	var aux struct {
+		RollappID       string          `json:"rollapp_id"`
+		Packet          json.RawMessage `json:"packet"`
+		Acknowledgement []byte          `json:"acknowledgement"`
+		Status          PacketStatus    `json:"status"`
+		ProofHeight     string          `json:"ProofHeight"`
+		Relayer         []byte          `json:"relayer"`
+		Type            string          `json:"type"`
+		Error           string          `json:"error"`
+	}
+	if err := json.Unmarshal(bz, &aux); err != nil {
+		return err
+	}
+
+	*p = RollappPacket{
+		RollappID:       aux.RollappID,
+		Acknowledgement: aux.Acknowledgement,
+		Status:          aux.Status,
+		Relayer:         aux.Relayer,
+		Type:            aux.Type,
+		Error:           aux.Error,
+	}
+	if len(aux.Packet) > 0 {
+		if err := cosmos.DefaultEncoding().Codec.UnmarshalJSON(aux.Packet, &p.Packet); err != nil {
+			return fmt.Errorf("decode packet: %w", err)
+		}
+	}
+	if aux.ProofHeight != "" {
+		height, err := strconv.ParseUint(aux.ProofHeight, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid proof height %q: %w", aux.ProofHeight, err)
+		}
+		p.ProofHeight = height
+	}
+	return nil
+}
+
+type rollappPacketsResponse struct {
+	RollappPackets []RollappPacket `json:"rollappPackets"`
+}
+
+// QueryRollappPackets lists the delayed packets of rollappID, only those with
+// status unless it is empty.
+func (h *Hub) QueryRollappPackets(ctx context.Context, rollappID string, status PacketStatus) ([]RollappPacket, error) {
+	command := []string{"delayedack", "packets-by-rollapp", rollappID}
+	if status != "" {
+		command = append(command, string(status))
+	}
+
+	var packets rollappPacketsResponse
+	if err := h.ExecQuery(ctx, &packets, command...); err != nil {
+		return nil, err
+	}
+	return packets.RollappPackets, nil
+}
+
+// QueryRollappPacket returns the delayed packet of rollappID with the given
+// source channel and sequence.
+func (h *Hub) QueryRollappPacket(ctx context.Context, rollappID, srcChannel string, sequence uint64) (RollappPacket, error) {
+	packets, err := h.QueryRollappPackets(ctx, rollappID, "")
+	if err != nil {
+		return RollappPacket{}, err
+	}
+	for _, p := range packets {
+		if p.Packet.SourceChannel == srcChannel && p.Packet.Sequence == sequence {
+			return p, nil
+		}
+	}
+	return RollappPacket{}, fmt.Errorf("no delayed packet %s/%d for rollapp %s", srcChannel, sequence, rollappID)
+}
@@ -0,0 +1,29 @@
+package hub
+
+import (
+	"context"
+	"testing"
+
+	"github.com/decentrio/e2e-testing-live/cosmos"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryRollappPacket(t *testing.T) {
+	fake := &cosmos.ScriptedExecutor{}
+	fake.Expect("q", "delayedack", "packets-by-rollapp", "rolx_100004-1").Return(`{"rollappPackets":[{
+		"rollapp_id":"rolx_100004-1",
+		"packet":{"sequence":"7","source_port":"transfer","source_channel":"channel-0","destination_port":"transfer","destination_channel":"channel-5","data":"e30=","timeout_height":{"revision_number":"0","revision_height":"0"},"timeout_timestamp":"1700000000000000000"},
+		"status":"PENDING",
+		"ProofHeight":"120",
+		"type":"ON_RECV"
+	}]}`)
+	h := NewHub(cosmos.CosmosChain{Bin: "dymd", Executor: fake})
+
+	packet, err := h.QueryRollappPacket(context.Background(), "rolx_100004-1", "channel-0", 7)
+	require.NoError(t, err)
+	require.Equal(t, PacketStatusPending, packet.Status)
+	require.Equal(t, uint64(120), packet.ProofHeight)
+	require.Equal(t, "channel-5", packet.Packet.DestinationChannel)
+	require.Equal(t, []byte("{}"), packet.Packet.Data)
+	require.Equal(t, "ON_RECV", packet.Type)
+}
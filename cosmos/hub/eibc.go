@@ -0,0 +1,436 @@
+package hub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	sdkmath "cosmossdk.io/math"
+	abcitypes "github.com/cometbft/cometbft/abci/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	transfertypes "github.com/cosmos/ibc-go/v7/modules/apps/transfer/types"
+	chanTypes "github.com/cosmos/ibc-go/v7/modules/core/04-channel/types"
+	"github.com/decentrio/e2e-testing-live/cosmos"
+	hubtypes "github.com/decentrio/e2e-testing-live/cosmos/hub/types"
+	"github.com/decentrio/rollup-e2e-testing/ibc"
+	"go.uber.org/zap"
+)
+
+// PacketStatus is the delayedack status of the packet tracked by a demand order.
+type PacketStatus string
+
+const (
+	PacketStatusPending   PacketStatus = "PENDING"
+	PacketStatusFinalized PacketStatus = "FINALIZED"
+	PacketStatusReverted  PacketStatus = "REVERTED"
+)
+
+const (
+	// EventTypeEIBC is the event the eibc module emits when it creates or
+	// updates a demand order.
+	EventTypeEIBC = "eibc"
+
+	// DefaultDemandOrderTimeout bounds the demand order lookups and waits
+	// when no timeout is given.
+	DefaultDemandOrderTimeout = 5 * time.Minute
+)
+
+// ErrDemandOrderNotFound is returned when no demand order matches a lookup.
+var ErrDemandOrderNotFound = errors.New("demand order not found")
+
+// DemandOrder is an eIBC demand order as returned by the hub.
+type DemandOrder struct {
+	ID                   string       `json:"id"`
+	TrackingPacketKey    string       `json:"tracking_packet_key"`
+	Price                sdk.Coins    `json:"price"`
+	Fee                  sdk.Coins    `json:"fee"`
+	Recipient            string       `json:"recipient"`
+	IsFulfilled          bool         `json:"is_fulfilled"`
+	FulfillerAddress     string       `json:"fulfiller_address"`
+	TrackingPacketStatus PacketStatus `json:"tracking_packet_status"`
+	RollappID            string       `json:"rollapp_id"`
+}
+
+// UnmarshalJSON also accepts the misspelled is_fullfilled field of older hubs.
+func (o *DemandOrder) UnmarshalJSON(bz []byte) error {
+	type demandOrder DemandOrder
+	var aux struct {
+		demandOrder
+		IsFullfilled bool `json:"is_fullfilled"`
+	}
+	if err := json.Unmarshal(bz, &aux); err != nil {
+		return err
+	}
+	*o = DemandOrder(aux.demandOrder)
+	o.IsFulfilled = o.IsFulfilled || aux.IsFullfilled || o.FulfillerAddress != ""
+	return nil
+}
+
+// ExpectedFee is the fee amount a fulfiller has to acknowledge when fulfilling the order.
+func (o DemandOrder) ExpectedFee() sdkmath.Int {
+	if len(o.Fee) == 0 {
+		return sdkmath.ZeroInt()
+	}
+	return o.Fee[0].Amount
+}
+
+// Finalized reports whether the packet behind the order was finalized on the hub.
+func (o DemandOrder) Finalized() bool {
+	return o.TrackingPacketStatus == PacketStatusFinalized
+}
+
+// EIBCEvent is an eibc event, emitted when a demand order is created or updated.
+type EIBCEvent struct {
+	OrderID string
+	Price   sdk.Coins
+	// Fee is an amount of the price's denom, as in DemandOrder.ExpectedFee.
+	Fee          sdkmath.Int
+	IsFulfilled  bool
+	PacketStatus PacketStatus
+	// The fields below are only emitted by newer hubs.
+	FulfillerAddress string
+	PacketKey        string
+	Recipient        string
+}
+
+// EIBCEvents parses the eibc events.
+func EIBCEvents(events []abcitypes.Event) ([]EIBCEvent, error) {
+	var parsed []EIBCEvent
+	for _, e := range cosmos.EventsOfType(events, EventTypeEIBC) {
+		attrs := cosmos.EventAttributes(e)
+		price, err := sdk.ParseCoinsNormalized(attrs["price"])
+		if err != nil {
+			return nil, fmt.Errorf("parse eibc event price: %w", err)
+		}
+		fee, err := eibcFee(attrs["fee"])
+		if err != nil {
+			return nil, fmt.Errorf("parse eibc event fee: %w", err)
+		}
+		parsed = append(parsed, EIBCEvent{
+			OrderID:          attrs["id"],
+			Price:            price,
+			Fee:              fee,
+			IsFulfilled:      attrs["is_fulfilled"] == "true" || attrs["is_fullfilled"] == "true",
+			PacketStatus:     PacketStatus(attrs["packet_status"]),
+			FulfillerAddress: attrs["fulfiller_address"],
+			PacketKey:        attrs["packet_key"],
+			Recipient:        attrs["recipient"],
+		})
+	}
+	return parsed, nil
+}
+
+// eibcFee parses the fee of an eibc event, a bare amount or, on older hubs,
+// coins.
+func eibcFee(s string) (sdkmath.Int, error) {
+	if s == "" {
+		return sdkmath.ZeroInt(), nil
+	}
+	if amount, ok := sdkmath.NewIntFromString(s); ok {
+		return amount, nil
+	}
+	coins, err := sdk.ParseCoinsNormalized(s)
+	if err != nil || len(coins) == 0 {
+		return sdkmath.Int{}, fmt.Errorf("invalid fee %q", s)
+	}
+	return coins[0].Amount, nil
+}
+
+type demandOrdersResponse struct {
+	DemandOrders []DemandOrder `json:"demand_orders"`
+}
+
+type demandOrderResponse struct {
+	DemandOrder DemandOrder `json:"demand_order"`
+}
+
+// DemandOrdersByStatus lists the demand orders whose packet has status.
+func (h *Hub) DemandOrdersByStatus(ctx context.Context, status PacketStatus) ([]DemandOrder, error) {
+	var orders demandOrdersResponse
+	if err := h.ExecQuery(ctx, &orders, "eibc", "list-demand-orders", string(status)); err != nil {
+		return nil, err
+	}
+	return orders.DemandOrders, nil
+}
+
+// DemandOrdersByRecipient lists the demand orders with status paying out to recipient.
+func (h *Hub) DemandOrdersByRecipient(ctx context.Context, status PacketStatus, recipient string) ([]DemandOrder, error) {
+	orders, err := h.DemandOrdersByStatus(ctx, status)
+	if err != nil {
+		return nil, err
+	}
+
+	var matching []DemandOrder
+	for _, o := range orders {
+		if o.Recipient == recipient {
+			matching = append(matching, o)
+		}
+	}
+	return matching, nil
+}
+
+// DemandOrderByPacketKey returns the demand order with status tracking packetKey.
+func (h *Hub) DemandOrderByPacketKey(ctx context.Context, status PacketStatus, packetKey string) (DemandOrder, error) {
+	orders, err := h.DemandOrdersByStatus(ctx, status)
+	if err != nil {
+		return DemandOrder{}, err
+	}
+
+	for _, o := range orders {
+		if o.TrackingPacketKey == packetKey {
+			return o, nil
+		}
+	}
+	return DemandOrder{}, &cosmos.NotFoundError{Resource: fmt.Sprintf("demand order of packet key %q", packetKey), Kind: ErrDemandOrderNotFound}
+}
+
+// DemandOrder returns the demand order with id.
+func (h *Hub) DemandOrder(ctx context.Context, id string) (DemandOrder, error) {
+	var order demandOrderResponse
+	if err := h.ExecQuery(ctx, &order, "eibc", "show-demand-order", id); err != nil {
+		var notFound *cosmos.NotFoundError
+		if errors.As(err, &notFound) {
+			notFound.Kind = ErrDemandOrderNotFound
+		}
+		return DemandOrder{}, err
+	}
+	return order.DemandOrder, nil
+}
+
+// FindDemandOrder returns the demand order the hub created when it received
+// packet from a rollapp. The hub blocks are scanned from startHeight until the
+// tx receiving packet is found or timeout (DefaultDemandOrderTimeout when
+// zero) elapses.
+func (h *Hub) FindDemandOrder(ctx context.Context, packet ibc.Packet, startHeight uint64, timeout time.Duration) (DemandOrder, error) {
+	if timeout <= 0 {
+		timeout = DefaultDemandOrderTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for height := startHeight; ; {
+		current, err := h.Height(ctx)
+		if err != nil {
+			return DemandOrder{}, err
+		}
+		if height > current {
+			select {
+			case <-ctx.Done():
+				return DemandOrder{}, fmt.Errorf("packet %s/%d not received by %s: %w", packet.SourceChannel, packet.Sequence, h.ChainID, ctx.Err())
+			case <-time.After(cosmos.TxPollInterval):
+			}
+			continue
+		}
+
+		txs, err := h.BlockTxs(ctx, height)
+		if err != nil {
+			return DemandOrder{}, err
+		}
+		for _, tx := range txs {
+			id, found, err := demandOrderID(tx, packet)
+			if err != nil {
+				return DemandOrder{}, fmt.Errorf("tx %s: %w", tx.Hash, err)
+			}
+			if found {
+				return h.DemandOrder(ctx, id)
+			}
+		}
+		height++
+	}
+}
+
+// demandOrderID returns the ID of the demand order tx created for packet. found
+// is false when tx does not receive packet.
+func demandOrderID(tx cosmos.BlockTx, packet ibc.Packet) (id string, found bool, _ error) {
+	if tx.Tx == nil || tx.Result.Code != 0 {
+		return "", false, nil
+	}
+
+	// Events of a 0.47 tx are not tagged with their message, so the eibc
+	// events are paired in order with the packets received by the tx.
+	var recvs, eibcRecvs []int
+	target := -1
+	for _, msg := range tx.Tx.GetMsgs() {
+		recv, ok := msg.(*chanTypes.MsgRecvPacket)
+		if !ok {
+			continue
+		}
+		if samePacket(recv.Packet, packet) {
+			target = len(recvs)
+		}
+		if hasEIbcMemo(recv.Packet.Data) {
+			eibcRecvs = append(eibcRecvs, len(recvs))
+		}
+		recvs = append(recvs, len(recvs))
+	}
+	if target < 0 {
+		return "", false, nil
+	}
+
+	events, err := EIBCEvents(tx.Result.Events)
+	if err != nil {
+		return "", true, err
+	}
+	ids := make([]string, len(events))
+	for i, e := range events {
+		ids[i] = e.OrderID
+	}
+
+	for _, candidates := range [][]int{recvs, eibcRecvs} {
+		if len(candidates) != len(ids) {
+			continue
+		}
+		for i, recv := range candidates {
+			if recv == target {
+				return ids[i], true, nil
+			}
+		}
+		return "", true, demandOrderNotFound(packet)
+	}
+	if len(ids) == 0 {
+		return "", true, demandOrderNotFound(packet)
+	}
+	return "", true, fmt.Errorf("cannot pair %d eibc events with %d received packets", len(ids), len(recvs))
+}
+
+func demandOrderNotFound(packet ibc.Packet) error {
+	return &cosmos.NotFoundError{
+		Resource: fmt.Sprintf("demand order of packet %s/%d", packet.SourceChannel, packet.Sequence),
+		Kind:     ErrDemandOrderNotFound,
+	}
+}
+
+func samePacket(p chanTypes.Packet, packet ibc.Packet) bool {
+	return p.Sequence == packet.Sequence &&
+		p.SourcePort == packet.SourcePort &&
+		p.SourceChannel == packet.SourceChannel
+}
+
+func hasEIbcMemo(data []byte) bool {
+	var packetData transfertypes.FungibleTokenPacketData
+	if err := json.Unmarshal(data, &packetData); err != nil {
+		return false
+	}
+	var memo map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(packetData.Memo), &memo); err != nil {
+		return false
+	}
+	_, ok := memo["eibc"]
+	return ok
+}
+
+// WaitForDemandOrder polls the demand order with id until done returns true
+// or timeout (DefaultDemandOrderTimeout when zero) elapses. The last order
+// seen is returned along with the timeout error.
+func (h *Hub) WaitForDemandOrder(ctx context.Context, id string, timeout time.Duration, done func(DemandOrder) bool) (DemandOrder, error) {
+	if timeout <= 0 {
+		timeout = DefaultDemandOrderTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(cosmos.TxPollInterval)
+	defer ticker.Stop()
+
+	var last DemandOrder
+	for {
+		order, err := h.DemandOrder(ctx, id)
+		if err == nil {
+			last = order
+			if done(order) {
+				return order, nil
+			}
+		} else if ctx.Err() == nil {
+			h.Log().Debug("query demand order", zap.String("id", id), zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return last, fmt.Errorf("demand order %s: %w", id, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// WaitForDemandOrderFulfilled waits until the demand order with id is fulfilled.
+func (h *Hub) WaitForDemandOrderFulfilled(ctx context.Context, id string, timeout time.Duration) (DemandOrder, error) {
+	return h.WaitForDemandOrder(ctx, id, timeout, func(o DemandOrder) bool {
+		return o.IsFulfilled
+	})
+}
+
+// WaitForDemandOrderFinalized waits until the packet behind the demand order
+// with id is finalized. A reverted packet fails the wait immediately.
+func (h *Hub) WaitForDemandOrderFinalized(ctx context.Context, id string, timeout time.Duration) (DemandOrder, error) {
+	order, err := h.WaitForDemandOrder(ctx, id, timeout, func(o DemandOrder) bool {
+		return o.TrackingPacketStatus != PacketStatusPending
+	})
+	if err != nil {
+		return order, err
+	}
+	if !order.Finalized() {
+		return order, fmt.Errorf("demand order %s: packet %s", id, order.TrackingPacketStatus)
+	}
+	return order, nil
+}
+
+// FulfillDemandOrder fulfills order with keyName, acknowledging its current
+// fee, and waits for the tx according to the hub's broadcast mode.
+func (h *Hub) FulfillDemandOrder(ctx context.Context, keyName string, order DemandOrder) (*cosmos.TxResponse, error) {
+	if order.IsFulfilled {
+		return nil, fmt.Errorf("demand order %s already fulfilled by %s", order.ID, order.FulfillerAddress)
+	}
+	return h.ExecTx(ctx, keyName, h.Fees, "eibc", "fulfill-order", order.ID, order.ExpectedFee().String())
+}
+
+// UpdateDemandOrder changes the fee of the demand order with id. Only the
+// order's recipient can update it.
+func (h *Hub) UpdateDemandOrder(ctx context.Context, keyName, id string, newFee sdkmath.Int) (*cosmos.TxResponse, error) {
+	return h.ExecTx(ctx, keyName, h.Fees, "eibc", "update-demand-order", id, newFee.String())
+}
+
+// NewMsgFulfillOrder builds an eIBC order fulfilment. expectedFee may be
+// empty for hubs that predate the field.
+func NewMsgFulfillOrder(fulfiller, orderID, expectedFee string) *hubtypes.MsgFulfillOrder {
+	return &hubtypes.MsgFulfillOrder{
+		FulfillerAddress: fulfiller,
+		OrderId:          orderID,
+		ExpectedFee:      expectedFee,
+	}
+}
+
+// FulfillOrder is the native counterpart of FulfillDemandOrder, signing
+// with keyName from the hub's in-process keyring.
+func (h *Hub) FulfillOrder(ctx context.Context, keyName, orderID, expectedFee string, txOpts cosmos.TxOptions) (*cosmos.TxResponse, error) {
+	if orderID == "" {
+		return nil, errors.New("order id cannot be empty")
+	}
+	fulfiller, err := h.KeyAddress(ctx, keyName)
+	if err != nil {
+		return nil, err
+	}
+	return h.BroadcastTx(ctx, keyName, txOpts, NewMsgFulfillOrder(fulfiller, orderID, expectedFee))
+}
+
+// BuildEIbcMemo returns the transfer memo asking the hub to create a demand
+// order paying fee out of a transfer of amount.
+func BuildEIbcMemo(fee, amount sdkmath.Int) (string, error) {
+	switch {
+	case fee.IsNil() || fee.IsNegative():
+		return "", fmt.Errorf("invalid eibc fee %s", fee)
+	case amount.IsNil() || !amount.IsPositive():
+		return "", fmt.Errorf("invalid transfer amount %s", amount)
+	case fee.GT(amount):
+		return "", fmt.Errorf("eibc fee %s exceeds transfer amount %s", fee, amount)
+	}
+
+	memo, err := json.Marshal(map[string]any{
+		"eibc": map[string]string{"fee": fee.String()},
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(memo), nil
+}
@@ -0,0 +1,177 @@
+package hub
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	sdkmath "cosmossdk.io/math"
+	abcitypes "github.com/cometbft/cometbft/abci/types"
+	rpcclient "github.com/cometbft/cometbft/rpc/client"
+	coretypes "github.com/cometbft/cometbft/rpc/core/types"
+	tmtypes "github.com/cometbft/cometbft/types"
+	transfertypes "github.com/cosmos/ibc-go/v7/modules/apps/transfer/types"
+	clienttypes "github.com/cosmos/ibc-go/v7/modules/core/02-client/types"
+	chanTypes "github.com/cosmos/ibc-go/v7/modules/core/04-channel/types"
+	"github.com/decentrio/e2e-testing-live/cosmos"
+	"github.com/decentrio/rollup-e2e-testing/ibc"
+	"github.com/stretchr/testify/require"
+)
+
+const pendingOrders = `{"demand_orders":[
+	{"id":"a1","tracking_packet_key":"key-a","price":[{"denom":"ibc/X","amount":"900"}],"fee":[{"denom":"ibc/X","amount":"100"}],"recipient":"dym1alice","is_fullfilled":false,"tracking_packet_status":"PENDING"},
+	{"id":"b2","tracking_packet_key":"key-b","price":[{"denom":"ibc/X","amount":"50"}],"fee":[],"recipient":"dym1bob","fulfiller_address":"dym1carol","tracking_packet_status":"PENDING"}
+]}`
+
+func TestDemandOrderQueries(t *testing.T) {
+	fake := &cosmos.ScriptedExecutor{}
+	fake.Expect("q", "eibc", "list-demand-orders", "PENDING").Return(pendingOrders).Repeatedly()
+	h := NewHub(cosmos.CosmosChain{Bin: "dymd", RPCAddr: "rpc:443", Executor: fake})
+
+	orders, err := h.DemandOrdersByRecipient(context.Background(), PacketStatusPending, "dym1alice")
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+	require.Equal(t, "a1", orders[0].ID)
+	require.Equal(t, sdkmath.NewInt(900), orders[0].Price.AmountOf("ibc/X"))
+	require.Equal(t, sdkmath.NewInt(100), orders[0].ExpectedFee())
+	require.False(t, orders[0].IsFulfilled)
+
+	order, err := h.DemandOrderByPacketKey(context.Background(), PacketStatusPending, "key-b")
+	require.NoError(t, err)
+	require.True(t, order.IsFulfilled)
+	require.Equal(t, "dym1carol", order.FulfillerAddress)
+	require.True(t, order.ExpectedFee().IsZero())
+
+	_, err = h.DemandOrderByPacketKey(context.Background(), PacketStatusPending, "key-c")
+	require.ErrorIs(t, err, ErrDemandOrderNotFound)
+}
+
+func TestWaitForDemandOrderFulfilled(t *testing.T) {
+	prev := cosmos.TxPollInterval
+	cosmos.TxPollInterval = time.Millisecond
+	t.Cleanup(func() { cosmos.TxPollInterval = prev })
+
+	fake := &cosmos.ScriptedExecutor{}
+	fake.Expect("q", "eibc", "show-demand-order", "a1").Fail(1, "connection refused")
+	fake.Expect("q", "eibc", "show-demand-order", "a1").Return(`{"demand_order":{"id":"a1","tracking_packet_status":"PENDING"}}`)
+	fake.Expect("q", "eibc", "show-demand-order", "a1").Return(`{"demand_order":{"id":"a1","is_fulfilled":true,"fulfiller_address":"dym1carol","tracking_packet_status":"PENDING"}}`)
+	fake.Expect("q", "eibc", "show-demand-order", "a1").Return(`{"demand_order":{"id":"a1","is_fulfilled":true,"tracking_packet_status":"REVERTED"}}`)
+	h := NewHub(cosmos.CosmosChain{Bin: "dymd", Executor: fake})
+
+	order, err := h.WaitForDemandOrderFulfilled(context.Background(), "a1", time.Second)
+	require.NoError(t, err)
+	require.Equal(t, "dym1carol", order.FulfillerAddress)
+
+	_, err = h.WaitForDemandOrderFinalized(context.Background(), "a1", time.Second)
+	require.ErrorContains(t, err, "REVERTED")
+	require.Empty(t, fake.Pending())
+}
+
+// recvBlockClient serves a single block at height 3 receiving packets.
+type recvBlockClient struct {
+	rpcclient.Client
+	txs    tmtypes.Txs
+	events []abcitypes.Event
+}
+
+func (c recvBlockClient) Status(context.Context) (*coretypes.ResultStatus, error) {
+	return &coretypes.ResultStatus{SyncInfo: coretypes.SyncInfo{LatestBlockHeight: 3}}, nil
+}
+
+func (c recvBlockClient) Block(_ context.Context, height *int64) (*coretypes.ResultBlock, error) {
+	var txs tmtypes.Txs
+	if *height == 3 {
+		txs = c.txs
+	}
+	return &coretypes.ResultBlock{Block: &tmtypes.Block{Data: tmtypes.Data{Txs: txs}}}, nil
+}
+
+func (c recvBlockClient) BlockResults(_ context.Context, height *int64) (*coretypes.ResultBlockResults, error) {
+	var results []*abcitypes.ResponseDeliverTx
+	for range c.txs {
+		if *height == 3 {
+			results = append(results, &abcitypes.ResponseDeliverTx{Events: c.events})
+		}
+	}
+	return &coretypes.ResultBlockResults{Height: *height, TxsResults: results}, nil
+}
+
+func transferPacket(t *testing.T, seq uint64, memo string) chanTypes.Packet {
+	data := transfertypes.NewFungibleTokenPacketData("arolx", "1000", "rol1alice", "dym1alice", memo)
+	return chanTypes.NewPacket(data.GetBytes(), seq, "transfer", "channel-0", "transfer", "channel-5", clienttypes.ZeroHeight(), 1)
+}
+
+func TestFindDemandOrder(t *testing.T) {
+	memo, err := BuildEIbcMemo(sdkmath.NewInt(100), sdkmath.NewInt(1000))
+	require.NoError(t, err)
+	plain, withEIbc := transferPacket(t, 1, ""), transferPacket(t, 2, memo)
+
+	builder := cosmos.DefaultEncoding().TxConfig.NewTxBuilder()
+	require.NoError(t, builder.SetMsgs(
+		&chanTypes.MsgRecvPacket{Packet: plain, Signer: "dym1relayer"},
+		&chanTypes.MsgRecvPacket{Packet: withEIbc, Signer: "dym1relayer"},
+	))
+	tx, err := cosmos.DefaultEncoding().TxConfig.TxEncoder()(builder.GetTx())
+	require.NoError(t, err)
+
+	fake := &cosmos.ScriptedExecutor{}
+	fake.Expect("q", "eibc", "show-demand-order", "b2").Return(`{"demand_order":{"id":"b2","tracking_packet_status":"PENDING"}}`)
+	h := NewHub(cosmos.CosmosChain{Bin: "dymd", Executor: fake, Client: recvBlockClient{
+		txs: tmtypes.Txs{tx},
+		events: []abcitypes.Event{{Type: EventTypeEIBC, Attributes: []abcitypes.EventAttribute{
+			{Key: "id", Value: "b2"},
+			{Key: "fee", Value: "100"},
+		}}},
+	}})
+
+	order, err := h.FindDemandOrder(context.Background(), ibc.Packet{Sequence: 2, SourcePort: "transfer", SourceChannel: "channel-0"}, 1, time.Second)
+	require.NoError(t, err)
+	require.Equal(t, "b2", order.ID)
+
+	_, err = h.FindDemandOrder(context.Background(), ibc.Packet{Sequence: 1, SourcePort: "transfer", SourceChannel: "channel-0"}, 1, time.Second)
+	require.ErrorIs(t, err, ErrDemandOrderNotFound)
+}
+
+func TestBuildEIbcMemo(t *testing.T) {
+	memo, err := BuildEIbcMemo(sdkmath.NewInt(100), sdkmath.NewInt(1000))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"eibc":{"fee":"100"}}`, memo)
+
+	_, err = BuildEIbcMemo(sdkmath.NewInt(-1), sdkmath.NewInt(1000))
+	require.Error(t, err)
+	_, err = BuildEIbcMemo(sdkmath.NewInt(1001), sdkmath.NewInt(1000))
+	require.ErrorContains(t, err, "exceeds")
+	_, err = BuildEIbcMemo(sdkmath.Int{}, sdkmath.NewInt(1000))
+	require.Error(t, err)
+}
+
+func TestEIBCEvents(t *testing.T) {
+	events, err := EIBCEvents([]abcitypes.Event{
+		{Type: "transfer"},
+		{Type: EventTypeEIBC, Attributes: []abcitypes.EventAttribute{
+			{Key: "id", Value: "c3"},
+			{Key: "price", Value: "990adym"},
+			{Key: "fee", Value: "10"},
+			{Key: "is_fulfilled", Value: "true"},
+			{Key: "packet_status", Value: "PENDING"},
+		}},
+		{Type: EventTypeEIBC, Attributes: []abcitypes.EventAttribute{
+			{Key: "aWQ=", Value: "ZDQ="},                     // id: d4
+			{Key: "ZmVl", Value: "NWFkeW0="},                 // fee: 5adym
+			{Key: "aXNfZnVsbGZpbGxlZA==", Value: "ZmFsc2U="}, // is_fullfilled: false
+		}},
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.Equal(t, "c3", events[0].OrderID)
+	require.Equal(t, "990adym", events[0].Price.String())
+	require.Equal(t, int64(10), events[0].Fee.Int64())
+	require.True(t, events[0].IsFulfilled)
+	require.Equal(t, PacketStatusPending, events[0].PacketStatus)
+	require.Equal(t, "d4", events[1].OrderID)
+	require.Equal(t, int64(5), events[1].Fee.Int64())
+	require.False(t, events[1].IsFulfilled)
+
+	_, err = EIBCEvents([]abcitypes.Event{{Type: EventTypeEIBC, Attributes: []abcitypes.EventAttribute{{Key: "fee", Value: "ten"}}}})
+	require.ErrorContains(t, err, `invalid fee "ten"`)
+}
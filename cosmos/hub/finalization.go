@@ -0,0 +1,296 @@
+package hub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cometbft/cometbft/libs/service"
+	coretypes "github.com/cometbft/cometbft/rpc/core/types"
+	"github.com/decentrio/e2e-testing-live/cosmos"
+	hubtypes "github.com/decentrio/e2e-testing-live/cosmos/hub/types"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// EventTypeStateUpdate is emitted by the rollapp module when a sequencer
+	// submits a state update.
+	EventTypeStateUpdate = "state_update"
+	// EventTypeStatusChange is emitted by the rollapp module when a state
+	// update is finalized or reverted.
+	EventTypeStatusChange = "status_change"
+
+	// DefaultFinalizationTimeout bounds WaitForRollappFinalized when no timeout is given.
+	DefaultFinalizationTimeout = 30 * time.Minute
+)
+
+// FinalizationOptions tunes WaitForRollappFinalized. The zero value is usable.
+type FinalizationOptions struct {
+	// Timeout defaults to DefaultFinalizationTimeout.
+	Timeout time.Duration
+	// PollInterval is the first delay between two gRPC queries, doubled up
+	// to MaxPollInterval while nothing changes. Defaults to 2s.
+	PollInterval time.Duration
+	// MaxPollInterval defaults to 30s. It is also the polling interval kept
+	// as a safety net while events are received over the websocket.
+	MaxPollInterval time.Duration
+	// Progress is called whenever the finalized or submitted height moves.
+	// Progress is logged by the hub when nil.
+	Progress func(FinalizationProgress)
+}
+
+func (o *FinalizationOptions) setDefaults(logger *zap.Logger) {
+	if o.Timeout <= 0 {
+		o.Timeout = DefaultFinalizationTimeout
+	}
+	if o.PollInterval <= 0 {
+		o.PollInterval = 2 * time.Second
+	}
+	if o.MaxPollInterval <= 0 {
+		o.MaxPollInterval = 30 * time.Second
+	}
+	if o.MaxPollInterval < o.PollInterval {
+		o.MaxPollInterval = o.PollInterval
+	}
+	if o.Progress == nil {
+		o.Progress = func(p FinalizationProgress) { logger.Info("rollapp finalization", zap.Stringer("progress", p)) }
+	}
+}
+
+// FinalizationProgress is how far a rollapp got towards having Target finalized on the hub.
+type FinalizationProgress struct {
+	RollappID string
+	Target    uint64
+	// Finalized is the last rollapp height of the latest finalized state update.
+	Finalized uint64
+	// Submitted is the last rollapp height of the latest state update seen,
+	// finalized or not. It is zero until an update is seen.
+	Submitted uint64
+}
+
+// Done reports whether Target is finalized.
+func (p FinalizationProgress) Done() bool {
+	return p.Finalized >= p.Target
+}
+
+func (p FinalizationProgress) String() string {
+	s := fmt.Sprintf("rollapp %s finalized up to %d of target %d", p.RollappID, p.Finalized, p.Target)
+	if p.Submitted > p.Finalized {
+		s += fmt.Sprintf(" (submitted up to %d)", p.Submitted)
+	}
+	return s
+}
+
+// QueryStateInfo returns the latest state update of rollappID over gRPC, or
+// the latest finalized one when finalized is set.
+func (h *Hub) QueryStateInfo(ctx context.Context, rollappID string, finalized bool) (*hubtypes.StateInfo, error) {
+	client, err := h.RollappQuery()
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.StateInfo(ctx, &hubtypes.QueryGetStateInfoRequest{RollappId: rollappID, Finalized: finalized})
+	if err != nil {
+		return nil, err
+	}
+	if res.StateInfo == nil {
+		return nil, fmt.Errorf("no state info for rollapp %s", rollappID)
+	}
+	return res.StateInfo, nil
+}
+
+// latestHeights returns the last rollapp heights of the latest finalized and
+// of the latest submitted state updates. A rollapp without any (finalized)
+// update yields zero.
+func (h *Hub) latestHeights(ctx context.Context, rollappID string) (finalized, submitted uint64, _ error) {
+	for _, onlyFinalized := range []bool{true, false} {
+		info, err := h.QueryStateInfo(ctx, rollappID, onlyFinalized)
+		switch {
+		case status.Code(err) == codes.NotFound:
+		case err != nil:
+			return 0, 0, err
+		case onlyFinalized:
+			finalized = info.EndHeight()
+		default:
+			submitted = info.EndHeight()
+		}
+	}
+	return finalized, submitted, nil
+}
+
+// WaitForRollappFinalized waits until the rollapp height target is covered
+// by a finalized state update of rollappID. Finalization is followed through
+// the hub's state_update and status_change events over the Client websocket;
+// the hub is also polled over gRPC, with backoff, in case events are missed
+// or the websocket is unavailable. The last progress seen is returned.
+func (h *Hub) WaitForRollappFinalized(ctx context.Context, rollappID string, target uint64, opts FinalizationOptions) (FinalizationProgress, error) {
+	opts.setDefaults(h.Log())
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	progress := FinalizationProgress{RollappID: rollappID, Target: target}
+	update := func(finalized, submitted uint64) bool {
+		moved := false
+		if finalized > progress.Finalized {
+			progress.Finalized, moved = finalized, true
+		}
+		if submitted > progress.Submitted {
+			progress.Submitted, moved = submitted, true
+		}
+		if moved {
+			opts.Progress(progress)
+		}
+		return moved
+	}
+
+	var pollErr error
+	poll := func() bool {
+		finalized, submitted, err := h.latestHeights(ctx, rollappID)
+		if err != nil {
+			pollErr = err
+			return false
+		}
+		pollErr = nil
+		return update(finalized, submitted)
+	}
+
+	poll()
+	if progress.Done() {
+		return progress, nil
+	}
+
+	interval := opts.PollInterval
+	events, err := h.subscribeRollappEvents(ctx, rollappID)
+	if err != nil {
+		h.Log().Warn("websocket unavailable, polling rollapp state", zap.String("rollapp_id", rollappID), zap.Error(err))
+	} else {
+		interval = opts.MaxPollInterval
+	}
+
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			err := fmt.Errorf("%s: %w", progress, ctx.Err())
+			if pollErr != nil {
+				err = errors.Join(err, fmt.Errorf("last state info query: %w", pollErr))
+			}
+			return progress, err
+		case ev, ok := <-events:
+			if !ok {
+				// The websocket went away, poll from now on.
+				events = nil
+				interval = opts.PollInterval
+				timer.Reset(interval)
+				continue
+			}
+			update(rollappEventHeights(ev, rollappID))
+		case <-timer.C:
+			switch {
+			case events != nil:
+				poll()
+				interval = opts.MaxPollInterval
+			case poll():
+				interval = opts.PollInterval
+			default:
+				interval = min(2*interval, opts.MaxPollInterval)
+			}
+			timer.Reset(interval)
+		}
+		if progress.Done() {
+			return progress, nil
+		}
+	}
+}
+
+// subscribeRollappEvents subscribes to the state updates and status changes
+// of rollappID. The returned channel is closed once ctx is done. The
+// websocket is not used while a cassette replays.
+func (h *Hub) subscribeRollappEvents(ctx context.Context, rollappID string) (<-chan coretypes.ResultEvent, error) {
+	if h.Client == nil {
+		return nil, fmt.Errorf("chain %s has no rpc client", h.ChainID)
+	}
+	if cosmos.Replaying() {
+		return nil, cosmos.ErrWebsocketReplayed
+	}
+	if err := h.Client.Start(); err != nil && !errors.Is(err, service.ErrAlreadyStarted) {
+		return nil, fmt.Errorf("start websocket: %w", err)
+	}
+
+	subscriber := fmt.Sprintf("e2e-finalization-%s-%d", rollappID, time.Now().UnixNano())
+	queries := []string{
+		fmt.Sprintf("tm.event='Tx' AND %s.rollapp_id='%s'", EventTypeStateUpdate, rollappID),
+		fmt.Sprintf("tm.event='NewBlock' AND %s.rollapp_id='%s'", EventTypeStatusChange, rollappID),
+	}
+	var subs []<-chan coretypes.ResultEvent
+	for _, q := range queries {
+		sub, err := h.Client.Subscribe(ctx, subscriber, q)
+		if err != nil {
+			_ = h.Client.UnsubscribeAll(context.Background(), subscriber)
+			return nil, fmt.Errorf("subscribe %q: %w", q, err)
+		}
+		subs = append(subs, sub)
+	}
+
+	out := make(chan coretypes.ResultEvent)
+	var wg sync.WaitGroup
+	for _, sub := range subs {
+		wg.Add(1)
+		go func(sub <-chan coretypes.ResultEvent) {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case ev, ok := <-sub:
+					if !ok {
+						return
+					}
+					select {
+					case out <- ev:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}(sub)
+	}
+	go func() {
+		wg.Wait()
+		_ = h.Client.UnsubscribeAll(context.Background(), subscriber)
+		close(out)
+	}()
+	return out, nil
+}
+
+// rollappEventHeights extracts from ev the last rollapp heights of the state
+// updates of rollappID it finalizes and submits.
+func rollappEventHeights(ev coretypes.ResultEvent, rollappID string) (finalized, submitted uint64) {
+	for _, eventType := range []string{EventTypeStatusChange, EventTypeStateUpdate} {
+		ids := ev.Events[eventType+".rollapp_id"]
+		starts := ev.Events[eventType+".start_height"]
+		nums := ev.Events[eventType+".num_blocks"]
+		statuses := ev.Events[eventType+".status"]
+		for i, id := range ids {
+			if id != rollappID || i >= len(starts) || i >= len(nums) {
+				continue
+			}
+			start, err1 := strconv.ParseUint(starts[i], 10, 64)
+			num, err2 := strconv.ParseUint(nums[i], 10, 64)
+			if err1 != nil || err2 != nil || num == 0 {
+				continue
+			}
+			end := start + num - 1
+			submitted = max(submitted, end)
+			if i < len(statuses) && statuses[i] == "FINALIZED" {
+				finalized = max(finalized, end)
+			}
+		}
+	}
+	return finalized, submitted
+}
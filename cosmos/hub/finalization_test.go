@@ -0,0 +1,163 @@
+package hub
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	rpcclient "github.com/cometbft/cometbft/rpc/client"
+	coretypes "github.com/cometbft/cometbft/rpc/core/types"
+	"github.com/decentrio/e2e-testing-live/cosmos"
+	hubtypes "github.com/decentrio/e2e-testing-live/cosmos/hub/types"
+	"github.com/decentrio/e2e-testing-live/testutil/fakechain"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// stateInfoServer answers rollapp StateInfo queries with the next of its
+// scripted finalized end heights, repeating the last one. Zero means that
+// nothing is finalized yet.
+type stateInfoServer struct {
+	mu        sync.Mutex
+	finalized []uint64
+	submitted uint64
+}
+
+func (s *stateInfoServer) stateInfo(req *hubtypes.QueryGetStateInfoRequest) (*hubtypes.QueryGetStateInfoResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	end := s.submitted
+	if req.Finalized {
+		end = s.finalized[0]
+		if len(s.finalized) > 1 {
+			s.finalized = s.finalized[1:]
+		}
+	}
+	if end == 0 {
+		return nil, status.Error(codes.NotFound, "not found")
+	}
+	return &hubtypes.QueryGetStateInfoResponse{StateInfo: &hubtypes.StateInfo{
+		StateInfoIndex: &hubtypes.StateInfoIndex{RollappId: req.RollappId, Index: 1},
+		StartHeight:    1,
+		NumBlocks:      end,
+	}}, nil
+}
+
+// serveStateInfo starts a fake chain answering rollapp state info queries from
+// s and returns its gRPC address.
+func serveStateInfo(t *testing.T, s *stateInfoServer) string {
+	return fakechain.New(t, fakechain.Options{Services: s.register}).GRPCAddr()
+}
+
+func (s *stateInfoServer) register(server *grpc.Server) {
+	server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "dymensionxyz.dymension.rollapp.Query",
+		HandlerType: (*any)(nil),
+		Methods: []grpc.MethodDesc{{
+			MethodName: "StateInfo",
+			Handler: func(_ any, _ context.Context, dec func(any) error, _ grpc.UnaryServerInterceptor) (any, error) {
+				var req hubtypes.QueryGetStateInfoRequest
+				if err := dec(&req); err != nil {
+					return nil, err
+				}
+				return s.stateInfo(&req)
+			},
+		}},
+	}, struct{}{})
+}
+
+// eventClient hands out a single subscription channel per query.
+type eventClient struct {
+	rpcclient.Client
+	mu         sync.Mutex
+	subs       map[string]chan coretypes.ResultEvent
+	subscribed int
+}
+
+func (c *eventClient) Start() error { return nil }
+
+func (c *eventClient) Subscribe(_ context.Context, _, query string, _ ...int) (<-chan coretypes.ResultEvent, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.subscribed++
+	return c.subs[query], nil
+}
+
+func (c *eventClient) UnsubscribeAll(context.Context, string) error { return nil }
+
+func TestWaitForRollappFinalizedFromEvents(t *testing.T) {
+	addr := serveStateInfo(t, &stateInfoServer{finalized: []uint64{5}, submitted: 10})
+	statusChanges := make(chan coretypes.ResultEvent, 1)
+	client := &eventClient{subs: map[string]chan coretypes.ResultEvent{
+		"tm.event='Tx' AND state_update.rollapp_id='rolx'":        make(chan coretypes.ResultEvent),
+		"tm.event='NewBlock' AND status_change.rollapp_id='rolx'": statusChanges,
+	}}
+	h := NewHub(cosmos.CosmosChain{GrpcAddr: addr, Client: client})
+
+	statusChanges <- coretypes.ResultEvent{Events: map[string][]string{
+		"status_change.rollapp_id":   {"roly", "rolx"},
+		"status_change.start_height": {"1", "6"},
+		"status_change.num_blocks":   {"100", "5"},
+		"status_change.status":       {"FINALIZED", "FINALIZED"},
+	}}
+
+	var reports []string
+	progress, err := h.WaitForRollappFinalized(context.Background(), "rolx", 10, FinalizationOptions{
+		Timeout:      5 * time.Second,
+		PollInterval: time.Hour,
+		Progress:     func(p FinalizationProgress) { reports = append(reports, p.String()) },
+	})
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), progress.Finalized)
+	require.Equal(t, []string{
+		"rollapp rolx finalized up to 5 of target 10 (submitted up to 10)",
+		"rollapp rolx finalized up to 10 of target 10",
+	}, reports)
+}
+
+func TestWaitForRollappFinalizedPolling(t *testing.T) {
+	addr := serveStateInfo(t, &stateInfoServer{finalized: []uint64{0, 3, 3, 12}, submitted: 12})
+	h := NewHub(cosmos.CosmosChain{GrpcAddr: addr})
+
+	var reports []FinalizationProgress
+	progress, err := h.WaitForRollappFinalized(context.Background(), "rolx", 10, FinalizationOptions{
+		Timeout:         5 * time.Second,
+		PollInterval:    time.Millisecond,
+		MaxPollInterval: 5 * time.Millisecond,
+		Progress:        func(p FinalizationProgress) { reports = append(reports, p) },
+	})
+	require.NoError(t, err)
+	require.True(t, progress.Done())
+	require.Equal(t, uint64(12), progress.Finalized)
+	require.Len(t, reports, 3)
+	require.Equal(t, uint64(3), reports[1].Finalized)
+
+	_, err = h.WaitForRollappFinalized(context.Background(), "rolx", 20, FinalizationOptions{
+		Timeout:      20 * time.Millisecond,
+		PollInterval: time.Millisecond,
+		Progress:     func(FinalizationProgress) {},
+	})
+	require.ErrorContains(t, err, "finalized up to 12 of target 20")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestRollappEventsNotSubscribedWhileReplaying(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty.cassette.jsonl")
+	require.NoError(t, os.WriteFile(path, nil, 0o600))
+	cassette, err := cosmos.OpenCassette(path, cosmos.CassetteReplay)
+	require.NoError(t, err)
+	restore, err := cosmos.UseCassette(cassette)
+	require.NoError(t, err)
+	defer restore()
+
+	client := &eventClient{}
+	h := NewHub(cosmos.CosmosChain{ChainID: "hub_1-1", Client: client})
+	_, err = h.subscribeRollappEvents(context.Background(), "rolx")
+	require.ErrorIs(t, err, cosmos.ErrWebsocketReplayed)
+	require.Zero(t, client.subscribed, "live events must not mix into a replay")
+}
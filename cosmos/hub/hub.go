@@ -1,4 +1,27 @@
 package hub
 
+import (
+	"github.com/decentrio/e2e-testing-live/cosmos"
+	hubtypes "github.com/decentrio/e2e-testing-live/cosmos/hub/types"
+)
+
+// Hub is a Dymension hub. It embeds the generic CosmosChain and adds the
+// hub specific modules on top of it: rollapp, sequencer, eibc, delayedack,
+// streamer and incentives.
 type Hub struct {
+	cosmos.CosmosChain
+}
+
+// NewHub wraps chain as a Dymension hub.
+func NewHub(chain cosmos.CosmosChain) *Hub {
+	return &Hub{CosmosChain: chain}
+}
+
+// RollappQuery returns a rollapp module query client over the hub's shared gRPC connection.
+func (h *Hub) RollappQuery() (hubtypes.RollappQueryClient, error) {
+	conn, err := h.GRPCConn()
+	if err != nil {
+		return nil, err
+	}
+	return hubtypes.NewRollappQueryClient(conn), nil
 }
@@ -0,0 +1,89 @@
+package hub
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/decentrio/rollup-e2e-testing/dymension"
+)
+
+// QueryRollapp returns the rollapp registered on the hub as rollappName.
+func (h *Hub) QueryRollapp(ctx context.Context, rollappName string) (*dymension.QueryGetRollappResponse, error) {
+	var rollapp dymension.QueryGetRollappResponse
+	if err := h.ExecQuery(ctx, &rollapp, "rollapp", "show", rollappName); err != nil {
+		return nil, err
+	}
+	return &rollapp, nil
+}
+
+// QueryRollappState returns the latest state update of rollappName, or the
+// latest finalized one when onlyFinalized is set.
+func (h *Hub) QueryRollappState(ctx context.Context, rollappName string, onlyFinalized bool) (*dymension.RollappState, error) {
+	command := []string{"rollapp", "state", rollappName}
+	if onlyFinalized {
+		command = append(command, "--finalized")
+	}
+
+	var rollappState dymension.RollappState
+	if err := h.ExecQuery(ctx, &rollappState, command...); err != nil {
+		return nil, err
+	}
+	return &rollappState, nil
+}
+
+// QueryLatestStateIndex returns the index of the latest (finalized) state update of rollappName.
+func (h *Hub) QueryLatestStateIndex(ctx context.Context, rollappName string, onlyFinalized bool) (*dymension.QueryGetLatestStateIndexResponse, error) {
+	command := []string{"rollapp", "latest-state-index", rollappName}
+	if onlyFinalized {
+		command = append(command, "--finalized")
+	}
+
+	var stateIndex dymension.QueryGetLatestStateIndexResponse
+	if err := h.ExecQuery(ctx, &stateIndex, command...); err != nil {
+		return nil, err
+	}
+	return &stateIndex, nil
+}
+
+// FinalizedRollappStateHeight returns the last rollapp height of the latest finalized state update of rollappName.
+func (h *Hub) FinalizedRollappStateHeight(ctx context.Context, rollappName string) (uint64, error) {
+	rollappState, err := h.QueryRollappState(ctx, rollappName, true)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(rollappState.StateInfo.BlockDescriptors.BD) == 0 {
+		return 0, fmt.Errorf("no block descriptors found for rollapp %s", rollappName)
+	}
+
+	lastBD := rollappState.StateInfo.BlockDescriptors.BD[len(rollappState.StateInfo.BlockDescriptors.BD)-1]
+	parsedHeight, err := strconv.ParseUint(lastBD.Height, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return parsedHeight, nil
+}
+
+// FinalizedRollappDymHeight returns the hub height the latest finalized state update of rollappName was created at.
+func (h *Hub) FinalizedRollappDymHeight(ctx context.Context, rollappName string) (uint64, error) {
+	rollappState, err := h.QueryRollappState(ctx, rollappName, true)
+	if err != nil {
+		return 0, err
+	}
+
+	parsedHeight, err := strconv.ParseUint(rollappState.StateInfo.CreationHeight, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return parsedHeight, nil
+}
+
+// FinalizedRollappStateIndex returns the index of the latest finalized state update of rollappName.
+func (h *Hub) FinalizedRollappStateIndex(ctx context.Context, rollappName string) (uint64, error) {
+	stateIndex, err := h.QueryLatestStateIndex(ctx, rollappName, true)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(stateIndex.StateIndex.Index, 10, 64)
+}
@@ -0,0 +1,43 @@
+package hub
+
+import (
+	"context"
+	"testing"
+
+	"github.com/decentrio/e2e-testing-live/cosmos"
+	"github.com/stretchr/testify/require"
+)
+
+const finalizedState = `{"stateInfo":{"stateInfoIndex":{"rollappId":"rolx_100004-1","index":"42"},"sequencer":"dym1seq","startHeight":"101","numBlocks":"2","creationHeight":"9000","status":"FINALIZED","BDs":{"BD":[{"height":"101"},{"height":"102"}]}}}`
+
+func TestRollappQueries(t *testing.T) {
+	fake := &cosmos.ScriptedExecutor{}
+	fake.Expect("q", "rollapp", "state", "rolx_100004-1").Return(finalizedState).Repeatedly()
+	fake.Expect("q", "rollapp", "latest-state-index", "rolx_100004-1").Return(`{"stateIndex":{"rollappId":"rolx_100004-1","index":"42"}}`)
+	fake.Expect("q", "sequencer", "show-sequencers-by-rollapp", "rolx_100004-1").Return(`{"sequencers":[
+		{"sequencerAddress":"dym1standby","rollappId":"rolx_100004-1","status":"OPERATING_STATUS_BONDED"},
+		{"sequencerAddress":"dym1seq","rollappId":"rolx_100004-1","proposer":true,"status":"OPERATING_STATUS_BONDED"}
+	]}`)
+	h := NewHub(cosmos.CosmosChain{Bin: "dymd", RPCAddr: "rpc:443", Executor: fake})
+	ctx := context.Background()
+
+	height, err := h.FinalizedRollappStateHeight(ctx, "rolx_100004-1")
+	require.NoError(t, err)
+	require.Equal(t, uint64(102), height)
+
+	dymHeight, err := h.FinalizedRollappDymHeight(ctx, "rolx_100004-1")
+	require.NoError(t, err)
+	require.Equal(t, uint64(9000), dymHeight)
+
+	index, err := h.FinalizedRollappStateIndex(ctx, "rolx_100004-1")
+	require.NoError(t, err)
+	require.Equal(t, uint64(42), index)
+
+	proposer, err := h.Proposer(ctx, "rolx_100004-1")
+	require.NoError(t, err)
+	require.Equal(t, "dym1seq", proposer.SequencerAddress)
+
+	state := fake.Calls()[0]
+	require.Equal(t, []string{"dymd", "q", "rollapp", "state", "rolx_100004-1", "--finalized", "--node", "https://rpc:443", "--output", "json"}, state)
+	require.Empty(t, fake.Pending())
+}
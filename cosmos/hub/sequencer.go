@@ -0,0 +1,40 @@
+package hub
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/decentrio/rollup-e2e-testing/dymension"
+)
+
+// QuerySequencersByRollapp returns the sequencers registered for rollappName.
+func (h *Hub) QuerySequencersByRollapp(ctx context.Context, rollappName string) (*dymension.QueryGetSequencersByRollappResponse, error) {
+	var sequencers dymension.QueryGetSequencersByRollappResponse
+	if err := h.ExecQuery(ctx, &sequencers, "sequencer", "show-sequencers-by-rollapp", rollappName); err != nil {
+		return nil, err
+	}
+	return &sequencers, nil
+}
+
+// QuerySequencer returns the sequencer with address sequencerAddr.
+func (h *Hub) QuerySequencer(ctx context.Context, sequencerAddr string) (*dymension.QueryGetSequencerResponse, error) {
+	var sequencer dymension.QueryGetSequencerResponse
+	if err := h.ExecQuery(ctx, &sequencer, "sequencer", "show-sequencer", sequencerAddr); err != nil {
+		return nil, err
+	}
+	return &sequencer, nil
+}
+
+// Proposer returns the sequencer currently producing rollappName's blocks.
+func (h *Hub) Proposer(ctx context.Context, rollappName string) (dymension.Sequencer, error) {
+	sequencers, err := h.QuerySequencersByRollapp(ctx, rollappName)
+	if err != nil {
+		return dymension.Sequencer{}, err
+	}
+	for _, seq := range sequencers.Sequencers {
+		if seq.Proposer {
+			return seq, nil
+		}
+	}
+	return dymension.Sequencer{}, fmt.Errorf("rollapp %s has no proposer among %d sequencers", rollappName, len(sequencers.Sequencers))
+}
@@ -0,0 +1,119 @@
+package hub
+
+import (
+	"context"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/decentrio/e2e-testing-live/cosmos"
+	"github.com/decentrio/rollup-e2e-testing/dymension"
+)
+
+// Stream is a streamer module stream paying Coins out to gauges over
+// NumEpochsPaidOver epochs.
+type Stream struct {
+	ID                   string    `json:"id"`
+	DistributeTo         DistrInfo `json:"distribute_to"`
+	Coins                sdk.Coins `json:"coins"`
+	StartTime            time.Time `json:"start_time"`
+	DistrEpochIdentifier string    `json:"distr_epoch_identifier"`
+	NumEpochsPaidOver    string    `json:"num_epochs_paid_over"`
+	FilledEpochs         string    `json:"filled_epochs"`
+	DistributedCoins     sdk.Coins `json:"distributed_coins"`
+	Sponsored            bool      `json:"sponsored"`
+}
+
+// DistrInfo splits a stream between gauges by weight.
+type DistrInfo struct {
+	TotalWeight string        `json:"total_weight"`
+	Records     []DistrRecord `json:"records"`
+}
+
+// DistrRecord is the weight of a single gauge in a DistrInfo.
+type DistrRecord struct {
+	GaugeID string `json:"gauge_id"`
+	Weight  string `json:"weight"`
+}
+
+// Gauge is an incentives module gauge distributing Coins to lockups or rollapps.
+type Gauge struct {
+	ID                string    `json:"id"`
+	IsPerpetual       bool      `json:"is_perpetual"`
+	Coins             sdk.Coins `json:"coins"`
+	StartTime         time.Time `json:"start_time"`
+	NumEpochsPaidOver string    `json:"num_epochs_paid_over"`
+	FilledEpochs      string    `json:"filled_epochs"`
+	DistributedCoins  sdk.Coins `json:"distributed_coins"`
+}
+
+type streamsResponse struct {
+	Data []Stream `json:"data"`
+}
+
+type gaugesResponse struct {
+	Data []Gauge `json:"data"`
+}
+
+// QueryStreams returns all streams, only the active ones if activeOnly is set.
+func (h *Hub) QueryStreams(ctx context.Context, activeOnly bool) ([]Stream, error) {
+	command := "streams"
+	if activeOnly {
+		command = "active-streams"
+	}
+
+	var streams streamsResponse
+	if err := h.ExecQuery(ctx, &streams, "streamer", command); err != nil {
+		return nil, err
+	}
+	return streams.Data, nil
+}
+
+// QueryStream returns the stream with id.
+func (h *Hub) QueryStream(ctx context.Context, id string) (Stream, error) {
+	var stream struct {
+		Stream Stream `json:"stream"`
+	}
+	if err := h.ExecQuery(ctx, &stream, "streamer", "stream-by-id", id); err != nil {
+		return Stream{}, err
+	}
+	return stream.Stream, nil
+}
+
+// QueryGauges returns all gauges, only the active ones if activeOnly is set.
+func (h *Hub) QueryGauges(ctx context.Context, activeOnly bool) ([]Gauge, error) {
+	command := "gauges"
+	if activeOnly {
+		command = "active-gauges"
+	}
+
+	var gauges gaugesResponse
+	if err := h.ExecQuery(ctx, &gauges, "incentives", command); err != nil {
+		return nil, err
+	}
+	return gauges.Data, nil
+}
+
+// QueryGauge returns the gauge with id.
+func (h *Hub) QueryGauge(ctx context.Context, id string) (Gauge, error) {
+	var gauge struct {
+		Gauge Gauge `json:"gauge"`
+	}
+	if err := h.ExecQuery(ctx, &gauge, "incentives", "gauge-by-id", id); err != nil {
+		return Gauge{}, err
+	}
+	return gauge.Gauge, nil
+}
+
+// AddToGauge adds rewards to the gauge with id.
+func (h *Hub) AddToGauge(ctx context.Context, keyName, id string, rewards sdk.Coins) (*cosmos.TxResponse, error) {
+	return h.ExecTx(ctx, keyName, h.Fees, "incentives", "add-to-gauge", id, rewards.String())
+}
+
+// QueryEpochInfos returns the hub's epochs, which pace stream and gauge distributions.
+func (h *Hub) QueryEpochInfos(ctx context.Context) (*dymension.QueryEpochsInfoResponse, error) {
+	var epochs dymension.QueryEpochsInfoResponse
+	if err := h.ExecQuery(ctx, &epochs, "epochs", "epoch-infos"); err != nil {
+		return nil, err
+	}
+	return &epochs, nil
+}
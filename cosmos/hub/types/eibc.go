@@ -0,0 +1,104 @@
+// Package types holds minimal, hand-written protobuf definitions of the
+// Dymension hub messages used by the tests. Only the fields the tests need
+// are declared; importing the dymension module itself would drag its whole
+// dependency tree into this repository.
+package types
+
+import (
+	"errors"
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+	"github.com/decentrio/e2e-testing-live/internal/protoenc"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+var eibcFileDescriptor = protoenc.GzipFileDescriptor("dymensionxyz/dymension/eibc/tx.proto", "dymensionxyz.dymension.eibc",
+	protoenc.MessageDescriptor("MsgFulfillOrder",
+		protoenc.StringField("fulfiller_address", 1),
+		protoenc.StringField("order_id", 2),
+		protoenc.StringField("expected_fee", 3),
+	),
+	protoenc.MessageDescriptor("MsgFulfillOrderResponse"),
+)
+
+// MsgFulfillOrder mirrors dymensionxyz.dymension.eibc.MsgFulfillOrder.
+type MsgFulfillOrder struct {
+	FulfillerAddress string `protobuf:"bytes,1,opt,name=fulfiller_address,proto3" json:"fulfiller_address,omitempty"`
+	OrderId          string `protobuf:"bytes,2,opt,name=order_id,proto3" json:"order_id,omitempty"`
+	ExpectedFee      string `protobuf:"bytes,3,opt,name=expected_fee,proto3" json:"expected_fee,omitempty"`
+}
+
+var _ sdk.Msg = (*MsgFulfillOrder)(nil)
+
+func (m *MsgFulfillOrder) Reset()         { *m = MsgFulfillOrder{} }
+func (m *MsgFulfillOrder) String() string { return fmt.Sprintf("%+v", *m) }
+func (*MsgFulfillOrder) ProtoMessage()    {}
+
+func (*MsgFulfillOrder) XXX_MessageName() string {
+	return "dymensionxyz.dymension.eibc.MsgFulfillOrder"
+}
+
+func (*MsgFulfillOrder) Descriptor() ([]byte, []int) {
+	return eibcFileDescriptor, []int{0}
+}
+
+func (m *MsgFulfillOrder) ValidateBasic() error {
+	if _, _, err := bech32.DecodeAndConvert(m.FulfillerAddress); err != nil {
+		return fmt.Errorf("invalid fulfiller address: %w", err)
+	}
+	if m.OrderId == "" {
+		return errors.New("order id cannot be empty")
+	}
+	return nil
+}
+
+func (m *MsgFulfillOrder) GetSigners() []sdk.AccAddress {
+	_, bz, err := bech32.DecodeAndConvert(m.FulfillerAddress)
+	if err != nil {
+		panic(err)
+	}
+	return []sdk.AccAddress{bz}
+}
+
+func (m *MsgFulfillOrder) Marshal() ([]byte, error) {
+	var b []byte
+	b = protoenc.AppendString(b, 1, m.FulfillerAddress)
+	b = protoenc.AppendString(b, 2, m.OrderId)
+	b = protoenc.AppendString(b, 3, m.ExpectedFee)
+	return b, nil
+}
+
+func (m *MsgFulfillOrder) Unmarshal(bz []byte) error {
+	m.Reset()
+	return protoenc.RangeFields(bz, func(num protowire.Number, typ protowire.Type, v []byte) error {
+		switch num {
+		case 1:
+			m.FulfillerAddress = string(v)
+		case 2:
+			m.OrderId = string(v)
+		case 3:
+			m.ExpectedFee = string(v)
+		}
+		return nil
+	})
+}
+
+// MsgFulfillOrderResponse mirrors dymensionxyz.dymension.eibc.MsgFulfillOrderResponse.
+type MsgFulfillOrderResponse struct{}
+
+func (m *MsgFulfillOrderResponse) Reset()         { *m = MsgFulfillOrderResponse{} }
+func (m *MsgFulfillOrderResponse) String() string { return "{}" }
+func (*MsgFulfillOrderResponse) ProtoMessage()    {}
+
+func (*MsgFulfillOrderResponse) XXX_MessageName() string {
+	return "dymensionxyz.dymension.eibc.MsgFulfillOrderResponse"
+}
+
+func (*MsgFulfillOrderResponse) Descriptor() ([]byte, []int) {
+	return eibcFileDescriptor, []int{1}
+}
+
+func (m *MsgFulfillOrderResponse) Marshal() ([]byte, error) { return nil, nil }
+func (m *MsgFulfillOrderResponse) Unmarshal([]byte) error   { return nil }
@@ -0,0 +1,230 @@
+package types
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/decentrio/e2e-testing-live/internal/protoenc"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// QueryStateInfoMethod is the full gRPC method name of the rollapp StateInfo query.
+const QueryStateInfoMethod = "/dymensionxyz.dymension.rollapp.Query/StateInfo"
+
+// RollappQueryClient is the subset of the rollapp module's Query service used by the tests.
+type RollappQueryClient interface {
+	StateInfo(ctx context.Context, in *QueryGetStateInfoRequest, opts ...grpc.CallOption) (*QueryGetStateInfoResponse, error)
+}
+
+type rollappQueryClient struct {
+	cc grpc.ClientConnInterface
+}
+
+// NewRollappQueryClient returns a rollapp module query client over cc.
+func NewRollappQueryClient(cc grpc.ClientConnInterface) RollappQueryClient {
+	return &rollappQueryClient{cc}
+}
+
+func (c *rollappQueryClient) StateInfo(ctx context.Context, in *QueryGetStateInfoRequest, opts ...grpc.CallOption) (*QueryGetStateInfoResponse, error) {
+	out := new(QueryGetStateInfoResponse)
+	if err := c.cc.Invoke(ctx, QueryStateInfoMethod, in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// QueryGetStateInfoRequest mirrors dymensionxyz.dymension.rollapp.QueryGetStateInfoRequest.
+// Without Index nor Height the latest (finalized) state info is returned.
+type QueryGetStateInfoRequest struct {
+	RollappId string `protobuf:"bytes,1,opt,name=rollappId,proto3" json:"rollappId,omitempty"`
+	Index     uint64 `protobuf:"varint,2,opt,name=index,proto3" json:"index,omitempty"`
+	Height    uint64 `protobuf:"varint,3,opt,name=height,proto3" json:"height,omitempty"`
+	Finalized bool   `protobuf:"varint,4,opt,name=finalized,proto3" json:"finalized,omitempty"`
+}
+
+func (m *QueryGetStateInfoRequest) Reset()         { *m = QueryGetStateInfoRequest{} }
+func (m *QueryGetStateInfoRequest) String() string { return fmt.Sprintf("%+v", *m) }
+func (*QueryGetStateInfoRequest) ProtoMessage()    {}
+
+func (*QueryGetStateInfoRequest) XXX_MessageName() string {
+	return "dymensionxyz.dymension.rollapp.QueryGetStateInfoRequest"
+}
+
+func (m *QueryGetStateInfoRequest) Marshal() ([]byte, error) {
+	var b []byte
+	b = protoenc.AppendString(b, 1, m.RollappId)
+	b = protoenc.AppendUint64(b, 2, m.Index)
+	b = protoenc.AppendUint64(b, 3, m.Height)
+	b = protoenc.AppendBool(b, 4, m.Finalized)
+	return b, nil
+}
+
+func (m *QueryGetStateInfoRequest) Unmarshal(bz []byte) error {
+	m.Reset()
+	return protoenc.RangeFields(bz, func(num protowire.Number, typ protowire.Type, v []byte) error {
+		var err error
+		switch num {
+		case 1:
+			m.RollappId = string(v)
+		case 2:
+			m.Index, err = protoenc.Varint(v)
+		case 3:
+			m.Height, err = protoenc.Varint(v)
+		case 4:
+			var x uint64
+			x, err = protoenc.Varint(v)
+			m.Finalized = x != 0
+		}
+		return err
+	})
+}
+
+// QueryGetStateInfoResponse mirrors dymensionxyz.dymension.rollapp.QueryGetStateInfoResponse.
+type QueryGetStateInfoResponse struct {
+	StateInfo *StateInfo `protobuf:"bytes,1,opt,name=stateInfo,proto3" json:"stateInfo,omitempty"`
+}
+
+func (m *QueryGetStateInfoResponse) Reset()         { *m = QueryGetStateInfoResponse{} }
+func (m *QueryGetStateInfoResponse) String() string { return fmt.Sprintf("%+v", *m) }
+func (*QueryGetStateInfoResponse) ProtoMessage()    {}
+
+func (*QueryGetStateInfoResponse) XXX_MessageName() string {
+	return "dymensionxyz.dymension.rollapp.QueryGetStateInfoResponse"
+}
+
+func (m *QueryGetStateInfoResponse) Marshal() ([]byte, error) {
+	if m.StateInfo == nil {
+		return nil, nil
+	}
+	bz, err := m.StateInfo.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return protoenc.AppendBytes(nil, 1, bz), nil
+}
+
+func (m *QueryGetStateInfoResponse) Unmarshal(bz []byte) error {
+	m.Reset()
+	return protoenc.RangeFields(bz, func(num protowire.Number, typ protowire.Type, v []byte) error {
+		if num != 1 {
+			return nil
+		}
+		m.StateInfo = &StateInfo{}
+		return m.StateInfo.Unmarshal(v)
+	})
+}
+
+// StateInfo mirrors the fields of dymensionxyz.dymension.rollapp.StateInfo
+// describing which rollapp blocks a state update covers. The block
+// descriptors are not decoded.
+type StateInfo struct {
+	StateInfoIndex *StateInfoIndex `protobuf:"bytes,1,opt,name=stateInfoIndex,proto3" json:"stateInfoIndex,omitempty"`
+	Sequencer      string          `protobuf:"bytes,2,opt,name=sequencer,proto3" json:"sequencer,omitempty"`
+	StartHeight    uint64          `protobuf:"varint,3,opt,name=startHeight,proto3" json:"startHeight,omitempty"`
+	NumBlocks      uint64          `protobuf:"varint,4,opt,name=numBlocks,proto3" json:"numBlocks,omitempty"`
+	DAPath         string          `protobuf:"bytes,5,opt,name=DAPath,proto3" json:"DAPath,omitempty"`
+	CreationHeight uint64          `protobuf:"varint,7,opt,name=creationHeight,proto3" json:"creationHeight,omitempty"`
+	Status         int32           `protobuf:"varint,8,opt,name=status,proto3" json:"status,omitempty"`
+}
+
+func (m *StateInfo) Reset()         { *m = StateInfo{} }
+func (m *StateInfo) String() string { return fmt.Sprintf("%+v", *m) }
+func (*StateInfo) ProtoMessage()    {}
+
+func (*StateInfo) XXX_MessageName() string {
+	return "dymensionxyz.dymension.rollapp.StateInfo"
+}
+
+// EndHeight returns the last rollapp height covered by the state update.
+func (m *StateInfo) EndHeight() uint64 {
+	if m.NumBlocks == 0 {
+		return m.StartHeight
+	}
+	return m.StartHeight + m.NumBlocks - 1
+}
+
+// Contains reports whether the state update covers the rollapp height.
+func (m *StateInfo) Contains(height uint64) bool {
+	return m.NumBlocks > 0 && height >= m.StartHeight && height <= m.EndHeight()
+}
+
+func (m *StateInfo) Marshal() ([]byte, error) {
+	var b []byte
+	if m.StateInfoIndex != nil {
+		bz, err := m.StateInfoIndex.Marshal()
+		if err != nil {
+			return nil, err
+		}
+		b = protoenc.AppendBytes(b, 1, bz)
+	}
+	b = protoenc.AppendString(b, 2, m.Sequencer)
+	b = protoenc.AppendUint64(b, 3, m.StartHeight)
+	b = protoenc.AppendUint64(b, 4, m.NumBlocks)
+	b = protoenc.AppendString(b, 5, m.DAPath)
+	b = protoenc.AppendUint64(b, 7, m.CreationHeight)
+	b = protoenc.AppendUint64(b, 8, uint64(m.Status))
+	return b, nil
+}
+
+func (m *StateInfo) Unmarshal(bz []byte) error {
+	m.Reset()
+	return protoenc.RangeFields(bz, func(num protowire.Number, typ protowire.Type, v []byte) error {
+		var err error
+		switch num {
+		case 1:
+			m.StateInfoIndex = &StateInfoIndex{}
+			err = m.StateInfoIndex.Unmarshal(v)
+		case 2:
+			m.Sequencer = string(v)
+		case 3:
+			m.StartHeight, err = protoenc.Varint(v)
+		case 4:
+			m.NumBlocks, err = protoenc.Varint(v)
+		case 5:
+			m.DAPath = string(v)
+		case 7:
+			m.CreationHeight, err = protoenc.Varint(v)
+		case 8:
+			var x uint64
+			x, err = protoenc.Varint(v)
+			m.Status = int32(x)
+		}
+		return err
+	})
+}
+
+// StateInfoIndex mirrors dymensionxyz.dymension.rollapp.StateInfoIndex.
+type StateInfoIndex struct {
+	RollappId string `protobuf:"bytes,1,opt,name=rollappId,proto3" json:"rollappId,omitempty"`
+	Index     uint64 `protobuf:"varint,2,opt,name=index,proto3" json:"index,omitempty"`
+}
+
+func (m *StateInfoIndex) Reset()         { *m = StateInfoIndex{} }
+func (m *StateInfoIndex) String() string { return fmt.Sprintf("%+v", *m) }
+func (*StateInfoIndex) ProtoMessage()    {}
+
+func (*StateInfoIndex) XXX_MessageName() string {
+	return "dymensionxyz.dymension.rollapp.StateInfoIndex"
+}
+
+func (m *StateInfoIndex) Marshal() ([]byte, error) {
+	var b []byte
+	b = protoenc.AppendString(b, 1, m.RollappId)
+	b = protoenc.AppendUint64(b, 2, m.Index)
+	return b, nil
+}
+
+func (m *StateInfoIndex) Unmarshal(bz []byte) error {
+	m.Reset()
+	return protoenc.RangeFields(bz, func(num protowire.Number, typ protowire.Type, v []byte) error {
+		var err error
+		switch num {
+		case 1:
+			m.RollappId = string(v)
+		case 2:
+			m.Index, err = protoenc.Varint(v)
+		}
+		return err
+	})
+}
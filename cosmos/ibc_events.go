@@ -0,0 +1,149 @@
+package cosmos
+
+import (
+	"fmt"
+	"strconv"
+
+	sdkmath "cosmossdk.io/math"
+	abcitypes "github.com/cometbft/cometbft/abci/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	transfertypes "github.com/cosmos/ibc-go/v7/modules/apps/transfer/types"
+	chanTypes "github.com/cosmos/ibc-go/v7/modules/core/04-channel/types"
+	"github.com/decentrio/rollup-e2e-testing/ibc"
+)
+
+// PacketEvent is a packet lifecycle event of the channel module, such as
+// send_packet or recv_packet.
+type PacketEvent struct {
+	Type       string
+	Packet     ibc.Packet
+	Connection string
+	Ordering   string
+}
+
+// SendPacketEvents parses the send_packet events.
+func SendPacketEvents(events []abcitypes.Event) ([]PacketEvent, error) {
+	return PacketEvents(events, chanTypes.EventTypeSendPacket)
+}
+
+// RecvPacketEvents parses the recv_packet events.
+func RecvPacketEvents(events []abcitypes.Event) ([]PacketEvent, error) {
+	return PacketEvents(events, chanTypes.EventTypeRecvPacket)
+}
+
+// PacketEvents parses the events of eventType, one of the channel module's
+// packet events.
+func PacketEvents(events []abcitypes.Event, eventType string) ([]PacketEvent, error) {
+	var parsed []PacketEvent
+	for _, e := range EventsOfType(events, eventType) {
+		for _, attrs := range attributeGroups(e) {
+			packet, err := packetFromAttributes(attrs)
+			if err != nil {
+				return nil, fmt.Errorf("parse %s event: %w", eventType, err)
+			}
+			parsed = append(parsed, PacketEvent{
+				Type:       eventType,
+				Packet:     packet,
+				Connection: attrs[chanTypes.AttributeKeyConnection],
+				Ordering:   attrs[chanTypes.AttributeKeyChannelOrdering],
+			})
+		}
+	}
+	return parsed, nil
+}
+
+// FungibleTokenPacketEvent is a fungible_token_packet event of the transfer
+// module, emitted when a transfer is received and when it is acknowledged.
+type FungibleTokenPacketEvent struct {
+	Sender   string
+	Receiver string
+	Denom    string
+	Amount   sdkmath.Int
+	Memo     string
+	// Success and Error report the outcome: on the receiving chain, of
+	// crediting the tokens; on the sending chain, of the acknowledgement.
+	Success bool
+	Error   string
+	// Acknowledgement is only set on the sending chain.
+	Acknowledgement string
+}
+
+// FungibleTokenPacketEvents parses the fungible_token_packet events. The
+// transfer module reports the outcome of an acknowledgement in a second event,
+// which is merged into the one before it.
+func FungibleTokenPacketEvents(events []abcitypes.Event) ([]FungibleTokenPacketEvent, error) {
+	var parsed []FungibleTokenPacketEvent
+	for _, e := range EventsOfType(events, transfertypes.EventTypePacket) {
+		for _, attrs := range attributeGroups(e) {
+			if _, ok := attrs[transfertypes.AttributeKeyReceiver]; !ok && len(parsed) > 0 {
+				last := &parsed[len(parsed)-1]
+				last.Success, last.Error = transferOutcome(attrs)
+				continue
+			}
+			amount, ok := sdkmath.NewIntFromString(attrs[transfertypes.AttributeKeyAmount])
+			if !ok {
+				return nil, fmt.Errorf("parse %s event: invalid amount %q", e.Type, attrs[transfertypes.AttributeKeyAmount])
+			}
+			event := FungibleTokenPacketEvent{
+				Sender:          attrs[sdk.AttributeKeySender],
+				Receiver:        attrs[transfertypes.AttributeKeyReceiver],
+				Denom:           attrs[transfertypes.AttributeKeyDenom],
+				Amount:          amount,
+				Memo:            attrs[transfertypes.AttributeKeyMemo],
+				Acknowledgement: attrs[transfertypes.AttributeKeyAck],
+			}
+			event.Success, event.Error = transferOutcome(attrs)
+			parsed = append(parsed, event)
+		}
+	}
+	return parsed, nil
+}
+
+func transferOutcome(attrs map[string]string) (bool, string) {
+	if errMsg, ok := attrs[transfertypes.AttributeKeyAckError]; ok {
+		return false, errMsg
+	}
+	success, ok := attrs[transfertypes.AttributeKeyAckSuccess]
+	return ok && success != "false", ""
+}
+
+func packetFromAttributes(attrs map[string]string) (ibc.Packet, error) {
+	seq, err := strconv.ParseUint(attrs[chanTypes.AttributeKeySequence], 10, 64)
+	if err != nil {
+		return ibc.Packet{}, fmt.Errorf("invalid packet sequence: %w", err)
+	}
+	timeoutNanos, err := strconv.ParseUint(attrs[chanTypes.AttributeKeyTimeoutTimestamp], 10, 64)
+	if err != nil {
+		return ibc.Packet{}, fmt.Errorf("invalid packet timeout timestamp: %w", err)
+	}
+	data, err := hexOrRaw(attrs, chanTypes.AttributeKeyDataHex, chanTypes.AttributeKeyData)
+	if err != nil {
+		return ibc.Packet{}, err
+	}
+	return ibc.Packet{
+		Sequence:         seq,
+		SourcePort:       attrs[chanTypes.AttributeKeySrcPort],
+		SourceChannel:    attrs[chanTypes.AttributeKeySrcChannel],
+		DestPort:         attrs[chanTypes.AttributeKeyDstPort],
+		DestChannel:      attrs[chanTypes.AttributeKeyDstChannel],
+		Data:             data,
+		TimeoutHeight:    attrs[chanTypes.AttributeKeyTimeoutHeight],
+		TimeoutTimestamp: ibc.Nanoseconds(timeoutNanos),
+	}, nil
+}
+
+// attributeGroups returns the attributes of a decoded event, split wherever
+// a key repeats: the tx logs of SDK 0.47 merge the events of a message that
+// share a type into one.
+func attributeGroups(e abcitypes.Event) []map[string]string {
+	var groups []map[string]string
+	var current map[string]string
+	for _, attr := range e.Attributes {
+		if _, seen := current[attr.Key]; current == nil || seen {
+			current = make(map[string]string)
+			groups = append(groups, current)
+		}
+		current[attr.Key] = attr.Value
+	}
+	return groups
+}
@@ -0,0 +1,197 @@
+package cosmos
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/cosmos/cosmos-sdk/crypto/keyring"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"github.com/ethereum/go-ethereum/common"
+	ethhd "github.com/evmos/ethermint/crypto/hd"
+)
+
+// NewKeyring opens a keyring rooted at dir. Both secp256k1 and eth_secp256k1
+// keys are supported, so the same keyring works for the hub and EVM rollapps.
+func NewKeyring(backend, dir string) (keyring.Keyring, error) {
+	return keyring.New(sdk.KeyringServiceName(), backend, dir, nil, DefaultEncoding().Codec, ethhd.EthSecp256k1Option())
+}
+
+// NewInMemoryKeyring returns a keyring that lives only as long as the process.
+func NewInMemoryKeyring() keyring.Keyring {
+	return keyring.NewInMemory(DefaultEncoding().Codec, ethhd.EthSecp256k1Option())
+}
+
+// CreateUser creates the key keyName in the chain binary's keyring and
+// returns it as a user of the chain's denom.
+func (c *CosmosChain) CreateUser(ctx context.Context, keyName string) (User, error) {
+	return c.CreateKey(ctx, keyName)
+}
+
+// CreateKey creates the key name from a fresh mnemonic, returned in the
+// user's Mnemonic so the key can be recovered later.
+func (c *CosmosChain) CreateKey(ctx context.Context, name string) (User, error) {
+	command := append([]string{"keys", "add", name, "--output", "json"}, c.keyFlags()...)
+	res, err := c.Exec(ctx, append(command, c.keyringFlags()...)...)
+	if err != nil {
+		return User{}, err
+	}
+	return c.parseKey(res)
+}
+
+// RecoverKey imports the key name derived from mnemonic, with the chain's
+// KeyAlgo and HDPath.
+func (c *CosmosChain) RecoverKey(ctx context.Context, name, mnemonic string) (User, error) {
+	command := append([]string{"keys", "add", name, "--recover", "--output", "json"}, c.keyFlags()...)
+	res, err := c.execStdin(ctx, []byte(mnemonic+"\n"), append(command, c.keyringFlags()...)...)
+	if err != nil {
+		return User{}, err
+	}
+	user, err := c.parseKey(res)
+	if err != nil {
+		return User{}, err
+	}
+	user.Mnemonic = mnemonic
+	return user, nil
+}
+
+// Key returns the key name of the chain binary's keyring.
+func (c *CosmosChain) Key(ctx context.Context, name string) (User, error) {
+	res, err := c.Exec(ctx, append([]string{"keys", "show", name, "--output", "json"}, c.keyringFlags()...)...)
+	if err != nil {
+		return User{}, keyNotFound(name, err)
+	}
+	return c.parseKey(res)
+}
+
+// ListKeys returns every key of the chain binary's keyring.
+func (c *CosmosChain) ListKeys(ctx context.Context) ([]User, error) {
+	res, err := c.Exec(ctx, append([]string{"keys", "list", "--output", "json"}, c.keyringFlags()...)...)
+	if err != nil {
+		return nil, err
+	}
+	// An empty keyring prints a notice on stderr instead of a JSON list.
+	if len(bytes.TrimSpace(res.Stdout)) == 0 {
+		return nil, nil
+	}
+	var keys []keyring.KeyOutput
+	if err := json.Unmarshal(res.Stdout, &keys); err != nil {
+		return nil, fmt.Errorf("decode keys list output: %w", err)
+	}
+	users := make([]User, 0, len(keys))
+	for _, key := range keys {
+		user, err := c.keyUser(key)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+// ExportKey returns the hex-encoded private key of name, which can be
+// imported into an in-process Keyring with ImportPrivKeyHex.
+func (c *CosmosChain) ExportKey(ctx context.Context, name string) (string, error) {
+	command := append([]string{"keys", "export", name, "--unarmored-hex", "--unsafe"}, c.keyringFlags()...)
+	// The binary asks for confirmation before printing the key.
+	res, err := c.execStdin(ctx, []byte("y\n"), command...)
+	if err != nil {
+		return "", err
+	}
+	privKey := string(lastLine(res))
+	if _, err := hex.DecodeString(privKey); err != nil || privKey == "" {
+		return "", fmt.Errorf("keys export %s: unexpected output %q", name, privKey)
+	}
+	return privKey, nil
+}
+
+// DeleteKey removes the key name from the chain binary's keyring.
+func (c *CosmosChain) DeleteKey(ctx context.Context, name string) error {
+	_, err := c.Exec(ctx, append([]string{"keys", "delete", name, "-y"}, c.keyringFlags()...)...)
+	return keyNotFound(name, err)
+}
+
+// KeyBech32 returns the bech32 address of the key name.
+func (c *CosmosChain) KeyBech32(ctx context.Context, name string) (string, error) {
+	res, err := c.Exec(ctx, append([]string{"keys", "show", "--address", name}, c.keyringFlags()...)...)
+	if err != nil {
+		return "", keyNotFound(name, err)
+	}
+	return string(bytes.TrimSuffix(res.Stdout, []byte("\n"))), nil
+}
+
+// keyNotFound returns err as a *NotFoundError of kind sdkerrors.ErrKeyNotFound
+// if the binary reported name missing.
+func keyNotFound(name string, err error) error {
+	err = AsNotFound("key "+name, err)
+	var notFound *NotFoundError
+	if errors.As(err, &notFound) {
+		notFound.Kind = sdkerrors.ErrKeyNotFound
+	}
+	return err
+}
+
+// keyringFlags selects the chain's keyring in key and tx commands.
+func (c CosmosChain) keyringFlags() []string {
+	flags := []string{"--keyring-backend", keyring.BackendTest}
+	if c.KeyringHome != "" {
+		flags = append(flags, "--home", c.KeyringHome)
+	}
+	return flags
+}
+
+// keyFlags selects how new keys are derived.
+func (c CosmosChain) keyFlags() []string {
+	var flags []string
+	if c.KeyAlgo != "" {
+		flags = append(flags, "--algo", c.KeyAlgo)
+	}
+	if c.HDPath != "" {
+		flags = append(flags, "--hd-path", c.HDPath)
+	}
+	return flags
+}
+
+// parseKey decodes the JSON key printed by keys add and keys show. keys add
+// prints it on stderr.
+func (c CosmosChain) parseKey(res ExecResult) (User, error) {
+	var key keyring.KeyOutput
+	if err := json.Unmarshal(lastLine(res), &key); err != nil {
+		return User{}, fmt.Errorf("decode key output: %w", err)
+	}
+	return c.keyUser(key)
+}
+
+func (c CosmosChain) keyUser(key keyring.KeyOutput) (User, error) {
+	_, bz, err := bech32.DecodeAndConvert(key.Address)
+	if err != nil {
+		return User{}, fmt.Errorf("key %s: %w", key.Name, err)
+	}
+	return User{
+		KeyName:    key.Name,
+		Address:    key.Address,
+		HexAddress: common.BytesToAddress(bz).Hex(),
+		PubKey:     key.PubKey,
+		Mnemonic:   key.Mnemonic,
+		Denom:      c.Denom,
+		Logger:     c.Logger,
+	}, nil
+}
+
+// lastLine returns the last non-empty line of the command's stdout, or of
+// its stderr if stdout is empty.
+func lastLine(res ExecResult) []byte {
+	out := bytes.TrimSpace(res.Stdout)
+	if len(out) == 0 {
+		out = bytes.TrimSpace(res.Stderr)
+	}
+	if i := bytes.LastIndexByte(out, '\n'); i >= 0 {
+		out = bytes.TrimSpace(out[i+1:])
+	}
+	return out
+}
@@ -0,0 +1,97 @@
+package cosmos
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+)
+
+// keyJSON is the output of keys add and keys show for a key whose address
+// bytes are all b.
+func keyJSON(name string, b byte, mnemonic string) string {
+	out, _ := json.Marshal(map[string]string{
+		"name":     name,
+		"type":     "local",
+		"address":  sdk.MustBech32ifyAddressBytes("dym", bytes.Repeat([]byte{b}, 20)),
+		"pubkey":   `{"@type":"/ethermint.crypto.v1.ethsecp256k1.PubKey","key":"A8x"}`,
+		"mnemonic": mnemonic,
+	})
+	return string(out)
+}
+
+func TestKeyringCommands(t *testing.T) {
+	const mnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	fake := &ScriptedExecutor{}
+	chain := CosmosChain{
+		Bin:         "rollapp-evm",
+		Denom:       "arolx",
+		KeyringHome: "/tmp/ci-keys",
+		KeyAlgo:     "eth_secp256k1",
+		HDPath:      "m/44'/60'/0'/0/0",
+		Executor:    fake,
+	}
+	ctx := context.Background()
+
+	// keys add --recover prints the key on stderr.
+	fake.Expect("keys", "add", "ci", "--recover", "--output", "json",
+		"--algo", "eth_secp256k1", "--hd-path", "m/44'/60'/0'/0/0",
+		"--keyring-backend", "test", "--home", "/tmp/ci-keys").Result = ExecResult{Stderr: []byte("\n" + keyJSON("ci", 0x11, "") + "\n")}
+	user, err := chain.RecoverKey(ctx, "ci", mnemonic)
+	require.NoError(t, err)
+	require.Equal(t, mnemonic+"\n", string(fake.Stdin(0)))
+	require.Equal(t, User{
+		KeyName:    "ci",
+		Address:    sdk.MustBech32ifyAddressBytes("dym", bytes.Repeat([]byte{0x11}, 20)),
+		HexAddress: "0x1111111111111111111111111111111111111111",
+		PubKey:     `{"@type":"/ethermint.crypto.v1.ethsecp256k1.PubKey","key":"A8x"}`,
+		Mnemonic:   mnemonic,
+		Denom:      "arolx",
+	}, user)
+
+	fake.Expect("keys", "show", "ci", "--output", "json", "--keyring-backend", "test", "--home", "/tmp/ci-keys").Return(keyJSON("ci", 0x11, ""))
+	shown, err := chain.Key(ctx, "ci")
+	require.NoError(t, err)
+	require.Equal(t, user.HexAddress, shown.HexAddress)
+	require.Empty(t, shown.Mnemonic)
+
+	fake.Expect("keys", "list").Return("[" + keyJSON("ci", 0x11, "") + "," + keyJSON("treasury", 0x22, "") + "]")
+	keys, err := chain.ListKeys(ctx)
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	require.Equal(t, "treasury", keys[1].KeyName)
+	require.Equal(t, "0x2222222222222222222222222222222222222222", keys[1].HexAddress)
+
+	fake.Expect("keys", "list").Result = ExecResult{Stderr: []byte("No records were found in keyring\n")}
+	keys, err = chain.ListKeys(ctx)
+	require.NoError(t, err)
+	require.Empty(t, keys)
+
+	fake.Expect("keys", "export", "ci", "--unarmored-hex", "--unsafe").Result = ExecResult{
+		Stderr: []byte("WARNING: The private key will be exported as an unarmored hexadecimal string. USE AT YOUR OWN RISK. Continue? [y/N]: \n0a1b2c\n"),
+	}
+	privKey, err := chain.ExportKey(ctx, "ci")
+	require.NoError(t, err)
+	require.Equal(t, "0a1b2c", privKey)
+	require.Equal(t, "y\n", string(fake.Stdin(4)))
+
+	fake.Expect("keys", "delete", "ci", "-y", "--keyring-backend", "test", "--home", "/tmp/ci-keys")
+	require.NoError(t, chain.DeleteKey(ctx, "ci"))
+	require.Empty(t, fake.Pending())
+}
+
+// plainExecutor cannot feed stdin.
+type plainExecutor struct{}
+
+func (plainExecutor) Exec(context.Context, string, ...string) (ExecResult, error) {
+	return ExecResult{}, nil
+}
+
+func TestRecoverKeyNeedsStdin(t *testing.T) {
+	chain := CosmosChain{Bin: "dymd", Executor: plainExecutor{}}
+	_, err := chain.RecoverKey(context.Background(), "ci", "word")
+	require.ErrorContains(t, err, "cannot feed stdin to dymd keys")
+}
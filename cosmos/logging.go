@@ -0,0 +1,54 @@
+package cosmos
+
+import (
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// DefaultLogger is used by chains that do not set a Logger. It discards
+// everything.
+var DefaultLogger = zap.NewNop()
+
+// Log returns the chain's logger, tagged with its chain ID.
+func (c CosmosChain) Log() *zap.Logger {
+	logger := c.Logger
+	if logger == nil {
+		logger = DefaultLogger
+	}
+	return logger.With(zap.String("chain_id", c.ChainID))
+}
+
+func (user *User) log() *zap.Logger {
+	if user.Logger == nil {
+		return DefaultLogger
+	}
+	return user.Logger
+}
+
+var (
+	mnemonicField = regexp.MustCompile(`"mnemonic"\s*:\s*"[^"]*"`)
+	mnemonicLine  = regexp.MustCompile(`(?m)^\s*([a-z]+ ){11,23}[a-z]+\s*$`)
+	privKeyHex    = regexp.MustCompile(`\b[0-9a-f]{64}\b`)
+)
+
+// Markers replacing the secrets hidden by redact.
+const (
+	redactedMnemonicField = `"mnemonic":"<redacted>"`
+	redactedMnemonic      = "<redacted mnemonic>"
+	redactedKey           = "<redacted key>"
+)
+
+// redact hides the secrets key commands print on stderr: mnemonics, bare or
+// in JSON, and hex private keys.
+func redact(s string) string {
+	return strings.TrimSpace(redactSecrets(s))
+}
+
+// redactSecrets is redact, leaving the surrounding whitespace.
+func redactSecrets(s string) string {
+	s = mnemonicField.ReplaceAllString(s, redactedMnemonicField)
+	s = mnemonicLine.ReplaceAllString(s, redactedMnemonic)
+	return privKeyHex.ReplaceAllString(s, redactedKey)
+}
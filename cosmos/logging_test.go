@@ -0,0 +1,44 @@
+package cosmos
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestExecLogsRedactedStderr(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	fake := &ScriptedExecutor{}
+	fake.Expect("keys", "add", "ci").Result = ExecResult{
+		Stderr:   []byte(`{"name":"ci","address":"dym1qqqsyqcyq5rqwzqfpg9scrgwpugpzysn3tau5h","mnemonic":"abandon abandon about"}`),
+		ExitCode: 0,
+	}
+	fake.Expect("keys", "export").Fail(1, "Continue? [y/N]:\n"+
+		"0a1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f9\n"+
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about\n")
+
+	chain := CosmosChain{ChainID: "hub_1-1", Bin: "dymd", Executor: fake, Logger: zap.New(core)}
+	_, err := chain.CreateKey(context.Background(), "ci")
+	require.NoError(t, err)
+	_, err = chain.ExportKey(context.Background(), "ci")
+	require.Error(t, err)
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 2)
+	add := entries[0].ContextMap()
+	require.Equal(t, "exec", entries[0].Message)
+	require.Equal(t, zap.DebugLevel, entries[0].Level)
+	require.Equal(t, "hub_1-1", add["chain_id"])
+	require.Equal(t, "dymd keys add ci --output json --keyring-backend test", add["cmd"])
+	require.Equal(t, int64(0), add["exit_code"])
+	require.Contains(t, add, "duration")
+	require.Equal(t, `{"name":"ci","address":"dym1qqqsyqcyq5rqwzqfpg9scrgwpugpzysn3tau5h","mnemonic":"<redacted>"}`, add["stderr"])
+
+	export := entries[1].ContextMap()
+	require.Equal(t, int64(1), export["exit_code"])
+	require.Equal(t, "Continue? [y/N]:\n<redacted key>\n<redacted mnemonic>", export["stderr"])
+	require.Equal(t, "exit status 1", export["error"])
+}
@@ -0,0 +1,91 @@
+package cosmos
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	abcitypes "github.com/cometbft/cometbft/abci/types"
+	chanTypes "github.com/cosmos/ibc-go/v7/modules/core/04-channel/types"
+	"github.com/decentrio/rollup-e2e-testing/ibc"
+)
+
+// WriteAcknowledgements returns the acknowledgements the chain wrote in block
+// at height, from the write_acknowledgement events of its txs and of
+// begin/end block. Chains with asynchronous acks (e.g. the hub's delayedack)
+// write them in a later block than the one receiving the packet.
+func (c CosmosChain) WriteAcknowledgements(ctx context.Context, height uint64) ([]ibc.PacketAcknowledgement, error) {
+	h := int64(height)
+	res, err := c.Client.BlockResults(ctx, &h)
+	if err != nil {
+		return nil, fmt.Errorf("find written acknowledgements at height %d: %w", height, err)
+	}
+
+	events := append([]abcitypes.Event{}, res.BeginBlockEvents...)
+	for _, tx := range res.TxsResults {
+		events = append(events, tx.Events...)
+	}
+	events = append(events, res.EndBlockEvents...)
+
+	var acks []ibc.PacketAcknowledgement
+	for _, e := range events {
+		if e.Type != chanTypes.EventTypeWriteAck {
+			continue
+		}
+		ack, err := packetAckFromEvent(e)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s event at height %d: %w", e.Type, height, err)
+		}
+		acks = append(acks, ack)
+	}
+	return acks, nil
+}
+
+// BlockTime returns the time of the block at height.
+func (c CosmosChain) BlockTime(ctx context.Context, height uint64) (time.Time, error) {
+	h := int64(height)
+	block, err := c.Client.Block(ctx, &h)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("tendermint rpc get block: %w", err)
+	}
+	return block.Block.Time, nil
+}
+
+// AcknowledgementError returns the error carried by an ICS-4 acknowledgement,
+// or an empty string for a successful one.
+func AcknowledgementError(ack []byte) (string, error) {
+	var parsed chanTypes.Acknowledgement
+	if err := chanTypes.SubModuleCdc.UnmarshalJSON(ack, &parsed); err != nil {
+		return "", fmt.Errorf("decode acknowledgement %q: %w", ack, err)
+	}
+	if parsed.Success() {
+		return "", nil
+	}
+	return parsed.GetError(), nil
+}
+
+func packetAckFromEvent(e abcitypes.Event) (ibc.PacketAcknowledgement, error) {
+	attrs := EventAttributes(e)
+	packet, err := packetFromAttributes(attrs)
+	if err != nil {
+		return ibc.PacketAcknowledgement{}, err
+	}
+	ack, err := hexOrRaw(attrs, chanTypes.AttributeKeyAckHex, chanTypes.AttributeKeyAck)
+	if err != nil {
+		return ibc.PacketAcknowledgement{}, err
+	}
+	return ibc.PacketAcknowledgement{Acknowledgement: ack, Packet: packet}, nil
+}
+
+// hexOrRaw prefers the hex encoded attribute and falls back to the deprecated raw one.
+func hexOrRaw(attrs map[string]string, hexKey, rawKey string) ([]byte, error) {
+	if v, ok := attrs[hexKey]; ok {
+		bz, err := hex.DecodeString(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", hexKey, err)
+		}
+		return bz, nil
+	}
+	return []byte(attrs[rawKey]), nil
+}
@@ -0,0 +1,62 @@
+package cosmos
+
+import (
+	"context"
+	"testing"
+
+	rpcclient "github.com/cometbft/cometbft/rpc/client"
+	coretypes "github.com/cometbft/cometbft/rpc/core/types"
+	tmtypes "github.com/cometbft/cometbft/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	clienttypes "github.com/cosmos/ibc-go/v7/modules/core/02-client/types"
+	chanTypes "github.com/cosmos/ibc-go/v7/modules/core/04-channel/types"
+	"github.com/stretchr/testify/require"
+)
+
+// fixedBlockClient serves fixed blocks by height.
+type fixedBlockClient struct {
+	rpcclient.Client
+	blocks map[int64]tmtypes.Txs
+}
+
+func (c fixedBlockClient) Block(_ context.Context, height *int64) (*coretypes.ResultBlock, error) {
+	return &coretypes.ResultBlock{Block: &tmtypes.Block{
+		Header: tmtypes.Header{Height: *height},
+		Data:   tmtypes.Data{Txs: c.blocks[*height]},
+	}}, nil
+}
+
+func encodeUnsignedTx(t *testing.T, msgs ...sdk.Msg) []byte {
+	builder := DefaultEncoding().TxConfig.NewTxBuilder()
+	require.NoError(t, builder.SetMsgs(msgs...))
+	bz, err := DefaultEncoding().TxConfig.TxEncoder()(builder.GetTx())
+	require.NoError(t, err)
+	return bz
+}
+
+func TestTimeoutsAndRecvPackets(t *testing.T) {
+	packet := chanTypes.NewPacket([]byte("data"), 4, "transfer", "channel-1", "transfer", "channel-0", clienttypes.NewHeight(0, 100), 0)
+	chain := CosmosChain{Client: fixedBlockClient{blocks: map[int64]tmtypes.Txs{
+		10: {encodeUnsignedTx(t,
+			&chanTypes.MsgTimeout{Packet: packet, Signer: "dym1x"},
+			&chanTypes.MsgTimeoutOnClose{Packet: packet, Signer: "dym1x"},
+		)},
+		11: {encodeUnsignedTx(t, &chanTypes.MsgRecvPacket{Packet: packet, Signer: "dym1x"})},
+	}}}
+
+	timeouts, err := chain.Timeouts(context.Background(), 10)
+	require.NoError(t, err)
+	require.Len(t, timeouts, 2)
+	require.Equal(t, uint64(4), timeouts[0].Packet.Sequence)
+	require.Equal(t, "channel-0", timeouts[1].Packet.DestChannel)
+	require.Equal(t, "0-100", timeouts[0].Packet.TimeoutHeight)
+
+	recvs, err := chain.RecvPackets(context.Background(), 11)
+	require.NoError(t, err)
+	require.Len(t, recvs, 1)
+	require.True(t, recvs[0].Equal(timeouts[0].Packet))
+
+	timeouts, err = chain.Timeouts(context.Background(), 11)
+	require.NoError(t, err)
+	require.Empty(t, timeouts)
+}
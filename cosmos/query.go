@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	abcitypes "github.com/cometbft/cometbft/abci/types"
 	tmtypes "github.com/cometbft/cometbft/rpc/core/types"
 	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
 	sdk "github.com/cosmos/cosmos-sdk/types"
@@ -34,3 +35,39 @@ func rangeBlockMessages(ctx context.Context, interfaceRegistry codectypes.Interf
 	}
 	return nil
 }
+
+// BlockTx is a transaction included in a block along with its execution result.
+// Tx is nil when the transaction could not be decoded with the chain's registry.
+type BlockTx struct {
+	Hash   string
+	Tx     sdk.Tx
+	Result abcitypes.ResponseDeliverTx
+}
+
+// BlockTxs returns the transactions of the block at height paired with their results.
+func (c CosmosChain) BlockTxs(ctx context.Context, height uint64) ([]BlockTx, error) {
+	h := int64(height)
+	block, err := c.Client.Block(ctx, &h)
+	if err != nil {
+		return nil, fmt.Errorf("tendermint rpc get block: %w", err)
+	}
+	results, err := c.Client.BlockResults(ctx, &h)
+	if err != nil {
+		return nil, fmt.Errorf("tendermint rpc get block results: %w", err)
+	}
+	if len(results.TxsResults) != len(block.Block.Txs) {
+		return nil, fmt.Errorf("block %d has %d txs but %d results", height, len(block.Block.Txs), len(results.TxsResults))
+	}
+
+	txs := make([]BlockTx, len(block.Block.Txs))
+	for i, txbz := range block.Block.Txs {
+		// Txs carrying messages unknown to the registry are kept with a nil Tx.
+		tx, _ := decodeTX(DefaultEncoding().InterfaceRegistry, txbz)
+		txs[i] = BlockTx{
+			Hash:   fmt.Sprintf("%X", txbz.Hash()),
+			Tx:     tx,
+			Result: *results.TxsResults[i],
+		}
+	}
+	return txs, nil
+}
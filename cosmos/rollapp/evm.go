@@ -0,0 +1,239 @@
+package rollapp
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	sdkmath "cosmossdk.io/math"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/query"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	"github.com/decentrio/e2e-testing-live/cosmos"
+	rollapptypes "github.com/decentrio/e2e-testing-live/cosmos/rollapp/types"
+	"github.com/ethereum/go-ethereum"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// erc20ModuleName is the name of the erc20 module account.
+const erc20ModuleName = "erc20"
+
+// EVMRollapp is a rollapp running the EVM, reachable over JSON-RPC.
+type EVMRollapp struct {
+	*Rollapp
+}
+
+// TokenPair maps a bank denom to its ERC20 representation in the erc20 module.
+type TokenPair struct {
+	ERC20Address  string `json:"erc20_address"`
+	Denom         string `json:"denom"`
+	Enabled       bool   `json:"enabled"`
+	ContractOwner string `json:"contract_owner"`
+}
+
+// JSONRPCURL returns the rollapp's Ethereum JSON-RPC endpoint, assuming
+// https when JsonRPCAddr has no scheme.
+func (r *EVMRollapp) JSONRPCURL() string {
+	if strings.Contains(r.JsonRPCAddr, "://") {
+		return r.JsonRPCAddr
+	}
+	return "https://" + r.JsonRPCAddr
+}
+
+// EthClient returns the shared client of the rollapp's JSON-RPC endpoint, see
+// cosmos.EthClient. It must not be closed by the caller.
+func (r *EVMRollapp) EthClient(ctx context.Context) (*ethclient.Client, error) {
+	if r.JsonRPCAddr == "" {
+		return nil, fmt.Errorf("rollapp %s has no json_rpc_addr", r.Name)
+	}
+	return cosmos.EthClient(ctx, r.JSONRPCURL())
+}
+
+// ERC20Query returns an erc20 module query client over the rollapp's shared gRPC connection.
+func (r *EVMRollapp) ERC20Query() (rollapptypes.ERC20QueryClient, error) {
+	conn, err := r.GRPCConn()
+	if err != nil {
+		return nil, err
+	}
+	return rollapptypes.NewERC20QueryClient(conn), nil
+}
+
+// TokenPairs returns the token pairs registered in the rollapp's erc20 module.
+func (r *EVMRollapp) TokenPairs(ctx context.Context) ([]TokenPair, error) {
+	client, err := r.ERC20Query()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		pairs   []TokenPair
+		nextKey []byte
+	)
+	for {
+		res, err := client.TokenPairs(ctx, &rollapptypes.QueryTokenPairsRequest{Pagination: &query.PageRequest{Key: nextKey}})
+		if err != nil {
+			return nil, fmt.Errorf("query token pairs: %w", err)
+		}
+		for _, p := range res.TokenPairs {
+			pairs = append(pairs, newTokenPair(p))
+		}
+		if res.Pagination == nil || len(res.Pagination.NextKey) == 0 {
+			return pairs, nil
+		}
+		nextKey = res.Pagination.NextKey
+	}
+}
+
+// TokenPair returns the token pair of token, either a bank denom or an ERC20 contract address.
+func (r *EVMRollapp) TokenPair(ctx context.Context, token string) (TokenPair, error) {
+	client, err := r.ERC20Query()
+	if err != nil {
+		return TokenPair{}, err
+	}
+	res, err := client.TokenPair(ctx, &rollapptypes.QueryTokenPairRequest{Token: token})
+	if err != nil {
+		return TokenPair{}, cosmos.AsNotFound("token pair of "+token, err)
+	}
+	return newTokenPair(res.TokenPair), nil
+}
+
+// ERC20Contract returns the ERC20 contract the erc20 module pairs with the
+// bank denom, e.g. the IBC denom of a hub token received by the rollapp.
+func (r *EVMRollapp) ERC20Contract(ctx context.Context, denom string) (string, error) {
+	pair, err := r.TokenPair(ctx, denom)
+	if err != nil {
+		return "", err
+	}
+	if pair.Denom != denom {
+		return "", fmt.Errorf("%s is the ERC20 contract of %s, not a denom", denom, pair.Denom)
+	}
+	return pair.ERC20Address, nil
+}
+
+// ERC20Denom returns the bank denom the erc20 module pairs with the ERC20
+// contract.
+func (r *EVMRollapp) ERC20Denom(ctx context.Context, contract string) (string, error) {
+	pair, err := r.TokenPair(ctx, contract)
+	if err != nil {
+		return "", err
+	}
+	if !strings.EqualFold(pair.ERC20Address, contract) {
+		return "", fmt.Errorf("%s is the denom of contract %s, not a contract", contract, pair.ERC20Address)
+	}
+	return pair.Denom, nil
+}
+
+// ERC20ModuleAddress returns the erc20 module account, which escrows the
+// coins converted into the ERC20 tokens of module-owned pairs, such as IBC
+// denoms.
+func (r *EVMRollapp) ERC20ModuleAddress() (string, error) {
+	return sdk.Bech32ifyAddressBytes(r.Bech32Prefix, authtypes.NewModuleAddress(erc20ModuleName))
+}
+
+// ConvertCoin converts coin from keyName's bank balance into the ERC20
+// tokens of its token pair, credited to receiver, hex or bech32, or to
+// keyName's own address if empty.
+func (r *EVMRollapp) ConvertCoin(ctx context.Context, keyName string, coin sdk.Coin, receiver string) (*cosmos.TxResponse, error) {
+	command := []string{"erc20", "convert-coin", coin.String()}
+	if receiver != "" {
+		addrs, err := cosmos.GetEvmAddressFromAnyFormatAddress(receiver)
+		if err != nil {
+			return nil, err
+		}
+		command = append(command, addrs[0].Hex())
+	}
+	return r.ExecTx(ctx, keyName, r.Fees, command...)
+}
+
+// ConvertERC20 converts amount of keyName's tokens of the ERC20 contract back
+// into the bank coins of its token pair, credited to receiver, hex or bech32,
+// or to keyName's own address if empty.
+func (r *EVMRollapp) ConvertERC20(ctx context.Context, keyName, contract string, amount sdkmath.Int, receiver string) (*cosmos.TxResponse, error) {
+	command := []string{"erc20", "convert-erc20", contract, amount.String()}
+	if receiver != "" {
+		addrs, err := cosmos.GetEvmAddressFromAnyFormatAddress(receiver)
+		if err != nil {
+			return nil, err
+		}
+		bech32, err := sdk.Bech32ifyAddressBytes(r.Bech32Prefix, addrs[0].Bytes())
+		if err != nil {
+			return nil, err
+		}
+		command = append(command, bech32)
+	}
+	return r.ExecTx(ctx, keyName, r.Fees, command...)
+}
+
+func newTokenPair(p rollapptypes.TokenPair) TokenPair {
+	return TokenPair{
+		ERC20Address:  p.Erc20Address,
+		Denom:         p.Denom,
+		Enabled:       p.Enabled,
+		ContractOwner: p.ContractOwner.String(),
+	}
+}
+
+// CallContract executes a read-only call of data against contract at height,
+// the latest block when height is nil. contract may be hex or bech32.
+func (r *EVMRollapp) CallContract(ctx context.Context, contract string, data []byte, height *big.Int) ([]byte, error) {
+	addrs, err := cosmos.GetEvmAddressFromAnyFormatAddress(contract)
+	if err != nil {
+		return nil, err
+	}
+	client, err := r.EthClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.CallContract(ctx, ethereum.CallMsg{To: &addrs[0], Data: data}, height)
+}
+
+// ERC20 binds the ERC20 contract, hex or bech32, over the rollapp's shared
+// JSON-RPC client.
+func (r *EVMRollapp) ERC20(ctx context.Context, contract string) (*cosmos.ERC20, error) {
+	if r.JsonRPCAddr == "" {
+		return nil, fmt.Errorf("rollapp %s has no json_rpc_addr", r.Name)
+	}
+	return cosmos.NewERC20(ctx, r.JSONRPCURL(), contract)
+}
+
+// ERC20Balance returns holder's balance of the ERC20 contract at height, the
+// latest block when height is nil. Both addresses may be hex or bech32.
+func (r *EVMRollapp) ERC20Balance(ctx context.Context, contract, holder string, height *big.Int) (*big.Int, error) {
+	erc20, err := r.ERC20(ctx, contract)
+	if err != nil {
+		return nil, err
+	}
+	return erc20.BalanceOf(ctx, holder, height)
+}
+
+// TransferERC20 sends amount of keyName's tokens of the ERC20 contract to to,
+// hex or bech32.
+func (r *EVMRollapp) TransferERC20(ctx context.Context, keyName, contract, to string, amount *big.Int) (*ethtypes.Receipt, error) {
+	erc20, err := r.ERC20(ctx, contract)
+	if err != nil {
+		return nil, err
+	}
+	return erc20.TransferAs(ctx, r.CosmosChain, keyName, to, amount)
+}
+
+// ApproveERC20 allows spender, hex or bech32, to transfer up to amount of
+// keyName's tokens of the ERC20 contract.
+func (r *EVMRollapp) ApproveERC20(ctx context.Context, keyName, contract, spender string, amount *big.Int) (*ethtypes.Receipt, error) {
+	erc20, err := r.ERC20(ctx, contract)
+	if err != nil {
+		return nil, err
+	}
+	return erc20.ApproveAs(ctx, r.CosmosChain, keyName, spender, amount)
+}
+
+// TransferERC20From sends amount of from's tokens of the ERC20 contract to
+// to, out of the allowance from gave keyName.
+func (r *EVMRollapp) TransferERC20From(ctx context.Context, keyName, contract, from, to string, amount *big.Int) (*ethtypes.Receipt, error) {
+	erc20, err := r.ERC20(ctx, contract)
+	if err != nil {
+		return nil, err
+	}
+	return erc20.TransferFromAs(ctx, r.CosmosChain, keyName, from, to, amount)
+}
@@ -1,4 +1,89 @@
 package rollapp
 
+import (
+	"context"
+	"fmt"
+
+	"github.com/decentrio/e2e-testing-live/cosmos"
+	"github.com/decentrio/e2e-testing-live/cosmos/hub"
+	"github.com/decentrio/rollup-e2e-testing/dymension"
+)
+
+// VM is the execution environment of a rollapp.
+type VM string
+
+const (
+	VMEVM  VM = "evm"
+	VMWasm VM = "wasm"
+)
+
+// Rollapp is a RollApp settling on a Dymension hub. It embeds the generic
+// CosmosChain; VM specific capabilities are reached through EVM and Wasm.
 type Rollapp struct {
+	cosmos.CosmosChain
+	// Name is the rollapp ID the rollapp is registered with on Hub.
+	Name string
+	VM   VM
+	Hub  *hub.Hub
+}
+
+// NewRollapp wraps chain as a rollapp registered on h as name, which
+// defaults to the chain ID.
+func NewRollapp(chain cosmos.CosmosChain, name string, vm VM, h *hub.Hub) *Rollapp {
+	if name == "" {
+		name = chain.ChainID
+	}
+	return &Rollapp{CosmosChain: chain, Name: name, VM: vm, Hub: h}
+}
+
+// EVM returns the EVM capabilities of the rollapp.
+func (r *Rollapp) EVM() (*EVMRollapp, error) {
+	if r.VM != VMEVM {
+		return nil, fmt.Errorf("rollapp %s is not an EVM rollapp (vm %q)", r.Name, r.VM)
+	}
+	return &EVMRollapp{Rollapp: r}, nil
+}
+
+// Wasm returns the CosmWasm capabilities of the rollapp.
+func (r *Rollapp) Wasm() (*WasmRollapp, error) {
+	if r.VM != VMWasm {
+		return nil, fmt.Errorf("rollapp %s is not a Wasm rollapp (vm %q)", r.Name, r.VM)
+	}
+	return &WasmRollapp{Rollapp: r}, nil
+}
+
+func (r *Rollapp) requireHub() (*hub.Hub, error) {
+	if r.Hub == nil {
+		return nil, fmt.Errorf("rollapp %s has no hub", r.Name)
+	}
+	return r.Hub, nil
+}
+
+// State returns the rollapp's latest state update on its hub, or the latest
+// finalized one when onlyFinalized is set.
+func (r *Rollapp) State(ctx context.Context, onlyFinalized bool) (*dymension.RollappState, error) {
+	h, err := r.requireHub()
+	if err != nil {
+		return nil, err
+	}
+	return h.QueryRollappState(ctx, r.Name, onlyFinalized)
+}
+
+// FinalizedHeight returns the rollapp's latest height finalized on its hub.
+func (r *Rollapp) FinalizedHeight(ctx context.Context) (uint64, error) {
+	h, err := r.requireHub()
+	if err != nil {
+		return 0, err
+	}
+	return h.FinalizedRollappStateHeight(ctx, r.Name)
+}
+
+// WaitForFinalized waits until the rollapp height is finalized on its hub.
+// See hub.Hub.WaitForRollappFinalized.
+func (r *Rollapp) WaitForFinalized(ctx context.Context, height uint64, opts hub.FinalizationOptions) (hub.FinalizationProgress, error) {
+	h, err := r.requireHub()
+	if err != nil {
+		return hub.FinalizationProgress{}, err
+	}
+	return h.WaitForRollappFinalized(ctx, r.Name, height, opts)
 }
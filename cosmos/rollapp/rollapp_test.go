@@ -0,0 +1,168 @@
+package rollapp
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	sdkmath "cosmossdk.io/math"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"github.com/cosmos/cosmos-sdk/types/query"
+	"github.com/decentrio/e2e-testing-live/cosmos"
+	"github.com/decentrio/e2e-testing-live/cosmos/hub"
+	rollapptypes "github.com/decentrio/e2e-testing-live/cosmos/rollapp/types"
+	"github.com/decentrio/e2e-testing-live/testutil/fakechain"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// serveERC20 starts a fake chain answering erc20 queries from pages of token
+// pairs, one page per TokenPairs call, and returns its gRPC address.
+func serveERC20(t *testing.T, pages [][]rollapptypes.TokenPair) string {
+	return fakechain.New(t, fakechain.Options{Services: func(server *grpc.Server) {
+		registerERC20(server, pages)
+	}}).GRPCAddr()
+}
+
+func registerERC20(server *grpc.Server, pages [][]rollapptypes.TokenPair) {
+	server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "evmos.erc20.v1.Query",
+		HandlerType: (*any)(nil),
+		Methods: []grpc.MethodDesc{{
+			MethodName: "TokenPairs",
+			Handler: func(_ any, _ context.Context, dec func(any) error, _ grpc.UnaryServerInterceptor) (any, error) {
+				var req rollapptypes.QueryTokenPairsRequest
+				if err := dec(&req); err != nil {
+					return nil, err
+				}
+				page := 0
+				if req.Pagination != nil && len(req.Pagination.Key) > 0 {
+					page = int(req.Pagination.Key[0])
+				}
+				res := &rollapptypes.QueryTokenPairsResponse{TokenPairs: pages[page], Pagination: &query.PageResponse{}}
+				if page+1 < len(pages) {
+					res.Pagination.NextKey = []byte{byte(page + 1)}
+				}
+				return res, nil
+			},
+		}, {
+			MethodName: "TokenPair",
+			Handler: func(_ any, _ context.Context, dec func(any) error, _ grpc.UnaryServerInterceptor) (any, error) {
+				var req rollapptypes.QueryTokenPairRequest
+				if err := dec(&req); err != nil {
+					return nil, err
+				}
+				for _, page := range pages {
+					for _, p := range page {
+						if p.Denom == req.Token || strings.EqualFold(p.Erc20Address, req.Token) {
+							return &rollapptypes.QueryTokenPairResponse{TokenPair: p}, nil
+						}
+					}
+				}
+				return nil, status.Errorf(codes.NotFound, "token pair for %s not found", req.Token)
+			},
+		}},
+	}, struct{}{})
+}
+
+func TestFinalizedHeightUsesRegistrationName(t *testing.T) {
+	hubExec := &cosmos.ScriptedExecutor{}
+	hubExec.Expect("q", "rollapp", "state", "rolx", "--finalized").Return(`{"stateInfo":{"BDs":{"BD":[{"height":"7"},{"height":"8"}]}}}`)
+	h := hub.NewHub(cosmos.CosmosChain{Bin: "dymd", Executor: hubExec})
+
+	r := NewRollapp(cosmos.CosmosChain{ChainID: "rolx_100004-1"}, "rolx", VMEVM, h)
+	height, err := r.FinalizedHeight(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(8), height)
+
+	require.Equal(t, "rolx_100004-1", NewRollapp(cosmos.CosmosChain{ChainID: "rolx_100004-1"}, "", VMEVM, nil).Name)
+	_, err = NewRollapp(cosmos.CosmosChain{}, "rolx", VMEVM, nil).FinalizedHeight(context.Background())
+	require.ErrorContains(t, err, "no hub")
+}
+
+func TestVMCapabilities(t *testing.T) {
+	addr := serveERC20(t, [][]rollapptypes.TokenPair{
+		{{Erc20Address: "0xAbC", Denom: "ibc/27394FB0", Enabled: true, ContractOwner: rollapptypes.OwnerModule}},
+		{{Erc20Address: "0xDeF", Denom: "arolx", ContractOwner: rollapptypes.OwnerExternal}},
+	})
+	evmRollapp := NewRollapp(cosmos.CosmosChain{Bin: "rollapp-evm", GrpcAddr: addr}, "rolx", VMEVM, nil)
+
+	_, err := evmRollapp.Wasm()
+	require.Error(t, err)
+	evm, err := evmRollapp.EVM()
+	require.NoError(t, err)
+	pairs, err := evm.TokenPairs(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []TokenPair{
+		{ERC20Address: "0xAbC", Denom: "ibc/27394FB0", Enabled: true, ContractOwner: "OWNER_MODULE"},
+		{ERC20Address: "0xDeF", Denom: "arolx", ContractOwner: "OWNER_EXTERNAL"},
+	}, pairs)
+	pair, err := evm.TokenPair(context.Background(), "arolx")
+	require.NoError(t, err)
+	require.Equal(t, "0xDeF", pair.ERC20Address)
+	_, err = evm.TokenPair(context.Background(), "aroly")
+	require.ErrorContains(t, err, "not found")
+
+	exec := &cosmos.ScriptedExecutor{}
+	exec.Expect("q", "wasm", "contract-state", "smart", "roly1cw20", `{"balance":{"address":"roly1alice"}}`).Return(`{"data":{"balance":"1500"}}`)
+	wasmRollapp := NewRollapp(cosmos.CosmosChain{Bin: "rollapp-wasm", Executor: exec}, "roly", VMWasm, nil)
+
+	_, err = wasmRollapp.EVM()
+	require.Error(t, err)
+	wasm, err := wasmRollapp.Wasm()
+	require.NoError(t, err)
+	balance, err := wasm.CW20Balance(context.Background(), "roly1cw20", "roly1alice")
+	require.NoError(t, err)
+	require.Equal(t, sdkmath.NewInt(1500), balance)
+	require.Empty(t, exec.Pending())
+}
+
+func TestERC20Conversions(t *testing.T) {
+	const contract = "0x00000000000000000000000000000000000000Aa"
+	addr := serveERC20(t, [][]rollapptypes.TokenPair{
+		{{Erc20Address: contract, Denom: "ibc/27394FB0", Enabled: true, ContractOwner: rollapptypes.OwnerModule}},
+	})
+	t.Cleanup(cosmos.CloseGRPCConns)
+	exec := &cosmos.ScriptedExecutor{}
+	chain := cosmos.CosmosChain{
+		Bin: "rollapp-evm", ChainID: "rolx_1-1", RPCAddr: "rpc:443", GrpcAddr: addr, Bech32Prefix: "rolx",
+		Fees: "1arolx", Executor: exec, BroadcastMode: cosmos.BroadcastSync,
+	}
+	evm, err := NewRollapp(chain, "rolx", VMEVM, nil).EVM()
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	got, err := evm.ERC20Contract(ctx, "ibc/27394FB0")
+	require.NoError(t, err)
+	require.Equal(t, contract, got)
+	denom, err := evm.ERC20Denom(ctx, strings.ToLower(contract))
+	require.NoError(t, err)
+	require.Equal(t, "ibc/27394FB0", denom)
+	_, err = evm.ERC20Contract(ctx, contract)
+	require.ErrorContains(t, err, "is the ERC20 contract of ibc/27394FB0, not a denom")
+	_, err = evm.ERC20Denom(ctx, "ibc/27394FB0")
+	require.ErrorContains(t, err, "not a contract")
+	_, err = evm.ERC20Contract(ctx, "aroly")
+	require.ErrorIs(t, err, sdkerrors.ErrNotFound)
+
+	module, err := evm.ERC20ModuleAddress()
+	require.NoError(t, err)
+	require.Equal(t, "rolx1glht96kr2rseywuvhhay894qw7ekuc4q4d4qs2", module)
+
+	receiver := sdk.MustBech32ifyAddressBytes("rolx", common.HexToAddress(contract).Bytes())
+	exec.Expect("tx", "erc20", "convert-coin", "5ibc/27394FB0", common.HexToAddress(contract).Hex(), "--fees", "1arolx").Return(`{"txhash":"AB","code":0}`)
+	exec.Expect("tx", "erc20", "convert-erc20", contract, "5", receiver, "--fees", "1arolx").Return(`{"txhash":"CD","code":0}`)
+	exec.Expect("tx", "erc20", "convert-erc20", contract, "7", "--fees").Return(`{"txhash":"EF","code":0}`)
+	res, err := evm.ConvertCoin(ctx, "alice", sdk.NewInt64Coin("ibc/27394FB0", 5), receiver)
+	require.NoError(t, err)
+	require.Equal(t, "AB", res.TxHash)
+	_, err = evm.ConvertERC20(ctx, "alice", contract, sdkmath.NewInt(5), contract)
+	require.NoError(t, err)
+	_, err = evm.ConvertERC20(ctx, "alice", contract, sdkmath.NewInt(7), "")
+	require.NoError(t, err)
+	require.Empty(t, exec.Pending())
+}
@@ -0,0 +1,268 @@
+// Package types holds minimal, hand-written protobuf definitions of the
+// rollapp module queries used by the tests. Only the fields the tests need
+// are declared; importing the rollapp modules themselves would drag their
+// whole dependency tree into this repository.
+package types
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cosmos/cosmos-sdk/types/query"
+	"github.com/decentrio/e2e-testing-live/internal/protoenc"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const (
+	// QueryTokenPairsMethod is the full gRPC method name of the erc20 TokenPairs query.
+	QueryTokenPairsMethod = "/evmos.erc20.v1.Query/TokenPairs"
+	// QueryTokenPairMethod is the full gRPC method name of the erc20 TokenPair query.
+	QueryTokenPairMethod = "/evmos.erc20.v1.Query/TokenPair"
+)
+
+// ERC20QueryClient is the subset of the erc20 module's Query service used by the tests.
+type ERC20QueryClient interface {
+	TokenPairs(ctx context.Context, in *QueryTokenPairsRequest, opts ...grpc.CallOption) (*QueryTokenPairsResponse, error)
+	TokenPair(ctx context.Context, in *QueryTokenPairRequest, opts ...grpc.CallOption) (*QueryTokenPairResponse, error)
+}
+
+type erc20QueryClient struct {
+	cc grpc.ClientConnInterface
+}
+
+// NewERC20QueryClient returns an erc20 module query client over cc.
+func NewERC20QueryClient(cc grpc.ClientConnInterface) ERC20QueryClient {
+	return &erc20QueryClient{cc}
+}
+
+func (c *erc20QueryClient) TokenPairs(ctx context.Context, in *QueryTokenPairsRequest, opts ...grpc.CallOption) (*QueryTokenPairsResponse, error) {
+	out := new(QueryTokenPairsResponse)
+	if err := c.cc.Invoke(ctx, QueryTokenPairsMethod, in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *erc20QueryClient) TokenPair(ctx context.Context, in *QueryTokenPairRequest, opts ...grpc.CallOption) (*QueryTokenPairResponse, error) {
+	out := new(QueryTokenPairResponse)
+	if err := c.cc.Invoke(ctx, QueryTokenPairMethod, in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Owner mirrors evmos.erc20.v1.Owner, the owner of a token pair's contract.
+type Owner int32
+
+const (
+	OwnerUnspecified Owner = 0
+	OwnerModule      Owner = 1
+	OwnerExternal    Owner = 2
+)
+
+var ownerNames = map[Owner]string{
+	OwnerUnspecified: "OWNER_UNSPECIFIED",
+	OwnerModule:      "OWNER_MODULE",
+	OwnerExternal:    "OWNER_EXTERNAL",
+}
+
+func (o Owner) String() string {
+	if name, ok := ownerNames[o]; ok {
+		return name
+	}
+	return fmt.Sprintf("Owner(%d)", int32(o))
+}
+
+// TokenPair mirrors evmos.erc20.v1.TokenPair.
+type TokenPair struct {
+	Erc20Address  string `protobuf:"bytes,1,opt,name=erc20_address,proto3" json:"erc20_address,omitempty"`
+	Denom         string `protobuf:"bytes,2,opt,name=denom,proto3" json:"denom,omitempty"`
+	Enabled       bool   `protobuf:"varint,3,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	ContractOwner Owner  `protobuf:"varint,4,opt,name=contract_owner,proto3,enum=evmos.erc20.v1.Owner" json:"contract_owner,omitempty"`
+}
+
+func (m *TokenPair) Reset()         { *m = TokenPair{} }
+func (m *TokenPair) String() string { return fmt.Sprintf("%+v", *m) }
+func (*TokenPair) ProtoMessage()    {}
+
+func (*TokenPair) XXX_MessageName() string {
+	return "evmos.erc20.v1.TokenPair"
+}
+
+func (m *TokenPair) Marshal() ([]byte, error) {
+	var b []byte
+	b = protoenc.AppendString(b, 1, m.Erc20Address)
+	b = protoenc.AppendString(b, 2, m.Denom)
+	b = protoenc.AppendBool(b, 3, m.Enabled)
+	b = protoenc.AppendUint64(b, 4, uint64(m.ContractOwner))
+	return b, nil
+}
+
+func (m *TokenPair) Unmarshal(bz []byte) error {
+	m.Reset()
+	return protoenc.RangeFields(bz, func(num protowire.Number, typ protowire.Type, v []byte) error {
+		var (
+			x   uint64
+			err error
+		)
+		switch num {
+		case 1:
+			m.Erc20Address = string(v)
+		case 2:
+			m.Denom = string(v)
+		case 3:
+			x, err = protoenc.Varint(v)
+			m.Enabled = x != 0
+		case 4:
+			x, err = protoenc.Varint(v)
+			m.ContractOwner = Owner(x)
+		}
+		return err
+	})
+}
+
+// QueryTokenPairsRequest mirrors evmos.erc20.v1.QueryTokenPairsRequest.
+type QueryTokenPairsRequest struct {
+	Pagination *query.PageRequest `protobuf:"bytes,1,opt,name=pagination,proto3" json:"pagination,omitempty"`
+}
+
+func (m *QueryTokenPairsRequest) Reset()         { *m = QueryTokenPairsRequest{} }
+func (m *QueryTokenPairsRequest) String() string { return fmt.Sprintf("%+v", *m) }
+func (*QueryTokenPairsRequest) ProtoMessage()    {}
+
+func (*QueryTokenPairsRequest) XXX_MessageName() string {
+	return "evmos.erc20.v1.QueryTokenPairsRequest"
+}
+
+func (m *QueryTokenPairsRequest) Marshal() ([]byte, error) {
+	if m.Pagination == nil {
+		return nil, nil
+	}
+	bz, err := m.Pagination.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return protoenc.AppendBytes(nil, 1, bz), nil
+}
+
+func (m *QueryTokenPairsRequest) Unmarshal(bz []byte) error {
+	m.Reset()
+	return protoenc.RangeFields(bz, func(num protowire.Number, typ protowire.Type, v []byte) error {
+		if num != 1 {
+			return nil
+		}
+		m.Pagination = &query.PageRequest{}
+		return m.Pagination.Unmarshal(v)
+	})
+}
+
+// QueryTokenPairsResponse mirrors evmos.erc20.v1.QueryTokenPairsResponse.
+type QueryTokenPairsResponse struct {
+	TokenPairs []TokenPair         `protobuf:"bytes,1,rep,name=token_pairs,proto3" json:"token_pairs"`
+	Pagination *query.PageResponse `protobuf:"bytes,2,opt,name=pagination,proto3" json:"pagination,omitempty"`
+}
+
+func (m *QueryTokenPairsResponse) Reset()         { *m = QueryTokenPairsResponse{} }
+func (m *QueryTokenPairsResponse) String() string { return fmt.Sprintf("%+v", *m) }
+func (*QueryTokenPairsResponse) ProtoMessage()    {}
+
+func (*QueryTokenPairsResponse) XXX_MessageName() string {
+	return "evmos.erc20.v1.QueryTokenPairsResponse"
+}
+
+func (m *QueryTokenPairsResponse) Marshal() ([]byte, error) {
+	var b []byte
+	for i := range m.TokenPairs {
+		bz, err := m.TokenPairs[i].Marshal()
+		if err != nil {
+			return nil, err
+		}
+		b = protoenc.AppendBytes(b, 1, bz)
+	}
+	if m.Pagination != nil {
+		bz, err := m.Pagination.Marshal()
+		if err != nil {
+			return nil, err
+		}
+		b = protoenc.AppendBytes(b, 2, bz)
+	}
+	return b, nil
+}
+
+func (m *QueryTokenPairsResponse) Unmarshal(bz []byte) error {
+	m.Reset()
+	return protoenc.RangeFields(bz, func(num protowire.Number, typ protowire.Type, v []byte) error {
+		switch num {
+		case 1:
+			var pair TokenPair
+			if err := pair.Unmarshal(v); err != nil {
+				return err
+			}
+			m.TokenPairs = append(m.TokenPairs, pair)
+		case 2:
+			m.Pagination = &query.PageResponse{}
+			return m.Pagination.Unmarshal(v)
+		}
+		return nil
+	})
+}
+
+// QueryTokenPairRequest mirrors evmos.erc20.v1.QueryTokenPairRequest. Token
+// is either a bank denom or an ERC20 contract address.
+type QueryTokenPairRequest struct {
+	Token string `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+}
+
+func (m *QueryTokenPairRequest) Reset()         { *m = QueryTokenPairRequest{} }
+func (m *QueryTokenPairRequest) String() string { return fmt.Sprintf("%+v", *m) }
+func (*QueryTokenPairRequest) ProtoMessage()    {}
+
+func (*QueryTokenPairRequest) XXX_MessageName() string {
+	return "evmos.erc20.v1.QueryTokenPairRequest"
+}
+
+func (m *QueryTokenPairRequest) Marshal() ([]byte, error) {
+	return protoenc.AppendString(nil, 1, m.Token), nil
+}
+
+func (m *QueryTokenPairRequest) Unmarshal(bz []byte) error {
+	m.Reset()
+	return protoenc.RangeFields(bz, func(num protowire.Number, typ protowire.Type, v []byte) error {
+		if num == 1 {
+			m.Token = string(v)
+		}
+		return nil
+	})
+}
+
+// QueryTokenPairResponse mirrors evmos.erc20.v1.QueryTokenPairResponse.
+type QueryTokenPairResponse struct {
+	TokenPair TokenPair `protobuf:"bytes,1,opt,name=token_pair,proto3" json:"token_pair"`
+}
+
+func (m *QueryTokenPairResponse) Reset()         { *m = QueryTokenPairResponse{} }
+func (m *QueryTokenPairResponse) String() string { return fmt.Sprintf("%+v", *m) }
+func (*QueryTokenPairResponse) ProtoMessage()    {}
+
+func (*QueryTokenPairResponse) XXX_MessageName() string {
+	return "evmos.erc20.v1.QueryTokenPairResponse"
+}
+
+func (m *QueryTokenPairResponse) Marshal() ([]byte, error) {
+	bz, err := m.TokenPair.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return protoenc.AppendBytes(nil, 1, bz), nil
+}
+
+func (m *QueryTokenPairResponse) Unmarshal(bz []byte) error {
+	m.Reset()
+	return protoenc.RangeFields(bz, func(num protowire.Number, typ protowire.Type, v []byte) error {
+		if num != 1 {
+			return nil
+		}
+		return m.TokenPair.Unmarshal(v)
+	})
+}
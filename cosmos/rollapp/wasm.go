@@ -0,0 +1,64 @@
+package rollapp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	sdkmath "cosmossdk.io/math"
+)
+
+// WasmRollapp is a rollapp running CosmWasm contracts.
+type WasmRollapp struct {
+	*Rollapp
+}
+
+// ContractInfo describes an instantiated contract.
+type ContractInfo struct {
+	CodeID  string `json:"code_id"`
+	Creator string `json:"creator"`
+	Admin   string `json:"admin"`
+	Label   string `json:"label"`
+}
+
+// ContractInfo returns the metadata of contract.
+func (r *WasmRollapp) ContractInfo(ctx context.Context, contract string) (ContractInfo, error) {
+	var info struct {
+		ContractInfo ContractInfo `json:"contract_info"`
+	}
+	if err := r.ExecQuery(ctx, &info, "wasm", "contract", contract); err != nil {
+		return ContractInfo{}, err
+	}
+	return info.ContractInfo, nil
+}
+
+// QueryContract runs the smart query msg against contract and decodes the
+// contract's answer into out.
+func (r *WasmRollapp) QueryContract(ctx context.Context, contract string, msg, out any) error {
+	bz, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("encode query: %w", err)
+	}
+
+	var res struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := r.ExecQuery(ctx, &res, "wasm", "contract-state", "smart", contract, string(bz)); err != nil {
+		return err
+	}
+	return json.Unmarshal(res.Data, out)
+}
+
+// CW20Balance returns holder's balance of the CW20 contract.
+func (r *WasmRollapp) CW20Balance(ctx context.Context, contract, holder string) (sdkmath.Int, error) {
+	var res struct {
+		Balance sdkmath.Int `json:"balance"`
+	}
+	err := r.QueryContract(ctx, contract, map[string]any{
+		"balance": map[string]string{"address": holder},
+	}, &res)
+	if err != nil {
+		return sdkmath.Int{}, err
+	}
+	return res.Balance, nil
+}
@@ -0,0 +1,247 @@
+package cosmos
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sdkmath "cosmossdk.io/math"
+	"github.com/cosmos/cosmos-sdk/client/tx"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/tx/signing"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	bankTypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	transfertypes "github.com/cosmos/ibc-go/v7/modules/apps/transfer/types"
+	clienttypes "github.com/cosmos/ibc-go/v7/modules/core/02-client/types"
+	"github.com/decentrio/rollup-e2e-testing/ibc"
+	"google.golang.org/grpc"
+)
+
+const (
+	// DefaultGasAdjustment scales simulated gas, matching the CLI flows.
+	DefaultGasAdjustment = 1.5
+	// DefaultPacketTimeout is the relative timeout applied to transfers that
+	// do not set one, as the transfer CLI does.
+	DefaultPacketTimeout = 10 * time.Minute
+)
+
+// TxOptions tunes how BroadcastTx builds a transaction.
+type TxOptions struct {
+	// Fees to pay, e.g. "6000adym". Defaults to the chain's Fees and, if that
+	// is empty too, to its GasPrices multiplied by the gas limit.
+	Fees string
+	// Gas limit. Zero simulates the transaction and scales the result by GasAdjustment.
+	Gas           uint64
+	GasAdjustment float64
+	Memo          string
+	TimeoutHeight uint64
+	// BroadcastMode overrides the chain's BroadcastMode for this tx.
+	BroadcastMode BroadcastMode
+}
+
+// BroadcastTx signs msgs with keyName from the chain's Keyring and broadcasts
+// them over CometBFT RPC, without shelling out to the chain binary. Account
+// number and sequence are queried over gRPC. Unless the broadcast mode says
+// otherwise, it waits for the tx to be committed; see WaitForTx.
+func (c CosmosChain) BroadcastTx(ctx context.Context, keyName string, opts TxOptions, msgs ...sdk.Msg) (*TxResponse, error) {
+	if c.Keyring == nil {
+		return nil, fmt.Errorf("chain %s has no keyring", c.ChainID)
+	}
+	if c.Client == nil {
+		return nil, fmt.Errorf("chain %s has no rpc client", c.ChainID)
+	}
+
+	conn, err := c.GRPCConn()
+	if err != nil {
+		return nil, err
+	}
+
+	txf, err := c.txFactory(ctx, conn, keyName, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if txf.Gas() == 0 {
+		_, gas, err := tx.CalculateGas(conn, txf.WithSimulateAndExecute(true), msgs...)
+		if err != nil {
+			return nil, fmt.Errorf("simulate tx: %w", err)
+		}
+		txf = txf.WithGas(gas)
+	}
+
+	builder, err := txf.BuildUnsignedTx(msgs...)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Sign(txf, keyName, builder, true); err != nil {
+		return nil, fmt.Errorf("sign tx: %w", err)
+	}
+	txBytes, err := DefaultEncoding().TxConfig.TxEncoder()(builder.GetTx())
+	if err != nil {
+		return nil, err
+	}
+
+	mode := c.broadcastMode(opts.BroadcastMode)
+	broadcast := c.Client.BroadcastTxSync
+	if mode == BroadcastAsync {
+		broadcast = c.Client.BroadcastTxAsync
+	}
+	res, err := broadcast(ctx, txBytes)
+	if err != nil {
+		return nil, fmt.Errorf("broadcast tx: %w", err)
+	}
+	return c.awaitTx(ctx, mode, &TxResponse{
+		TxHash:    res.Hash.String(),
+		Codespace: res.Codespace,
+		Code:      res.Code,
+		Data:      res.Data.String(),
+		RawLog:    res.Log,
+		GasWanted: fmt.Sprint(txf.Gas()),
+	})
+}
+
+func (c CosmosChain) txFactory(ctx context.Context, conn *grpc.ClientConn, keyName string, opts TxOptions) (tx.Factory, error) {
+	record, err := c.Keyring.Key(keyName)
+	if err != nil {
+		return tx.Factory{}, err
+	}
+	addr, err := record.GetAddress()
+	if err != nil {
+		return tx.Factory{}, err
+	}
+	bech32Addr, err := c.Bech32(ctx, conn, addr)
+	if err != nil {
+		return tx.Factory{}, err
+	}
+	accNum, seq, err := c.accountNumberSequence(ctx, conn, bech32Addr)
+	if err != nil {
+		return tx.Factory{}, err
+	}
+
+	gasAdjustment := opts.GasAdjustment
+	if gasAdjustment == 0 {
+		gasAdjustment = DefaultGasAdjustment
+	}
+	txf := tx.Factory{}.
+		WithTxConfig(DefaultEncoding().TxConfig).
+		WithKeybase(c.Keyring).
+		WithFromName(keyName).
+		WithChainID(c.ChainID).
+		WithAccountNumber(accNum).
+		WithSequence(seq).
+		WithGas(opts.Gas).
+		WithGasAdjustment(gasAdjustment).
+		WithMemo(opts.Memo).
+		WithTimeoutHeight(opts.TimeoutHeight).
+		WithSignMode(signing.SignMode_SIGN_MODE_DIRECT)
+
+	switch {
+	case opts.Fees != "":
+		txf = txf.WithFees(opts.Fees)
+	case c.Fees != "":
+		txf = txf.WithFees(c.Fees)
+	case c.GasPrices != "":
+		txf = txf.WithGasPrices(c.GasPrices)
+	}
+	return txf, nil
+}
+
+// Bech32 encodes addr with the chain's Bech32Prefix, asking the chain for its
+// prefix when none is configured.
+func (c CosmosChain) Bech32(ctx context.Context, conn *grpc.ClientConn, addr sdk.AccAddress) (string, error) {
+	prefix := c.Bech32Prefix
+	if prefix == "" {
+		res, err := authtypes.NewQueryClient(conn).Bech32Prefix(ctx, &authtypes.Bech32PrefixRequest{})
+		if err != nil {
+			return "", fmt.Errorf("query bech32 prefix: %w", err)
+		}
+		prefix = res.Bech32Prefix
+	}
+	return sdk.Bech32ifyAddressBytes(prefix, addr)
+}
+
+func (c CosmosChain) accountNumberSequence(ctx context.Context, conn *grpc.ClientConn, address string) (uint64, uint64, error) {
+	res, err := authtypes.NewQueryClient(conn).Account(ctx, &authtypes.QueryAccountRequest{Address: address})
+	if err != nil {
+		return 0, 0, AsNotFound("account "+address, err)
+	}
+	var acc authtypes.AccountI
+	if err := DefaultEncoding().InterfaceRegistry.UnpackAny(res.Account, &acc); err != nil {
+		return 0, 0, fmt.Errorf("unpack account %s: %w", address, err)
+	}
+	return acc.GetAccountNumber(), acc.GetSequence(), nil
+}
+
+// KeyAddress returns the bech32 address of keyName from the chain's keyring.
+func (c CosmosChain) KeyAddress(ctx context.Context, keyName string) (string, error) {
+	if c.Keyring == nil {
+		return "", fmt.Errorf("chain %s has no keyring", c.ChainID)
+	}
+	record, err := c.Keyring.Key(keyName)
+	if err != nil {
+		return "", err
+	}
+	addr, err := record.GetAddress()
+	if err != nil {
+		return "", err
+	}
+	if c.Bech32Prefix != "" {
+		return sdk.Bech32ifyAddressBytes(c.Bech32Prefix, addr)
+	}
+	conn, err := c.GRPCConn()
+	if err != nil {
+		return "", err
+	}
+	return c.Bech32(ctx, conn, addr)
+}
+
+// NewMsgTransfer builds an ICS-20 transfer of toWallet's amount over channelID.
+// Without an explicit timeout the packet times out DefaultPacketTimeout from now.
+func NewMsgTransfer(channelID, sender string, toWallet ibc.WalletData, options ibc.TransferOptions) *transfertypes.MsgTransfer {
+	var (
+		timeoutHeight clienttypes.Height
+		timeoutNanos  uint64
+	)
+	switch {
+	case options.Timeout != nil && options.Timeout.NanoSeconds > 0:
+		timeoutNanos = options.Timeout.NanoSeconds
+	case options.Timeout != nil && options.Timeout.Height > 0:
+		timeoutHeight = clienttypes.NewHeight(0, uint64(options.Timeout.Height))
+	default:
+		timeoutNanos = uint64(time.Now().Add(DefaultPacketTimeout).UnixNano())
+	}
+	return transfertypes.NewMsgTransfer(
+		"transfer", channelID,
+		sdk.NewCoin(toWallet.Denom, toWallet.Amount),
+		sender, toWallet.Address,
+		timeoutHeight, timeoutNanos,
+		options.Memo,
+	)
+}
+
+// NewMsgSend builds a bank send of amount denom from one address to another.
+func NewMsgSend(from, to, denom string, amount sdkmath.Int) *bankTypes.MsgSend {
+	return &bankTypes.MsgSend{
+		FromAddress: from,
+		ToAddress:   to,
+		Amount:      sdk.NewCoins(sdk.NewCoin(denom, amount)),
+	}
+}
+
+// IBCTransfer is the native counterpart of SendIBCTransfer.
+func (c CosmosChain) IBCTransfer(ctx context.Context, keyName, channelID string, toWallet ibc.WalletData, options ibc.TransferOptions, txOpts TxOptions) (*TxResponse, error) {
+	sender, err := c.KeyAddress(ctx, keyName)
+	if err != nil {
+		return nil, err
+	}
+	return c.BroadcastTx(ctx, keyName, txOpts, NewMsgTransfer(channelID, sender, toWallet, options))
+}
+
+// BankSend sends toWallet's amount from keyName to toWallet's address.
+func (c CosmosChain) BankSend(ctx context.Context, keyName string, toWallet ibc.WalletData, txOpts TxOptions) (*TxResponse, error) {
+	from, err := c.KeyAddress(ctx, keyName)
+	if err != nil {
+		return nil, err
+	}
+	return c.BroadcastTx(ctx, keyName, txOpts, NewMsgSend(from, toWallet.Address, toWallet.Denom, toWallet.Amount))
+}
@@ -0,0 +1,72 @@
+package cosmos
+
+import (
+	"testing"
+
+	sdkmath "cosmossdk.io/math"
+	"github.com/cosmos/cosmos-sdk/client/tx"
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	"github.com/cosmos/cosmos-sdk/crypto/keyring"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/tx/signing"
+	hubtypes "github.com/decentrio/e2e-testing-live/cosmos/hub/types"
+	"github.com/decentrio/rollup-e2e-testing/ibc"
+	ethhd "github.com/evmos/ethermint/crypto/hd"
+	ethermint "github.com/evmos/ethermint/types"
+	"github.com/stretchr/testify/require"
+)
+
+// signOffline signs msgs like BroadcastTx does, minus the network round trips.
+func signOffline(t *testing.T, kr keyring.Keyring, keyName string, msgs ...sdk.Msg) sdk.Tx {
+	txf := tx.Factory{}.
+		WithTxConfig(DefaultEncoding().TxConfig).
+		WithKeybase(kr).
+		WithChainID("rolx_100004-1").
+		WithGas(200_000).
+		WithFees("10arolx").
+		WithSignMode(signing.SignMode_SIGN_MODE_DIRECT)
+	builder, err := txf.BuildUnsignedTx(msgs...)
+	require.NoError(t, err)
+	require.NoError(t, tx.Sign(txf, keyName, builder, true))
+
+	bz, err := DefaultEncoding().TxConfig.TxEncoder()(builder.GetTx())
+	require.NoError(t, err)
+	decoded, err := decodeTX(DefaultEncoding().InterfaceRegistry, bz)
+	require.NoError(t, err)
+	return decoded
+}
+
+func TestSignWithEthSecp256k1(t *testing.T) {
+	kr := NewInMemoryKeyring()
+	record, _, err := kr.NewMnemonic("evm", keyring.English, ethermint.BIP44HDPath, keyring.DefaultBIP39Passphrase, ethhd.EthSecp256k1)
+	require.NoError(t, err)
+	addr, err := record.GetAddress()
+	require.NoError(t, err)
+	from, err := sdk.Bech32ifyAddressBytes("rolx", addr)
+	require.NoError(t, err)
+
+	msg := NewMsgTransfer("channel-0", from, ibc.WalletData{Address: "dym1xyz", Denom: "arolx", Amount: sdkmath.NewInt(5)}, ibc.TransferOptions{Memo: "m"})
+	decoded := signOffline(t, kr, "evm", msg)
+	require.Equal(t, []sdk.Msg{msg}, decoded.GetMsgs())
+	require.NotZero(t, msg.TimeoutTimestamp)
+}
+
+func TestMsgFulfillOrderRoundTrip(t *testing.T) {
+	kr := NewInMemoryKeyring()
+	record, _, err := kr.NewMnemonic("hub", keyring.English, sdk.FullFundraiserPath, keyring.DefaultBIP39Passphrase, hd.Secp256k1)
+	require.NoError(t, err)
+	addr, err := record.GetAddress()
+	require.NoError(t, err)
+	fulfiller, err := sdk.Bech32ifyAddressBytes("dym", addr)
+	require.NoError(t, err)
+
+	msg := &hubtypes.MsgFulfillOrder{FulfillerAddress: fulfiller, OrderId: "order-1", ExpectedFee: "100"}
+	require.NoError(t, msg.ValidateBasic())
+	require.Equal(t, []sdk.AccAddress{addr}, msg.GetSigners())
+
+	decoded := signOffline(t, kr, "hub", msg)
+	require.Len(t, decoded.GetMsgs(), 1)
+	got, ok := decoded.GetMsgs()[0].(*hubtypes.MsgFulfillOrder)
+	require.True(t, ok)
+	require.Equal(t, msg, got)
+}
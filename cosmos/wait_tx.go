@@ -0,0 +1,162 @@
+package cosmos
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	coretypes "github.com/cometbft/cometbft/rpc/core/types"
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"go.uber.org/zap"
+)
+
+// BroadcastMode selects when a tx-sending function returns.
+type BroadcastMode string
+
+const (
+	// BroadcastCommit waits until the tx is included in a block. It is the default.
+	BroadcastCommit BroadcastMode = "commit"
+	// BroadcastSync returns once the tx passed CheckTx.
+	BroadcastSync BroadcastMode = "sync"
+	// BroadcastAsync returns as soon as the node accepted the tx bytes.
+	BroadcastAsync BroadcastMode = "async"
+)
+
+var (
+	// DefaultTxTimeout bounds how long tx-sending functions wait for inclusion.
+	DefaultTxTimeout = time.Minute
+	// TxPollInterval is the delay between two lookups of a pending tx.
+	TxPollInterval = time.Second
+)
+
+// cliMode returns the --broadcast-mode flag value the chain binary understands.
+// The CLI cannot wait for inclusion itself, so commit is emulated on top of sync.
+func (m BroadcastMode) cliMode() string {
+	if m == BroadcastAsync {
+		return string(BroadcastAsync)
+	}
+	return string(BroadcastSync)
+}
+
+func (c CosmosChain) broadcastMode(override BroadcastMode) BroadcastMode {
+	switch {
+	case override != "":
+		return override
+	case c.BroadcastMode != "":
+		return c.BroadcastMode
+	default:
+		return BroadcastCommit
+	}
+}
+
+// CheckTxResponse returns a *TxFailedError if res has a non-zero code.
+func CheckTxResponse(res *TxResponse) error {
+	if res == nil || res.Code == 0 {
+		return nil
+	}
+	return &TxFailedError{
+		TxHash:    res.TxHash,
+		Height:    res.Height,
+		Codespace: res.Codespace,
+		Code:      res.Code,
+		RawLog:    res.RawLog,
+	}
+}
+
+// awaitTx finishes a broadcast according to mode. The response is always
+// returned alongside a *TxFailedError so callers can inspect it.
+func (c CosmosChain) awaitTx(ctx context.Context, mode BroadcastMode, broadcast *TxResponse) (*TxResponse, error) {
+	if mode == BroadcastAsync {
+		return broadcast, nil
+	}
+	if err := CheckTxResponse(broadcast); err != nil {
+		return broadcast, err
+	}
+	if mode == BroadcastSync {
+		return broadcast, nil
+	}
+	return WaitForTx(ctx, c, broadcast.TxHash, DefaultTxTimeout)
+}
+
+// WaitForTx polls the chain's CometBFT Tx endpoint until the tx with hash is
+// committed or timeout elapses (DefaultTxTimeout when zero). A committed tx
+// with a non-zero code is returned together with a *TxFailedError.
+func WaitForTx(ctx context.Context, chain CosmosChain, hash string, timeout time.Duration) (*TxResponse, error) {
+	if chain.Client == nil {
+		return nil, fmt.Errorf("chain %s has no rpc client", chain.ChainID)
+	}
+	hashBz, err := hex.DecodeString(hash)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tx hash %q: %w", hash, err)
+	}
+	if timeout <= 0 {
+		timeout = DefaultTxTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(TxPollInterval)
+	defer ticker.Stop()
+	for {
+		res, err := chain.Client.Tx(ctx, hashBz, false)
+		if err == nil {
+			txResp, err := chain.txResponseFromResult(ctx, res)
+			if err != nil {
+				return nil, err
+			}
+			return txResp, CheckTxResponse(txResp)
+		}
+		if !isTxNotFound(err) && ctx.Err() == nil {
+			chain.Log().Debug("query tx", zap.String("hash", hash), zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil, fmt.Errorf("tx %s not committed on %s within %s: %w", hash, chain.ChainID, timeout, err)
+			}
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func isTxNotFound(err error) bool {
+	return strings.Contains(err.Error(), "not found")
+}
+
+// txResponseFromResult converts a CometBFT tx result into a TxResponse,
+// looking up the block time of its height.
+func (c CosmosChain) txResponseFromResult(ctx context.Context, res *coretypes.ResultTx) (*TxResponse, error) {
+	txResp := &TxResponse{
+		Height:    fmt.Sprint(res.Height),
+		TxHash:    res.Hash.String(),
+		Codespace: res.TxResult.Codespace,
+		Code:      res.TxResult.Code,
+		Data:      strings.ToUpper(hex.EncodeToString(res.TxResult.Data)),
+		RawLog:    res.TxResult.Log,
+		Info:      res.TxResult.Info,
+		GasWanted: fmt.Sprint(res.TxResult.GasWanted),
+		GasUsed:   fmt.Sprint(res.TxResult.GasUsed),
+		Events:    res.TxResult.Events,
+	}
+	if logs, err := sdk.ParseABCILogs(res.TxResult.Log); err == nil {
+		txResp.Logs = logs
+	}
+	if sdkTx, err := decodeTX(DefaultEncoding().InterfaceRegistry, res.Tx); err == nil {
+		if asAny, ok := sdkTx.(interface{ AsAny() *codectypes.Any }); ok {
+			txResp.Tx = asAny.AsAny()
+		}
+	}
+
+	block, err := c.Client.Block(ctx, &res.Height)
+	if err != nil {
+		return nil, fmt.Errorf("get block %d of tx %s: %w", res.Height, txResp.TxHash, err)
+	}
+	txResp.Timestamp = block.Block.Time.Format(time.RFC3339)
+	return txResp, nil
+}
@@ -0,0 +1,76 @@
+package cosmos
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	abcitypes "github.com/cometbft/cometbft/abci/types"
+	rpcclient "github.com/cometbft/cometbft/rpc/client"
+	coretypes "github.com/cometbft/cometbft/rpc/core/types"
+	tmtypes "github.com/cometbft/cometbft/types"
+	"github.com/stretchr/testify/require"
+)
+
+// pendingTxClient reports a tx as not found until it was asked for it `after` times.
+type pendingTxClient struct {
+	rpcclient.Client
+	after   int
+	lookups int
+	result  abcitypes.ResponseDeliverTx
+}
+
+func (c *pendingTxClient) Tx(_ context.Context, hash []byte, _ bool) (*coretypes.ResultTx, error) {
+	c.lookups++
+	if c.lookups <= c.after {
+		return nil, errors.New("RPC error -32603 - Internal error: tx (ABCD) not found")
+	}
+	return &coretypes.ResultTx{Hash: hash, Height: 7, TxResult: c.result}, nil
+}
+
+func (c *pendingTxClient) Block(_ context.Context, height *int64) (*coretypes.ResultBlock, error) {
+	return &coretypes.ResultBlock{Block: &tmtypes.Block{Header: tmtypes.Header{Height: *height, Time: time.Unix(1700000000, 0).UTC()}}}, nil
+}
+
+func withTxPollInterval(t *testing.T, d time.Duration) {
+	prev := TxPollInterval
+	TxPollInterval = d
+	t.Cleanup(func() { TxPollInterval = prev })
+}
+
+func TestWaitForTxPollsUntilCommitted(t *testing.T) {
+	withTxPollInterval(t, time.Millisecond)
+	client := &pendingTxClient{after: 3, result: abcitypes.ResponseDeliverTx{GasWanted: 10, GasUsed: 8}}
+	chain := CosmosChain{ChainID: "hub_1-1", Client: client}
+
+	res, err := WaitForTx(context.Background(), chain, "ABCD", time.Second)
+	require.NoError(t, err)
+	require.Equal(t, 4, client.lookups)
+	require.Equal(t, "7", res.Height)
+	require.Equal(t, "ABCD", res.TxHash)
+	require.Equal(t, "8", res.GasUsed)
+	require.Equal(t, "2023-11-14T22:13:20Z", res.Timestamp)
+}
+
+func TestWaitForTxFailedCode(t *testing.T) {
+	withTxPollInterval(t, time.Millisecond)
+	client := &pendingTxClient{result: abcitypes.ResponseDeliverTx{Code: 5, Codespace: "sdk", Log: "insufficient funds"}}
+	chain := CosmosChain{ChainID: "hub_1-1", Client: client}
+
+	res, err := WaitForTx(context.Background(), chain, "ABCD", time.Second)
+	var txErr *TxFailedError
+	require.ErrorAs(t, err, &txErr)
+	require.Equal(t, uint32(5), txErr.Code)
+	require.Equal(t, "sdk", txErr.Codespace)
+	require.Equal(t, "insufficient funds", txErr.RawLog)
+	require.NotNil(t, res)
+}
+
+func TestWaitForTxTimeout(t *testing.T) {
+	withTxPollInterval(t, time.Millisecond)
+	chain := CosmosChain{ChainID: "hub_1-1", Client: &pendingTxClient{after: 1 << 30}}
+
+	_, err := WaitForTx(context.Background(), chain, "ABCD", 20*time.Millisecond)
+	require.ErrorContains(t, err, "not committed on hub_1-1")
+}
@@ -1,3 +0,0 @@
-rm -rf ~/.dymension/keyring-test/dym1.info 
-rm -rf ~/.rollapp_evm/keyring-test/rolx1.info 
-rm -rf ~/.rollapp-wasm/keyring-test/roly1.info 
\ No newline at end of file
@@ -2,91 +2,79 @@ package example
 
 import (
 	"context"
-	"fmt"
+	"flag"
+	"math/big"
+	"os"
 	"testing"
+	"time"
 
 	"cosmossdk.io/math"
-	sdkmath "cosmossdk.io/math"
-	bankTypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
 	transfertypes "github.com/cosmos/ibc-go/v7/modules/apps/transfer/types"
+	"github.com/decentrio/e2e-testing-live/config"
 	"github.com/decentrio/e2e-testing-live/cosmos"
+	dymhub "github.com/decentrio/e2e-testing-live/cosmos/hub"
 	"github.com/decentrio/e2e-testing-live/testutil"
 	"github.com/decentrio/rollup-e2e-testing/ibc"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/stretchr/testify/require"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"go.uber.org/zap/zaptest"
 )
 
 var (
-	channelIDDymRollappX = "channel-17"
-	channelIDDymRollappY = "channel-22"
-	channelIDRollappXDym = "channel-0"
-	channelIDRollappYDym = "channel-0"
-	dymFee               = "6000000000000000adym"
-	rolxFee              = "10000000000000arolx"
-	rolyFee              = "2000000000000000aroly"
-	erc20Addr            = "rolx1glht96kr2rseywuvhhay894qw7ekuc4q4d4qs2"
-	erc20IBCDenom        = "ibc/FECACB927EB3102CCCB240FFB3B6FCCEEB8D944C6FEA8DFF079650FEFF59781D"
+	network    = flag.String("network", envOr("E2E_NETWORK", "blumbus"), "network profile to run the tests against")
+	networkDir = flag.String("network-dir", "../networks", "directory holding the network profiles")
 )
 
+// blockWaitTimeout bounds the waits on several chains at once. Idle rollapps
+// produce blocks only on demand, so it is longer than cosmos.StallTimeout.
+const blockWaitTimeout = 3 * time.Minute
+
 func TestIBCTransfer(t *testing.T) {
 	if testing.Short() {
 		t.Skip()
 	}
+	testutil.UseCassette(t)
 	ctx := context.Background()
 
-	hub := cosmos.CosmosChain{
-		RPCAddr:       "rpc-blumbus.mzonder.com:443",
-		GrpcAddr:      "grpc-blumbus.mzonder.com:9090",
-		ChainID:       "blumbus_111-1",
-		Bin:           "dymd",
-		GasPrices:     "1000adym",
-		GasAdjustment: "1.1",
-		Denom:         "adym",
-	}
-
-	rollappX := cosmos.CosmosChain{
-		RPCAddr:       "rpc.rolxtwo.evm.ra.blumbus.noisnemyd.xyz:443",
-		GrpcAddr:      "3.123.185.77:9090",
-		ChainID:       "rolx_100004-1",
-		Bin:           "rollapp-evm",
-		GasPrices:     "0.0arolx",
-		GasAdjustment: "1.1",
-		Denom:         "arolx",
-	}
-
-	rollappY := cosmos.CosmosChain{
-		RPCAddr:       "rpc.roly.wasm.ra.blumbus.noisnemyd.xyz:443",
-		GrpcAddr:      "18.153.150.111:9090",
-		ChainID:       "rollappy_700002-1",
-		Bin:           "rollapp-wasm",
-		GasPrices:     "0.0aroly",
-		GasAdjustment: "1.1",
-		Denom:         "aroly",
-	}
+	net, err := config.LoadProfile(*networkDir, *network)
+	require.NoError(t, err)
+	net.Logger = zaptest.NewLogger(t)
 
-	dymensionUser, err := hub.CreateUser("dym1")
+	hub, err := net.Hub("hub")
+	require.NoError(t, err)
+	rollappX, err := net.Rollapp("rollappx", hub)
 	require.NoError(t, err)
-	rollappXUser, err := rollappX.CreateUser("rolx1")
+	rollappY, err := net.Rollapp("rollappy", hub)
 	require.NoError(t, err)
-	rollappYUser, err := rollappY.CreateUser("roly1")
+
+	hubRollappX, err := cosmos.FindTransferChannel(ctx, hub.CosmosChain, rollappX.CosmosChain)
 	require.NoError(t, err)
+	channelIDDymRollappX := hubRollappX.ChannelID
+	channelIDRollappXDym := hubRollappX.CounterpartyChannelID
 
-	err = hub.NewClient("https://" + hub.RPCAddr)
+	err = hub.NewClient(hub.RPCURL())
 	require.NoError(t, err)
 
-	err = rollappX.NewClient("https://" + rollappX.RPCAddr)
+	err = rollappX.NewClient(rollappX.RPCURL())
 	require.NoError(t, err)
 
-	err = rollappY.NewClient("https://" + rollappY.RPCAddr)
+	err = rollappY.NewClient(rollappY.RPCURL())
 	require.NoError(t, err)
 
-	dymensionUser.GetFaucet("http://18.184.170.181:3000/api/get-dym")
-	rollappXUser.GetFaucet("http://18.184.170.181:3000/api/get-rollx")
-	rollappYUser.GetFaucet("http://18.184.170.181:3000/api/get-rolly")
+	accounts := make(map[string]cosmos.User)
+	for _, chain := range []string{"hub", "rollappx"} {
+		faucet, err := net.Faucet(chain)
+		require.NoError(t, err)
+		pool, err := testutil.NewAccountPool(faucet)
+		require.NoError(t, err)
+		accounts[chain], err = pool.Account(ctx, t)
+		require.NoError(t, err, "fund %s account", chain)
+	}
+	dymensionUser, rollappXUser := accounts["hub"], accounts["rollappx"]
 
 	// Wait for blocks
-	testutil.WaitForBlocks(ctx, 5, hub)
+	require.NoError(t, testutil.WaitForBlocksWithin(ctx, blockWaitTimeout, 5, hub, rollappX, rollappY))
 
 	// Get the IBC denom
 	rollappTokenDenom := transfertypes.GetPrefixedDenom("transfer", channelIDDymRollappX, rollappXUser.Denom)
@@ -95,21 +83,17 @@ func TestIBCTransfer(t *testing.T) {
 	hubTokenDenom := transfertypes.GetPrefixedDenom("transfer", channelIDRollappXDym, dymensionUser.Denom)
 	hubIBCDenom := transfertypes.ParseDenomTrace(hubTokenDenom).IBCDenom()
 
-	dymensionOrigBal, err := dymensionUser.GetBalance(ctx, dymensionUser.Denom, hub.GrpcAddr)
+	// Hub tokens reaching the EVM rollapp are converted into the ERC20 tokens
+	// of their token pair, the coins being escrowed by the erc20 module.
+	rollappXEVM, err := rollappX.EVM()
 	require.NoError(t, err)
-	fmt.Println(dymensionOrigBal)
-
-	rollappXOrigBal, err := rollappXUser.GetBalance(ctx, rollappXUser.Denom, rollappX.GrpcAddr)
+	erc20Module, err := rollappXEVM.ERC20ModuleAddress()
 	require.NoError(t, err)
-	fmt.Println(rollappXOrigBal)
-
-	rollappYOrigBal, err := rollappYUser.GetBalance(ctx, rollappYUser.Denom, rollappY.GrpcAddr)
+	dymAccount := testutil.BalanceAccount{Name: "dym", Chain: hub.CosmosChain, Address: dymensionUser.Address}
+	rolxAccount := testutil.BalanceAccount{Name: "rolx", Chain: rollappX.CosmosChain, Address: rollappXUser.Address}
+	erc20Account := testutil.BalanceAccount{Name: "erc20", Chain: rollappX.CosmosChain, Address: erc20Module}
+	before, err := testutil.TakeBalanceSnapshot(ctx, testutil.SnapshotOptions{IncludeIBC: true}, dymAccount, rolxAccount, erc20Account)
 	require.NoError(t, err)
-	fmt.Println(rollappYOrigBal)
-
-	erc20_OrigBal, err := GetERC20Balance(ctx, erc20IBCDenom, rollappX.GrpcAddr)
-	require.NoError(t, err)
-	fmt.Println(erc20_OrigBal)
 
 	transferAmount := math.NewInt(1_000_000)
 
@@ -120,12 +104,29 @@ func TestIBCTransfer(t *testing.T) {
 		Amount:  transferAmount,
 	}
 
-	testutil.WaitForBlocks(ctx, 3, hub)
+	require.NoError(t, testutil.WaitForBlocksWithin(ctx, blockWaitTimeout, 3, hub, rollappX))
+
+	tracker, err := testutil.NewPacketTracker(ctx, hub, rollappX)
+	require.NoError(t, err)
+
+	hubTxResp, err := cosmos.SendIBCTransfer(ctx, hub.CosmosChain, channelIDDymRollappX, dymensionUser.Address, transferData, hub.Fees, ibc.TransferOptions{})
+	require.NoError(t, err)
 
-	cosmos.SendIBCTransfer(hub, channelIDDymRollappX, dymensionUser.Address, transferData, dymFee, ibc.TransferOptions{})
+	packet, err := tracker.Track(ctx, *hubTxResp)
 	require.NoError(t, err)
+	require.True(t, packet.Acked(), "hub -> rollapp transfer not acknowledged: %s", packet.AckError)
+
+	// The token pair of hub tokens is registered when the rollapp first
+	// receives them, so it only exists now. The fresh rollapp account held
+	// none of its ERC20 tokens before. They are read over JSON-RPC, which not
+	// every profile has.
+	hubTokenPair, err := rollappXEVM.TokenPair(ctx, hubIBCDenom)
+	require.NoError(t, err)
+	withERC20 := rollappX.JsonRPCAddr != ""
+	if withERC20 {
+		rolxAccount.ERC20 = []string{hubTokenPair.ERC20Address}
+	}
 
-	testutil.WaitForBlocks(ctx, 3, hub)
 	// Compose an IBC transfer and send from rollapp -> hub
 	transferData = ibc.WalletData{
 		Address: dymensionUser.Address,
@@ -138,37 +139,81 @@ func TestIBCTransfer(t *testing.T) {
 
 	var options ibc.TransferOptions
 	// set eIBC specific memo
-	options.Memo = BuildEIbcMemo(eibcFee)
-	cosmos.SendIBCTransfer(rollappX, channelIDRollappXDym, rollappXUser.Address, transferData, rolxFee, options)
+	options.Memo, err = dymhub.BuildEIbcMemo(eibcFee, transferAmount)
 	require.NoError(t, err)
 
-	testutil.WaitForBlocks(ctx, 10, hub)
+	hubHeight, err := hub.Height(ctx)
+	require.NoError(t, err)
 
-	erc20_Bal, err := GetERC20Balance(ctx, hubIBCDenom, rollappX.GrpcAddr)
+	rollappTxResp, err := cosmos.SendIBCTransfer(ctx, rollappX.CosmosChain, channelIDRollappXDym, rollappXUser.Address, transferData, rollappX.Fees, options)
 	require.NoError(t, err)
-	fmt.Println(erc20_Bal)
-	fmt.Println(rollappIBCDenom)
-	testutil.AssertBalance(t, ctx, dymensionUser, rollappIBCDenom, hub.GrpcAddr, transferAmount.Sub(eibcFee))
-	require.Equal(t, erc20_OrigBal.Add(transferAmount), erc20_Bal)
-}
 
-func BuildEIbcMemo(eibcFee math.Int) string {
-	return fmt.Sprintf(`{"eibc": {"fee": "%s"}}`, eibcFee.String())
-}
-func GetERC20Balance(ctx context.Context, denom, grpcAddr string) (sdkmath.Int, error) {
-	params := &bankTypes.QueryBalanceRequest{Address: erc20Addr, Denom: denom}
-	conn, err := grpc.NewClient(grpcAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
-	if err != nil {
-		return sdkmath.Int{}, err
-	}
-	defer conn.Close()
+	ibcTx, err := cosmos.GetIbcTxFromTxResponse(*rollappTxResp)
+	require.NoError(t, err)
+
+	order, err := hub.FindDemandOrder(ctx, ibcTx.Packet, hubHeight, 0)
+	require.NoError(t, err)
+	require.Equal(t, eibcFee, order.ExpectedFee())
+	require.Equal(t, dymensionUser.Address, order.Recipient)
+
+	require.NoError(t, testutil.WaitForBlocksWithin(ctx, blockWaitTimeout, 10, hub, rollappX))
 
-	queryClient := bankTypes.NewQueryClient(conn)
-	res, err := queryClient.Balance(ctx, params)
+	after, err := testutil.TakeBalanceSnapshot(ctx, before.Options, dymAccount, rolxAccount, erc20Account)
+	require.NoError(t, err)
+	diff, err := before.Diff(after, *hubTxResp, *rollappTxResp)
+	require.NoError(t, err)
+	expected := []testutil.ExpectedDelta{
+		testutil.Delta("dym", dymensionUser.Denom, transferAmount.Neg()),
+		testutil.Delta("dym", rollappIBCDenom, transferAmount, eibcFee.Neg()),
+		testutil.Delta("rolx", rollappXUser.Denom, transferAmount.Neg()),
+		testutil.Delta("erc20", hubIBCDenom, transferAmount),
+	}
+	if withERC20 {
+		expected = append(expected, testutil.Delta("rolx", testutil.ERC20Denom(hubTokenPair.ERC20Address), transferAmount))
+	}
+	diff.Assert(t, expected...)
 
-	if err != nil {
-		return sdkmath.Int{}, err
+	if !withERC20 {
+		t.Skipf("network %s has no json_rpc_addr for rollappx, skipping the ERC20 conversions", *network)
 	}
 
-	return res.Balance.Amount, nil
+	// Convert half of the received ERC20 tokens back into coins, out of the
+	// erc20 module's escrow, then the coins into ERC20 tokens again.
+	half := transferAmount.QuoRaw(2)
+	convertTx, err := rollappXEVM.ConvertERC20(ctx, rollappXUser.KeyName, hubTokenPair.ERC20Address, half, "")
+	require.NoError(t, err)
+	converted, err := after.Retake(ctx)
+	require.NoError(t, err)
+	diff, err = after.Diff(converted, *convertTx)
+	require.NoError(t, err)
+	diff.Assert(t, append(testutil.ConvertERC20Deltas("rolx", "rolx", hubTokenPair, half),
+		testutil.Delta("erc20", hubIBCDenom, half.Neg()))...)
+
+	convertTx, err = rollappXEVM.ConvertCoin(ctx, rollappXUser.KeyName, sdk.NewCoin(hubIBCDenom, half), "")
+	require.NoError(t, err)
+	reconverted, err := converted.Retake(ctx)
+	require.NoError(t, err)
+	diff, err = converted.Diff(reconverted, *convertTx)
+	require.NoError(t, err)
+	diff.Assert(t, append(testutil.ConvertCoinDeltas("rolx", "rolx", hubTokenPair, half),
+		testutil.Delta("erc20", hubIBCDenom, half))...)
+
+	// The module mints the ERC20 tokens of module-owned pairs, which the
+	// contract logs as a Transfer from the zero address in the tx's block.
+	erc20, err := rollappXEVM.ERC20(ctx, hubTokenPair.ERC20Address)
+	require.NoError(t, err)
+	block, ok := new(big.Int).SetString(convertTx.Height, 10)
+	require.True(t, ok, "tx height %q", convertTx.Height)
+	transfers, err := erc20.Transfers(ctx, cosmos.TransferFilter{FromBlock: block, ToBlock: block, To: []string{rollappXUser.Address}})
+	require.NoError(t, err)
+	require.Len(t, transfers, 1)
+	require.Equal(t, common.Address{}, transfers[0].From)
+	require.Equal(t, half.BigInt(), transfers[0].Value)
+}
+
+func envOr(key, fallback string) string {
+	if v, ok := os.LookupEnv(key); ok {
+		return v
+	}
+	return fallback
 }
@@ -0,0 +1,125 @@
+// Package protoenc holds the helpers behind the hand-written protobuf
+// messages of this repository: wire encoding and the minimal file
+// descriptors the sdk needs to decode them.
+package protoenc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// GzipFileDescriptor builds the gzipped FileDescriptorProto returned by the
+// Descriptor methods of hand-written messages. The sdk tx decoder needs it
+// to check messages for unknown fields.
+func GzipFileDescriptor(name, pkg string, msgs ...*descriptorpb.DescriptorProto) []byte {
+	bz, err := proto.Marshal(&descriptorpb.FileDescriptorProto{
+		Name:        proto.String(name),
+		Package:     proto.String(pkg),
+		MessageType: msgs,
+		Syntax:      proto.String("proto3"),
+	})
+	if err != nil {
+		panic(err)
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(bz); err != nil {
+		panic(err)
+	}
+	if err := zw.Close(); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
+
+func MessageDescriptor(name string, fields ...*descriptorpb.FieldDescriptorProto) *descriptorpb.DescriptorProto {
+	return &descriptorpb.DescriptorProto{Name: proto.String(name), Field: fields}
+}
+
+func StringField(name string, num int32) *descriptorpb.FieldDescriptorProto {
+	return &descriptorpb.FieldDescriptorProto{
+		Name:     proto.String(name),
+		Number:   proto.Int32(num),
+		Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+		JsonName: proto.String(name),
+	}
+}
+
+// AppendString appends a length-delimited string field, omitting the proto3 default.
+func AppendString(b []byte, num protowire.Number, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+// RangeFields walks the wire encoding of a message, calling f for every field.
+// v is the payload of length-delimited fields and the raw encoded value of
+// any other field type.
+func RangeFields(bz []byte, f func(num protowire.Number, typ protowire.Type, v []byte) error) error {
+	for len(bz) > 0 {
+		num, typ, n := protowire.ConsumeTag(bz)
+		if n < 0 {
+			return fmt.Errorf("invalid tag: %w", protowire.ParseError(n))
+		}
+		bz = bz[n:]
+
+		var v []byte
+		if typ == protowire.BytesType {
+			v, n = protowire.ConsumeBytes(bz)
+		} else {
+			n = protowire.ConsumeFieldValue(num, typ, bz)
+		}
+		if n < 0 {
+			return fmt.Errorf("invalid field %d: %w", num, protowire.ParseError(n))
+		}
+		if typ != protowire.BytesType {
+			v = bz[:n]
+		}
+		bz = bz[n:]
+
+		if err := f(num, typ, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AppendUint64 appends a Varint field, omitting the proto3 default.
+func AppendUint64(b []byte, num protowire.Number, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+// AppendBool appends a bool field, omitting the proto3 default.
+func AppendBool(b []byte, num protowire.Number, v bool) []byte {
+	if !v {
+		return b
+	}
+	return AppendUint64(b, num, 1)
+}
+
+// AppendBytes appends a length-delimited field, such as an embedded message.
+func AppendBytes(b []byte, num protowire.Number, bz []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, bz)
+}
+
+// Varint decodes the raw value of a Varint field as passed by RangeFields.
+func Varint(v []byte) (uint64, error) {
+	x, n := protowire.ConsumeVarint(v)
+	if n < 0 {
+		return 0, protowire.ParseError(n)
+	}
+	return x, nil
+}
@@ -0,0 +1,116 @@
+package testutil
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/decentrio/e2e-testing-live/cosmos"
+)
+
+// accountCleanupTimeout bounds the sweep and key deletion of a test's accounts.
+const accountCleanupTimeout = 2 * time.Minute
+
+// AccountPool hands out freshly created, funded accounts of a chain. Keys
+// are named after the test with a random suffix, so tests can run in
+// parallel and be re-run without cleaning the keyring. When the test ends,
+// leftover funds are swept back to the faucet's treasury and the keys are
+// deleted.
+type AccountPool struct {
+	Faucet *cosmos.Faucet
+}
+
+// NewAccountPool returns a pool funding its accounts from faucet. With a
+// treasury key and an amount, the accounts requested together are funded by
+// a single multi-send.
+func NewAccountPool(faucet *cosmos.Faucet) (*AccountPool, error) {
+	// Create the RPC client now: the faucet's chain is shared by parallel
+	// tests from here on.
+	if faucet.Chain.Client == nil {
+		if err := faucet.Chain.NewClient(faucet.Chain.RPCURL()); err != nil {
+			return nil, err
+		}
+	}
+	return &AccountPool{Faucet: faucet}, nil
+}
+
+// Account returns a funded account for t.
+func (p *AccountPool) Account(ctx context.Context, t testing.TB) (cosmos.User, error) {
+	users, err := p.Accounts(ctx, t, 1)
+	if err != nil {
+		return cosmos.User{}, err
+	}
+	return users[0], nil
+}
+
+// Accounts creates n accounts for t and funds them.
+func (p *AccountPool) Accounts(ctx context.Context, t testing.TB, n int) ([]cosmos.User, error) {
+	prefix, err := keyPrefix(t.Name())
+	if err != nil {
+		return nil, err
+	}
+	chain := p.Faucet.Chain
+
+	users := make([]cosmos.User, 0, n)
+	t.Cleanup(func() { p.release(t, users) })
+	for i := 0; i < n; i++ {
+		user, err := chain.CreateUser(ctx, fmt.Sprintf("%s-%d", prefix, i))
+		if err != nil {
+			return nil, fmt.Errorf("create account on %s: %w", chain.ChainID, err)
+		}
+		users = append(users, user)
+	}
+
+	addresses := make([]string, len(users))
+	for i, user := range users {
+		addresses[i] = user.Address
+	}
+	if err := p.Faucet.FundMany(ctx, addresses...); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// release sweeps the funds of users back to the treasury and deletes their
+// keys. Failures are logged: they leak funds, not correctness.
+func (p *AccountPool) release(t testing.TB, users []cosmos.User) {
+	ctx, cancel := context.WithTimeout(context.Background(), accountCleanupTimeout)
+	defer cancel()
+	chain := p.Faucet.Chain
+	for _, user := range users {
+		if p.Faucet.TreasuryKey != "" {
+			if err := p.Faucet.Sweep(ctx, user.KeyName, user.Address); err != nil {
+				t.Logf("sweep funds of %s on %s: %v", user.KeyName, chain.ChainID, err)
+			}
+		}
+		if err := chain.DeleteKey(ctx, user.KeyName); err != nil {
+			t.Logf("delete key %s on %s: %v", user.KeyName, chain.ChainID, err)
+		}
+	}
+}
+
+// keyPrefix derives a keyring-friendly, unique key name prefix from a test name.
+func keyPrefix(testName string) (string, error) {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		default:
+			return '-'
+		}
+	}, testName)
+	if len(name) > 32 {
+		name = name[:32]
+	}
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	return strings.Trim(name, "-") + "-" + hex.EncodeToString(suffix), nil
+}
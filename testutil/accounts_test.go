@@ -0,0 +1,108 @@
+package testutil
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/decentrio/e2e-testing-live/cosmos"
+	"github.com/decentrio/e2e-testing-live/testutil/fakechain"
+	"github.com/stretchr/testify/require"
+)
+
+// bankExecutor applies the bank sends it runs to the balances of a fake
+// chain, and names the keys it adds after the command like the binary does.
+type bankExecutor struct {
+	cosmos.ScriptedExecutor
+	mu   sync.Mutex
+	bank *fakechain.Chain
+	// addresses maps key names to addresses.
+	addresses map[string]string
+}
+
+func (e *bankExecutor) Exec(ctx context.Context, bin string, args ...string) (cosmos.ExecResult, error) {
+	res, err := e.ScriptedExecutor.Exec(ctx, bin, args...)
+	if err == nil && len(args) > 2 && args[0] == "keys" && args[1] == "add" {
+		res.Stdout = bytes.Replace(res.Stdout, []byte(`"name":"key"`), []byte(`"name":"`+args[2]+`"`), 1)
+	}
+	if err != nil || len(args) < 3 || args[0] != "tx" || args[1] != "bank" {
+		return res, err
+	}
+	from := e.addresses[args[3]]
+	end := 4
+	for end < len(args) && !strings.HasPrefix(args[end], "--") {
+		end++
+	}
+	recipients := args[4 : end-1]
+	amount, err := sdk.ParseCoinsNormalized(args[end-1])
+	if err != nil {
+		return res, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, to := range recipients {
+		e.bank.SetBalance(from, e.bank.Balances(from).Sub(amount...)...)
+		e.bank.SetBalance(to, e.bank.Balances(to).Add(amount...)...)
+	}
+	return res, nil
+}
+
+func TestAccountPool(t *testing.T) {
+	bank := fakechain.New(t, fakechain.Options{})
+	bank.SetBalance("dym1treasury", sdk.NewInt64Coin("adym", 10_000))
+	exec := &bankExecutor{bank: bank, addresses: map[string]string{"treasury": "dym1treasury"}}
+	chain := cosmos.CosmosChain{
+		ChainID:       "hub_1-1",
+		Bin:           "dymd",
+		Denom:         "adym",
+		Fees:          "2adym",
+		RPCAddr:       "http://127.0.0.1:26657",
+		GrpcAddr:      bank.GRPCAddr(),
+		BroadcastMode: cosmos.BroadcastSync,
+		Executor:      exec,
+	}
+	t.Cleanup(cosmos.CloseGRPCConns)
+	faucet := chain.NewFaucet("")
+	faucet.TreasuryKey = "treasury"
+	faucet.Amount = sdk.NewInt64Coin("adym", 1000)
+	pool, err := NewAccountPool(faucet)
+	require.NoError(t, err)
+
+	alice := sdk.MustBech32ifyAddressBytes("dym", bytes.Repeat([]byte{1}, 20))
+	bob := sdk.MustBech32ifyAddressBytes("dym", bytes.Repeat([]byte{2}, 20))
+	var keys []string
+	t.Run("Parallel/Test", func(t *testing.T) {
+		for _, addr := range []string{alice, bob} {
+			exec.Expect("keys", "add").Return(`{"name":"key","type":"local","address":"` + addr + `","pubkey":"{}"}`)
+		}
+		exec.Expect("tx", "bank", "multi-send", "treasury", alice, bob, "1000adym").Return(`{"txhash":"AB"}`)
+
+		users, err := pool.Accounts(context.Background(), t, 2)
+		require.NoError(t, err)
+		require.Len(t, users, 2)
+		for i, user := range users {
+			require.Regexp(t, `^testaccountpool-parallel-test-[0-9a-f]{8}-\d$`, user.KeyName)
+			require.Equal(t, int64(1000), bank.Balances(user.Address).AmountOf("adym").Int64())
+			exec.addresses[user.KeyName] = user.Address
+			keys = append(keys, user.KeyName)
+			if i == 0 {
+				// Spend some, the rest is swept back.
+				bank.SetBalance(user.Address, sdk.NewInt64Coin("adym", 400), sdk.NewInt64Coin("ibc/27394FB0", 5))
+			}
+		}
+		require.NotEqual(t, keys[0], keys[1])
+		require.Equal(t, keys[0][:len(keys[0])-1], keys[1][:len(keys[1])-1], "accounts requested together share a prefix")
+
+		exec.Expect("keys", "show", "--address", "treasury").Return("dym1treasury\n")
+		exec.Expect("tx", "bank", "send", keys[0], "dym1treasury", "398adym,5ibc/27394FB0").Return(`{"txhash":"CD"}`)
+		exec.Expect("keys", "delete", keys[0]).Return("")
+		exec.Expect("tx", "bank", "send", keys[1], "dym1treasury", "998adym").Return(`{"txhash":"EF"}`)
+		exec.Expect("keys", "delete", keys[1]).Return("")
+	})
+	require.Empty(t, exec.Pending())
+	require.Equal(t, int64(10_000-2000+398+998), bank.Balances("dym1treasury").AmountOf("adym").Int64())
+}
@@ -0,0 +1,314 @@
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"text/tabwriter"
+
+	sdkmath "cosmossdk.io/math"
+	"github.com/cosmos/cosmos-sdk/types/query"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/decentrio/e2e-testing-live/cosmos"
+	"github.com/decentrio/e2e-testing-live/cosmos/rollapp"
+)
+
+// ERC20Denom is the denom under which a BalanceSnapshot records balances of
+// the ERC20 contract.
+func ERC20Denom(contract string) string {
+	return "erc20:" + contract
+}
+
+// ConvertCoinDeltas expects the balances moved by a convert-coin of amount of
+// the token pair's denom from sender to receiver, both account names: the
+// sender's coins drop by amount and the receiver's ERC20 tokens rise by as
+// much. The receiver's account must list pair.ERC20Address in its ERC20
+// contracts. Coins of module-owned pairs are escrowed by the erc20 module
+// account, whose expectation is up to the caller.
+func ConvertCoinDeltas(sender, receiver string, pair rollapp.TokenPair, amount sdkmath.Int) []ExpectedDelta {
+	return []ExpectedDelta{
+		Delta(sender, pair.Denom, amount.Neg()),
+		Delta(receiver, ERC20Denom(pair.ERC20Address), amount),
+	}
+}
+
+// ConvertERC20Deltas expects the balances moved by a convert-erc20 of amount
+// of the token pair's contract from sender to receiver, the reverse of
+// ConvertCoinDeltas.
+func ConvertERC20Deltas(sender, receiver string, pair rollapp.TokenPair, amount sdkmath.Int) []ExpectedDelta {
+	return []ExpectedDelta{
+		Delta(sender, ERC20Denom(pair.ERC20Address), amount.Neg()),
+		Delta(receiver, pair.Denom, amount),
+	}
+}
+
+// BalanceAccount is an account, on a given chain, whose balances a
+// BalanceSnapshot records.
+type BalanceAccount struct {
+	// Name labels the account in expectations and failure messages. It
+	// defaults to Address.
+	Name    string
+	Chain   cosmos.CosmosChain
+	Address string
+	// ERC20 lists contracts whose balance is recorded as well, read over the
+	// chain's JSON-RPC endpoint. EVM rollapps only.
+	ERC20 []string
+}
+
+func (a BalanceAccount) name() string {
+	if a.Name != "" {
+		return a.Name
+	}
+	return a.Address
+}
+
+// SnapshotOptions selects the balances a BalanceSnapshot records.
+type SnapshotOptions struct {
+	// IncludeIBC records ibc/ denoms too. They are skipped by default.
+	IncludeIBC bool
+}
+
+// BalanceSnapshot is the balances of a set of accounts at one point in time.
+type BalanceSnapshot struct {
+	Accounts []BalanceAccount
+	Options  SnapshotOptions
+	// Balances maps account names to their balance of each denom.
+	Balances map[string]map[string]sdkmath.Int
+}
+
+// TakeBalanceSnapshot records the current balances of accounts.
+func TakeBalanceSnapshot(ctx context.Context, opts SnapshotOptions, accounts ...BalanceAccount) (*BalanceSnapshot, error) {
+	s := &BalanceSnapshot{
+		Accounts: accounts,
+		Options:  opts,
+		Balances: make(map[string]map[string]sdkmath.Int, len(accounts)),
+	}
+	for _, acc := range accounts {
+		if _, ok := s.Balances[acc.name()]; ok {
+			return nil, fmt.Errorf("duplicate balance account %s", acc.name())
+		}
+		balances, err := accountBalances(ctx, acc, opts)
+		if err != nil {
+			return nil, fmt.Errorf("balances of %s on %s: %w", acc.name(), acc.Chain.ChainID, err)
+		}
+		s.Balances[acc.name()] = balances
+	}
+	return s, nil
+}
+
+// Retake records the current balances of the snapshot's accounts, with the
+// same options.
+func (s *BalanceSnapshot) Retake(ctx context.Context) (*BalanceSnapshot, error) {
+	return TakeBalanceSnapshot(ctx, s.Options, s.Accounts...)
+}
+
+// Balance returns the balance of denom recorded for account, zero if none.
+func (s *BalanceSnapshot) Balance(account, denom string) sdkmath.Int {
+	if b, ok := s.Balances[account][denom]; ok {
+		return b
+	}
+	return sdkmath.ZeroInt()
+}
+
+func accountBalances(ctx context.Context, acc BalanceAccount, opts SnapshotOptions) (map[string]sdkmath.Int, error) {
+	bank, err := acc.Chain.BankQuery()
+	if err != nil {
+		return nil, err
+	}
+	balances := make(map[string]sdkmath.Int)
+	var nextKey []byte
+	for {
+		res, err := bank.AllBalances(ctx, &banktypes.QueryAllBalancesRequest{
+			Address:    acc.Address,
+			Pagination: &query.PageRequest{Key: nextKey},
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, coin := range res.Balances {
+			if !opts.IncludeIBC && strings.HasPrefix(coin.Denom, "ibc/") {
+				continue
+			}
+			balances[coin.Denom] = coin.Amount
+		}
+		if res.Pagination == nil || len(res.Pagination.NextKey) == 0 {
+			break
+		}
+		nextKey = res.Pagination.NextKey
+	}
+
+	if len(acc.ERC20) == 0 {
+		return balances, nil
+	}
+	evm := rollapp.EVMRollapp{Rollapp: rollapp.NewRollapp(acc.Chain, "", rollapp.VMEVM, nil)}
+	for _, contract := range acc.ERC20 {
+		balance, err := evm.ERC20Balance(ctx, contract, acc.Address, nil)
+		if err != nil {
+			return nil, fmt.Errorf("erc20 %s: %w", contract, err)
+		}
+		balances[ERC20Denom(contract)] = sdkmath.NewIntFromBigInt(balance)
+	}
+	return balances, nil
+}
+
+// BalanceDelta is how a balance moved between two snapshots.
+type BalanceDelta struct {
+	Account string
+	Denom   string
+	Before  sdkmath.Int
+	After   sdkmath.Int
+	// Fees is what the account paid in tx fees, in Denom, according to the
+	// tx responses given to Diff.
+	Fees sdkmath.Int
+}
+
+// Delta is the change of the balance, fees included.
+func (d BalanceDelta) Delta() sdkmath.Int {
+	return d.After.Sub(d.Before)
+}
+
+// BalanceDiff is the difference between two snapshots of the same accounts.
+type BalanceDiff struct {
+	// Deltas has an entry per account and denom seen in either snapshot,
+	// sorted by account then denom.
+	Deltas []BalanceDelta
+}
+
+// Diff compares s with a later snapshot. txs are the transactions sent in
+// between by the snapshot's accounts; the fees they paid are accounted for
+// when checking expectations.
+func (s *BalanceSnapshot) Diff(later *BalanceSnapshot, txs ...cosmos.TxResponse) (BalanceDiff, error) {
+	fees := make(map[[2]string]sdkmath.Int)
+	for _, tx := range txs {
+		payer, fee, err := cosmos.TxFee(tx)
+		if err != nil {
+			return BalanceDiff{}, err
+		}
+		account := ""
+		for _, acc := range s.Accounts {
+			if acc.Address == payer {
+				account = acc.name()
+				break
+			}
+		}
+		if account == "" {
+			return BalanceDiff{}, fmt.Errorf("tx %s was paid by %s, which is not in the snapshot", tx.TxHash, payer)
+		}
+		for _, coin := range fee {
+			key := [2]string{account, coin.Denom}
+			if prev, ok := fees[key]; ok {
+				fees[key] = prev.Add(coin.Amount)
+			} else {
+				fees[key] = coin.Amount
+			}
+		}
+	}
+
+	var diff BalanceDiff
+	for _, acc := range s.Accounts {
+		name := acc.name()
+		denoms := make(map[string]bool)
+		for denom := range s.Balances[name] {
+			denoms[denom] = true
+		}
+		for denom := range later.Balances[name] {
+			denoms[denom] = true
+		}
+		for denom := range denoms {
+			fee, ok := fees[[2]string{name, denom}]
+			if !ok {
+				fee = sdkmath.ZeroInt()
+			}
+			diff.Deltas = append(diff.Deltas, BalanceDelta{
+				Account: name,
+				Denom:   denom,
+				Before:  s.Balance(name, denom),
+				After:   later.Balance(name, denom),
+				Fees:    fee,
+			})
+		}
+	}
+	sort.Slice(diff.Deltas, func(i, j int) bool {
+		a, b := diff.Deltas[i], diff.Deltas[j]
+		if a.Account != b.Account {
+			return a.Account < b.Account
+		}
+		return a.Denom < b.Denom
+	})
+	return diff, nil
+}
+
+// ExpectedDelta is the expected change of an account's balance, excluding
+// the fees it paid.
+type ExpectedDelta struct {
+	Account string
+	Denom   string
+	Delta   sdkmath.Int
+}
+
+// Delta expects account's balance of denom to change by the sum of amounts,
+// fees aside: Delta("alice", "adym", amount.Neg()) for a transfer out.
+func Delta(account, denom string, amounts ...sdkmath.Int) ExpectedDelta {
+	sum := sdkmath.ZeroInt()
+	for _, a := range amounts {
+		sum = sum.Add(a)
+	}
+	return ExpectedDelta{Account: account, Denom: denom, Delta: sum}
+}
+
+// Check verifies the expected deltas, each reduced by the fees the account
+// paid in that denom. Balances that paid fees without being listed are
+// expected to have only paid fees; other balances are not checked. The error
+// lists every balance as a table.
+func (d BalanceDiff) Check(expected ...ExpectedDelta) error {
+	want := make(map[[2]string]sdkmath.Int, len(expected))
+	for _, e := range expected {
+		want[[2]string{e.Account, e.Denom}] = e.Delta
+	}
+
+	var buf strings.Builder
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ACCOUNT\tDENOM\tBEFORE\tAFTER\tDELTA\tFEES\tEXPECTED\t")
+	failed := false
+	for _, delta := range d.Deltas {
+		key := [2]string{delta.Account, delta.Denom}
+		expected, ok := want[key]
+		delete(want, key)
+		mark := ""
+		switch {
+		case ok || !delta.Fees.IsZero():
+			if !ok {
+				expected = sdkmath.ZeroInt()
+			}
+			expected = expected.Sub(delta.Fees)
+			if !delta.Delta().Equal(expected) {
+				mark, failed = "  <- mismatch", true
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", delta.Account, delta.Denom, delta.Before, delta.After, delta.Delta(), delta.Fees, expected, mark)
+		default:
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t-\t\n", delta.Account, delta.Denom, delta.Before, delta.After, delta.Delta(), delta.Fees)
+		}
+	}
+	// Expectations on balances absent from both snapshots are checked against zero.
+	for _, e := range expected {
+		if _, ok := want[[2]string{e.Account, e.Denom}]; ok && !e.Delta.IsZero() {
+			failed = true
+			fmt.Fprintf(w, "%s\t%s\t0\t0\t0\t0\t%s\t  <- mismatch\n", e.Account, e.Denom, e.Delta)
+		}
+	}
+	_ = w.Flush()
+	if failed {
+		return fmt.Errorf("unexpected balance changes:\n%s", buf.String())
+	}
+	return nil
+}
+
+// Assert fails the test if Check does.
+func (d BalanceDiff) Assert(t testing.TB, expected ...ExpectedDelta) {
+	t.Helper()
+	if err := d.Check(expected...); err != nil {
+		t.Fatal(err)
+	}
+}
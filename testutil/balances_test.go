@@ -0,0 +1,104 @@
+package testutil
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	sdkmath "cosmossdk.io/math"
+	abcitypes "github.com/cometbft/cometbft/abci/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/decentrio/e2e-testing-live/cosmos"
+	"github.com/decentrio/e2e-testing-live/cosmos/rollapp"
+	"github.com/decentrio/e2e-testing-live/testutil/fakechain"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/require"
+)
+
+// serveERC20Balance answers every eth_call with balance.
+func serveERC20Balance(t *testing.T, balance *atomic.Int64) string {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID json.RawMessage `json:"id"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"jsonrpc": "2.0",
+			"id":      req.ID,
+			"result":  hexutil.Encode(common.BigToHash(big.NewInt(balance.Load())).Bytes()),
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func feeTx(hash, payer, fee string) cosmos.TxResponse {
+	return cosmos.TxResponse{TxHash: hash, Events: []abcitypes.Event{
+		{Type: "tx", Attributes: []abcitypes.EventAttribute{{Key: "acc_seq", Value: payer + "/3"}}},
+		{Type: "tx", Attributes: []abcitypes.EventAttribute{{Key: "fee", Value: fee}, {Key: "fee_payer", Value: payer}}},
+	}}
+}
+
+func TestBalanceSnapshotDiff(t *testing.T) {
+	const (
+		alice    = "dym1alice"
+		ibcDenom = "ibc/27394FB0"
+		token    = "0x00000000000000000000000000000000000000aa"
+	)
+	bob := sdk.MustBech32ifyAddressBytes("rolx", make([]byte, 20))
+	bank := fakechain.New(t, fakechain.Options{})
+	bank.SetBalance(alice, sdk.NewInt64Coin("adym", 1000))
+	bank.SetBalance(bob, sdk.NewInt64Coin("arolx", 500), sdk.NewInt64Coin(ibcDenom, 7))
+	hub := cosmos.CosmosChain{ChainID: "hub_1-1", GrpcAddr: bank.GRPCAddr()}
+	var erc20Balance atomic.Int64
+	erc20Balance.Store(40)
+	rolx := cosmos.CosmosChain{ChainID: "rolx_1-1", GrpcAddr: hub.GrpcAddr, JsonRPCAddr: serveERC20Balance(t, &erc20Balance)}
+	t.Cleanup(cosmos.CloseGRPCConns)
+	t.Cleanup(cosmos.CloseEthClients)
+
+	ctx := context.Background()
+	before, err := TakeBalanceSnapshot(ctx, SnapshotOptions{},
+		BalanceAccount{Name: "alice", Chain: hub, Address: alice},
+		BalanceAccount{Name: "bob", Chain: rolx, Address: bob, ERC20: []string{token}},
+	)
+	require.NoError(t, err)
+	require.Equal(t, int64(40), before.Balance("bob", ERC20Denom(token)).Int64())
+	require.Equal(t, int64(0), before.Balance("bob", ibcDenom).Int64(), "ibc denoms are skipped by default")
+
+	// alice sends 100adym to bob, paying a 3adym fee, and bob converts 10arolx to ERC20.
+	bank.SetBalance(alice, sdk.NewInt64Coin("adym", 897))
+	bank.SetBalance(bob, sdk.NewInt64Coin("adym", 100), sdk.NewInt64Coin("arolx", 488))
+	erc20Balance.Store(50)
+	after, err := before.Retake(ctx)
+	require.NoError(t, err)
+
+	diff, err := before.Diff(after, feeTx("A1", alice, "3adym"), feeTx("B1", bob, "2arolx"))
+	require.NoError(t, err)
+	amount := sdkmath.NewInt(100)
+	diff.Assert(t,
+		Delta("alice", "adym", amount.Neg()),
+		Delta("bob", "adym", amount),
+		Delta("bob", "arolx", sdkmath.NewInt(-10)),
+		Delta("bob", "unused", sdkmath.ZeroInt()),
+		Delta("bob", ERC20Denom(token), sdkmath.NewInt(10)),
+	)
+	conversion, err := before.Diff(after, feeTx("B1", bob, "2arolx"))
+	require.NoError(t, err)
+	pair := rollapp.TokenPair{ERC20Address: token, Denom: "arolx", Enabled: true}
+	conversion.Assert(t, ConvertCoinDeltas("bob", "bob", pair, sdkmath.NewInt(10))...)
+	require.Error(t, conversion.Check(ConvertERC20Deltas("bob", "bob", pair, sdkmath.NewInt(10))...))
+
+	err = diff.Check(Delta("bob", "adym", amount, sdkmath.NewInt(-1)))
+	require.ErrorContains(t, err, "unexpected balance changes")
+	require.Regexp(t, `bob +adym +0 +100 +100 +0 +99 +<- mismatch`, err.Error())
+	require.Regexp(t, `alice +adym +1000 +897 +-103 +3 +-3 +<- mismatch`, err.Error(), "fee payers are checked even when not listed")
+	require.Regexp(t, `bob +erc20:0x0+aa +40 +50 +10 +0 +- *\n`, err.Error())
+
+	_, err = before.Diff(after, feeTx("C1", "dym1carol", "1adym"))
+	require.ErrorContains(t, err, "paid by dym1carol, which is not in the snapshot")
+}
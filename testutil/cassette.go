@@ -0,0 +1,60 @@
+package testutil
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/decentrio/e2e-testing-live/cosmos"
+)
+
+// Environment variables configuring UseCassette.
+const (
+	// CassetteDirEnv is the directory holding the cassettes, one per test.
+	// Cassettes are not used when it is unset.
+	CassetteDirEnv = "E2E_CASSETTE_DIR"
+	// CassetteModeEnv is "record", the default, or "replay".
+	CassetteModeEnv = "E2E_CASSETTE_MODE"
+)
+
+// UseCassette records the chain interactions of the test to, or replays them
+// from, a cassette named after it in the directory set by E2E_CASSETTE_DIR,
+// until the test ends. It does nothing when the variable is unset.
+func UseCassette(t testing.TB) {
+	t.Helper()
+	dir := os.Getenv(CassetteDirEnv)
+	if dir == "" {
+		return
+	}
+	mode := cosmos.CassetteMode(os.Getenv(CassetteModeEnv))
+	if mode == "" {
+		mode = cosmos.CassetteRecord
+	}
+	if mode == cosmos.CassetteRecord {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			t.Fatalf("create cassette dir: %v", err)
+		}
+	}
+	name := strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ' ' || r == ':' {
+			return '_'
+		}
+		return r
+	}, t.Name())
+	cassette, err := cosmos.OpenCassette(filepath.Join(dir, name+".cassette.jsonl"), mode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restore, err := cosmos.UseCassette(cassette)
+	if err != nil {
+		_ = cassette.Close()
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		restore()
+		if err := cassette.Close(); err != nil {
+			t.Errorf("close cassette: %v", err)
+		}
+	})
+}
@@ -0,0 +1,310 @@
+// Package fakechain runs an in-process stand-in for a chain node, so that the
+// cosmos and testutil packages can be exercised by ordinary go test runs
+// without live endpoints.
+//
+// A Chain serves the subset of the CometBFT RPC used by the harness (status,
+// block, block_results, tx, broadcast_tx_* and websocket subscriptions) and
+// the gRPC bank, auth, tx simulation and IBC query services. Its state is
+// scripted by the test: balances, accounts, channels and blocks of given txs
+// and events. Blocks are only committed when the test asks for one, or on
+// every Options.BlockTime.
+package fakechain
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	abcitypes "github.com/cometbft/cometbft/abci/types"
+	coretypes "github.com/cometbft/cometbft/rpc/core/types"
+	tmtypes "github.com/cometbft/cometbft/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/decentrio/e2e-testing-live/cosmos"
+	"google.golang.org/grpc"
+)
+
+// Options configures a Chain. The zero value is usable.
+type Options struct {
+	// ChainID defaults to "fake_1-1".
+	ChainID string
+	// Denom defaults to "adym".
+	Denom string
+	// Bech32Prefix defaults to "dym".
+	Bech32Prefix string
+	// BlockTime commits a block, with the txs broadcast since the last one,
+	// on every tick. Blocks are only committed by Commit when zero.
+	BlockTime time.Duration
+	// CheckTx decides whether a broadcast tx enters the mempool. Every tx is
+	// accepted when nil.
+	CheckTx func(tx []byte) abcitypes.ResponseCheckTx
+	// DeliverTx returns the result of a broadcast tx once committed. When nil
+	// the bank sends of the tx are applied to the balances and the tx
+	// succeeds, or fails with insufficient funds.
+	DeliverTx func(tx []byte) abcitypes.ResponseDeliverTx
+	// Services registers more gRPC services on the node, such as the queries
+	// of a module the chain does not fake.
+	Services func(*grpc.Server)
+}
+
+func (o *Options) setDefaults() {
+	if o.ChainID == "" {
+		o.ChainID = "fake_1-1"
+	}
+	if o.Denom == "" {
+		o.Denom = "adym"
+	}
+	if o.Bech32Prefix == "" {
+		o.Bech32Prefix = "dym"
+	}
+}
+
+// Chain is a fake chain node. All its methods are safe for concurrent use.
+type Chain struct {
+	opts Options
+	t    testing.TB
+
+	// commitMu serializes commits, which deliver txs without holding mu.
+	commitMu sync.Mutex
+	mu       sync.Mutex
+	blocks   []*block
+	txs      map[string]*abcitypes.TxResult
+	mempool  []tmtypes.Tx
+	balances map[string]sdk.Coins
+	accounts map[string]*account
+	channels []*channel
+	// committed is closed and replaced by every commit.
+	committed     chan struct{}
+	subscriptions int
+
+	eventBus *tmtypes.EventBus
+	rpcAddr  string
+	grpcAddr string
+}
+
+type block struct {
+	block   *tmtypes.Block
+	id      tmtypes.BlockID
+	results *coretypes.ResultBlockResults
+}
+
+// New starts a chain with a first, empty block. It is stopped when the test
+// ends.
+func New(t testing.TB, opts Options) *Chain {
+	t.Helper()
+	opts.setDefaults()
+	c := &Chain{
+		opts:      opts,
+		t:         t,
+		txs:       map[string]*abcitypes.TxResult{},
+		balances:  map[string]sdk.Coins{},
+		accounts:  map[string]*account{},
+		committed: make(chan struct{}),
+		eventBus:  tmtypes.NewEventBus(),
+	}
+	if err := c.eventBus.Start(); err != nil {
+		t.Fatalf("start event bus: %v", err)
+	}
+	t.Cleanup(func() { _ = c.eventBus.Stop() })
+
+	c.serveRPC(t)
+	c.serveGRPC(t)
+	c.Commit()
+
+	if opts.BlockTime > 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			ticker := time.NewTicker(opts.BlockTime)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					c.Commit()
+				}
+			}
+		}()
+		t.Cleanup(func() {
+			cancel()
+			<-done
+		})
+	}
+	return c
+}
+
+// ChainID returns the chain ID reported by the node.
+func (c *Chain) ChainID() string {
+	return c.opts.ChainID
+}
+
+// RPCAddr returns the URL of the CometBFT RPC, whose websocket is served at /websocket.
+func (c *Chain) RPCAddr() string {
+	return c.rpcAddr
+}
+
+// GRPCAddr returns the host:port of the gRPC server, which does not use TLS.
+func (c *Chain) GRPCAddr() string {
+	return c.grpcAddr
+}
+
+// CosmosChain returns a chain configured to talk to the fake node, with an
+// RPC client connected to it. Commands run by its executor still need the
+// chain binary.
+func (c *Chain) CosmosChain() cosmos.CosmosChain {
+	chain := cosmos.CosmosChain{
+		ChainID:      c.opts.ChainID,
+		RPCAddr:      c.rpcAddr,
+		GrpcAddr:     c.grpcAddr,
+		GrpcTLS:      cosmos.GRPCTLSInsecure,
+		Denom:        c.opts.Denom,
+		Bech32Prefix: c.opts.Bech32Prefix,
+	}
+	if err := chain.NewClient(c.rpcAddr); err != nil {
+		c.t.Fatalf("rpc client of %s: %v", c.opts.ChainID, err)
+	}
+	return chain
+}
+
+// Height returns the height of the latest block.
+func (c *Chain) Height() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return int64(len(c.blocks))
+}
+
+// Block is the content of a block to commit.
+type Block struct {
+	// Txs follow the txs broadcast since the last block.
+	Txs []Tx
+	// BeginBlockEvents and EndBlockEvents are emitted by the block itself,
+	// e.g. the acknowledgements written by the hub's delayedack module.
+	BeginBlockEvents []abcitypes.Event
+	EndBlockEvents   []abcitypes.Event
+	// Time defaults to the current time.
+	Time time.Time
+}
+
+// Commit commits a block of the broadcast txs followed by txs, and returns its height.
+func (c *Chain) Commit(txs ...Tx) int64 {
+	return c.CommitBlock(Block{Txs: txs})
+}
+
+// CommitBlock commits b and returns its height. Its NewBlock event and the
+// Tx events of its txs are published to the websocket subscribers.
+func (c *Chain) CommitBlock(b Block) int64 {
+	if b.Time.IsZero() {
+		b.Time = time.Now()
+	}
+	c.commitMu.Lock()
+	defer c.commitMu.Unlock()
+
+	c.mu.Lock()
+	broadcast := c.mempool
+	c.mempool = nil
+	c.mu.Unlock()
+
+	txs := make([]tmtypes.Tx, 0, len(broadcast)+len(b.Txs))
+	results := make([]*abcitypes.ResponseDeliverTx, 0, cap(txs))
+	for _, tx := range broadcast {
+		res := c.deliverTx(tx)
+		txs = append(txs, tx)
+		results = append(results, &res)
+	}
+	for _, tx := range b.Txs {
+		bz, err := tx.Bytes()
+		if err != nil {
+			// Txs that cannot be encoded are reported and left out.
+			c.t.Errorf("fakechain %s: %v", c.opts.ChainID, err)
+			continue
+		}
+		res := tx.Result
+		txs = append(txs, bz)
+		results = append(results, &res)
+	}
+
+	c.mu.Lock()
+	height := int64(len(c.blocks)) + 1
+	header := tmtypes.Header{ChainID: c.opts.ChainID, Height: height, Time: b.Time.UTC().Round(0)}
+	lastCommit := &tmtypes.Commit{}
+	if height > 1 {
+		last := c.blocks[height-2]
+		header.LastBlockID = last.id
+		lastCommit = &tmtypes.Commit{Height: height - 1, BlockID: last.id}
+	}
+	tmBlock := &tmtypes.Block{Header: header, Data: tmtypes.Data{Txs: txs}, LastCommit: lastCommit}
+	committed := &block{
+		block: tmBlock,
+		id:    tmtypes.BlockID{Hash: tmBlock.Hash()},
+		results: &coretypes.ResultBlockResults{
+			Height:           height,
+			TxsResults:       results,
+			BeginBlockEvents: b.BeginBlockEvents,
+			EndBlockEvents:   b.EndBlockEvents,
+		},
+	}
+	c.blocks = append(c.blocks, committed)
+	txResults := make([]*abcitypes.TxResult, len(txs))
+	for i, tx := range txs {
+		txResults[i] = &abcitypes.TxResult{Height: height, Index: uint32(i), Tx: tx, Result: *results[i]}
+		c.txs[string(tx.Hash())] = txResults[i]
+	}
+	close(c.committed)
+	c.committed = make(chan struct{})
+	c.mu.Unlock()
+
+	c.publish(committed, txResults)
+	return height
+}
+
+func (c *Chain) publish(b *block, txs []*abcitypes.TxResult) {
+	err := c.eventBus.PublishEventNewBlock(tmtypes.EventDataNewBlock{
+		Block:            b.block,
+		ResultBeginBlock: abcitypes.ResponseBeginBlock{Events: b.results.BeginBlockEvents},
+		ResultEndBlock:   abcitypes.ResponseEndBlock{Events: b.results.EndBlockEvents},
+	})
+	for _, tx := range txs {
+		if err != nil {
+			break
+		}
+		err = c.eventBus.PublishEventTx(tmtypes.EventDataTx{TxResult: *tx})
+	}
+	if err != nil {
+		c.t.Errorf("fakechain %s: publish block %d: %v", c.opts.ChainID, b.block.Height, err)
+	}
+}
+
+// Tx is a transaction to commit with its execution result.
+type Tx struct {
+	Msgs []sdk.Msg
+	Memo string
+	// Result is returned as is for the tx: its code, log, events and gas.
+	Result abcitypes.ResponseDeliverTx
+}
+
+// Bytes encodes the unsigned tx. Txs of the same messages and memo share their bytes and hash.
+func (tx Tx) Bytes() ([]byte, error) {
+	txConfig := cosmos.DefaultEncoding().TxConfig
+	builder := txConfig.NewTxBuilder()
+	if err := builder.SetMsgs(tx.Msgs...); err != nil {
+		return nil, fmt.Errorf("encode tx: %w", err)
+	}
+	builder.SetMemo(tx.Memo)
+	bz, err := txConfig.TxEncoder()(builder.GetTx())
+	if err != nil {
+		return nil, fmt.Errorf("encode tx: %w", err)
+	}
+	return bz, nil
+}
+
+// Hash returns the hash of the tx in the upper-case hex of TxResponse.TxHash.
+func (tx Tx) Hash() (string, error) {
+	bz, err := tx.Bytes()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%X", tmtypes.Tx(bz).Hash()), nil
+}
@@ -0,0 +1,240 @@
+package fakechain
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	sdkmath "cosmossdk.io/math"
+	abcitypes "github.com/cometbft/cometbft/abci/types"
+	tmtypes "github.com/cometbft/cometbft/types"
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	"github.com/cosmos/cosmos-sdk/crypto/keyring"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	transfertypes "github.com/cosmos/ibc-go/v7/modules/apps/transfer/types"
+	chanTypes "github.com/cosmos/ibc-go/v7/modules/core/04-channel/types"
+	"github.com/cosmos/ibc-go/v7/modules/core/exported"
+	"github.com/decentrio/e2e-testing-live/cosmos"
+	"github.com/decentrio/e2e-testing-live/testutil"
+	"github.com/decentrio/rollup-e2e-testing/ibc"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func init() {
+	cosmos.TxPollInterval = 5 * time.Millisecond
+	cosmos.HeightPollInterval = 5 * time.Millisecond
+}
+
+func TestQueries(t *testing.T) {
+	t.Cleanup(cosmos.CloseGRPCConns)
+	ctx := context.Background()
+	fake := New(t, Options{})
+	chain := fake.CosmosChain()
+
+	height, err := chain.Height(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), height)
+
+	at := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+	require.Equal(t, int64(2), fake.CommitBlock(Block{Time: at}))
+	blockTime, err := chain.BlockTime(ctx, 2)
+	require.NoError(t, err)
+	require.True(t, at.Equal(blockTime))
+	_, err = chain.BlockTime(ctx, 3)
+	require.ErrorContains(t, err, "must be less than or equal to the current blockchain height 2")
+
+	user := cosmos.User{Address: "dym1alice"}
+	fake.SetBalance(user.Address, sdk.NewInt64Coin("adym", 42))
+	balance, err := user.GetBalance(ctx, "adym", chain)
+	require.NoError(t, err)
+	require.Equal(t, int64(42), balance.Int64())
+	balance, err = user.GetBalance(ctx, "arolx", chain)
+	require.NoError(t, err)
+	require.True(t, balance.IsZero())
+}
+
+func TestFindTransferChannel(t *testing.T) {
+	t.Cleanup(cosmos.CloseGRPCConns)
+	t.Cleanup(cosmos.ResetChannelCache)
+	hub := New(t, Options{ChainID: "hub_1-1"})
+	rollapp := New(t, Options{ChainID: "rolx_1-1", Bech32Prefix: "rolx"})
+
+	hub.AddChannel(Channel{ChannelID: "channel-0", CounterpartyChannelID: "channel-0", CounterpartyChainID: "rolx_1-1", ClientStatus: exported.Expired})
+	want := hub.AddChannel(Channel{ChannelID: "channel-3", CounterpartyChannelID: "channel-1", CounterpartyChainID: "rolx_1-1"})
+	hub.AddChannel(Channel{ChannelID: "channel-4", CounterpartyChannelID: "channel-2", CounterpartyChainID: "roly_1-1"})
+	rollapp.AddChannel(Channel{ChannelID: "channel-1", CounterpartyChannelID: "channel-3", CounterpartyChainID: "hub_1-1"})
+
+	pair, err := cosmos.FindTransferChannel(context.Background(), hub.CosmosChain(), rollapp.CosmosChain())
+	require.NoError(t, err)
+	require.Equal(t, want, pair)
+	require.Equal(t, "connection-1", pair.ConnectionID)
+	require.Equal(t, "07-tendermint-1", pair.ClientID)
+}
+
+func TestFindTransferChannelClientStatusError(t *testing.T) {
+	t.Cleanup(cosmos.CloseGRPCConns)
+	t.Cleanup(cosmos.ResetChannelCache)
+	hub := New(t, Options{ChainID: "hub_1-1"})
+	rollapp := New(t, Options{ChainID: "rolx_1-1", Bech32Prefix: "rolx"})
+	statusErr := status.Error(codes.Unavailable, "client store unavailable")
+
+	hub.AddChannel(Channel{ChannelID: "channel-0", CounterpartyChannelID: "channel-0", CounterpartyChainID: "rolx_1-1", ClientStatus: exported.Expired})
+	hub.AddChannel(Channel{ChannelID: "channel-1", CounterpartyChannelID: "channel-1", CounterpartyChainID: "rolx_1-1", ClientStatusErr: statusErr})
+	rollapp.AddChannel(Channel{ChannelID: "channel-1", CounterpartyChannelID: "channel-1", CounterpartyChainID: "hub_1-1"})
+
+	// The failed query is reported rather than taken for an inactive client.
+	_, err := cosmos.FindTransferChannel(context.Background(), hub.CosmosChain(), rollapp.CosmosChain())
+	require.ErrorContains(t, err, "no usable transfer channel from hub_1-1 to rolx_1-1")
+	require.ErrorContains(t, err, "channel-1: query client status 07-tendermint-1")
+	require.Equal(t, codes.Unavailable, status.Code(err), err.Error())
+
+	// It does not hide an active channel.
+	want := hub.AddChannel(Channel{ChannelID: "channel-2", CounterpartyChannelID: "channel-2", CounterpartyChainID: "rolx_1-1"})
+	rollapp.AddChannel(Channel{ChannelID: "channel-2", CounterpartyChannelID: "channel-2", CounterpartyChainID: "hub_1-1"})
+	pair, err := cosmos.FindTransferChannel(context.Background(), hub.CosmosChain(), rollapp.CosmosChain())
+	require.NoError(t, err)
+	require.Equal(t, want, pair)
+}
+
+func TestBroadcastTx(t *testing.T) {
+	t.Cleanup(cosmos.CloseGRPCConns)
+	ctx := context.Background()
+	fake := New(t, Options{BlockTime: 10 * time.Millisecond})
+	chain := fake.CosmosChain()
+	chain.Keyring = cosmos.NewInMemoryKeyring()
+	record, _, err := chain.Keyring.NewMnemonic("alice", keyring.English, sdk.FullFundraiserPath, "", hd.Secp256k1)
+	require.NoError(t, err)
+	addr, err := record.GetAddress()
+	require.NoError(t, err)
+	alice := sdk.MustBech32ifyAddressBytes("dym", addr)
+	const bob = "dym1qqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqnrql8a"
+	fake.SetBalance(alice, sdk.NewInt64Coin("adym", 100))
+
+	res, err := chain.BankSend(ctx, "alice", ibc.WalletData{Address: bob, Denom: "adym", Amount: sdkmath.NewInt(30)}, cosmos.TxOptions{})
+	require.NoError(t, err)
+	amount, ok := cosmos.AttributeValue(res.Events, "transfer", "amount")
+	require.True(t, ok)
+	require.Equal(t, "30adym", amount)
+	require.Equal(t, sdk.NewCoins(sdk.NewInt64Coin("adym", 70)), fake.Balances(alice))
+	require.Equal(t, sdk.NewCoins(sdk.NewInt64Coin("adym", 30)), fake.Balances(bob))
+
+	_, err = chain.BankSend(ctx, "alice", ibc.WalletData{Address: bob, Denom: "adym", Amount: sdkmath.NewInt(71)}, cosmos.TxOptions{})
+	require.ErrorIs(t, err, sdkerrors.ErrInsufficientFunds)
+}
+
+func TestSubscribeNewBlocks(t *testing.T) {
+	ctx := context.Background()
+	fake := New(t, Options{})
+	chain := fake.CosmosChain()
+	require.NoError(t, chain.Client.Start())
+	t.Cleanup(func() { _ = chain.Client.Stop() })
+
+	events, err := chain.Client.Subscribe(ctx, "test", "tm.event='NewBlock' AND eibc.id='3'")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return fake.Subscriptions() == 1 }, 5*time.Second, time.Millisecond)
+	fake.Commit()
+	fake.CommitBlock(Block{EndBlockEvents: []abcitypes.Event{{Type: "eibc", Attributes: []abcitypes.EventAttribute{{Key: "id", Value: "3", Index: true}}}}})
+
+	select {
+	case ev := <-events:
+		require.Equal(t, int64(3), ev.Data.(tmtypes.EventDataNewBlock).Block.Height)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no NewBlock event")
+	}
+}
+
+func TestPollForAck(t *testing.T) {
+	t.Cleanup(cosmos.CloseGRPCConns)
+	ctx := context.Background()
+	hub := New(t, Options{ChainID: "hub_1-1"})
+	rollapp := New(t, Options{ChainID: "rolx_1-1"})
+	hub.AddChannel(Channel{ChannelID: "channel-0", CounterpartyChannelID: "channel-5", CounterpartyChainID: "rolx_1-1"})
+	packet := ibc.Packet{
+		Sequence:         7,
+		SourcePort:       transfertypes.PortID,
+		SourceChannel:    "channel-0",
+		DestPort:         transfertypes.PortID,
+		DestChannel:      "channel-5",
+		Data:             []byte(`{"amount":"5","denom":"adym","receiver":"rolx1bob","sender":"dym1alice"}`),
+		TimeoutHeight:    "0-0",
+		TimeoutTimestamp: ibc.Nanoseconds(1e18),
+	}
+	ack := chanTypes.NewResultAcknowledgement([]byte{1}).Acknowledgement()
+
+	transfer := hub.SendPacketTx(packet)
+	hub.Commit(transfer)
+	hash, err := transfer.Hash()
+	require.NoError(t, err)
+	txResp, err := cosmos.WaitForTx(ctx, hub.CosmosChain(), hash, time.Second)
+	require.NoError(t, err)
+	sent, err := cosmos.GetIbcTxFromTxResponse(*txResp)
+	require.NoError(t, err)
+	require.Equal(t, packet, sent.Packet)
+
+	rollapp.Commit(rollapp.RecvPacketTx("rolx1relayer", packet, ack))
+	written, err := rollapp.CosmosChain().WriteAcknowledgements(ctx, 2)
+	require.NoError(t, err)
+	require.Len(t, written, 1)
+	require.Equal(t, ack, written[0].Acknowledgement)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		hub.Commit()
+		hub.Commit(hub.AcknowledgePacketTx("dym1relayer", packet, ack))
+	}()
+	found, err := testutil.PollForAck(ctx, hub.CosmosChain(), cosmos.DefaultEncoding().InterfaceRegistry, 3, 10, packet)
+	<-done
+	require.NoError(t, err)
+	require.Equal(t, ack, found.Acknowledgement)
+	require.True(t, found.Packet.Equal(packet))
+}
+
+func TestWaitForBlocks(t *testing.T) {
+	fake := New(t, Options{BlockTime: 5 * time.Millisecond})
+	start := fake.Height()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, testutil.WaitForBlocks(ctx, 3, fake.CosmosChain()))
+	require.GreaterOrEqual(t, fake.Height(), start+3)
+}
+
+func TestWaitForBlocksMultiChain(t *testing.T) {
+	hub := New(t, Options{ChainID: "hub_1-1", BlockTime: 5 * time.Millisecond})
+	rollapp := New(t, Options{ChainID: "rolx_1-1", BlockTime: 20 * time.Millisecond})
+	start := rollapp.Height()
+	require.NoError(t, testutil.WaitForBlocksWithin(context.Background(), 5*time.Second, 3, hub.CosmosChain(), rollapp.CosmosChain()))
+	require.GreaterOrEqual(t, rollapp.Height(), start+3)
+}
+
+func TestWatchHeightShared(t *testing.T) {
+	fake := New(t, Options{})
+	chain := fake.CosmosChain()
+	w, release := chain.WatchHeight()
+	again, releaseAgain := fake.CosmosChain().WatchHeight()
+	copied := chain
+	same, releaseSame := copied.WatchHeight()
+	require.NotSame(t, w, again, "each CosmosChain call dials its own client")
+	require.Same(t, w, same)
+	require.Eventually(t, func() bool { return fake.Subscriptions() == 2 }, 5*time.Second, time.Millisecond)
+	releaseAgain()
+
+	for i := 0; i < 3; i++ {
+		fake.Commit()
+	}
+	ctx := context.Background()
+	h, err := w.WaitForHeight(ctx, 4, 5*time.Second)
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), h)
+
+	release()
+	h, err = same.WaitForHeight(ctx, 4, 0)
+	require.NoError(t, err, "still used")
+	require.Equal(t, uint64(4), h)
+	releaseSame()
+	_, err = same.WaitForHeight(ctx, 5, 0)
+	require.ErrorContains(t, err, "height watcher of chain fake_1-1 stopped")
+}
@@ -0,0 +1,252 @@
+package fakechain
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	transfertypes "github.com/cosmos/ibc-go/v7/modules/apps/transfer/types"
+	clienttypes "github.com/cosmos/ibc-go/v7/modules/core/02-client/types"
+	conntypes "github.com/cosmos/ibc-go/v7/modules/core/03-connection/types"
+	chanTypes "github.com/cosmos/ibc-go/v7/modules/core/04-channel/types"
+	"github.com/cosmos/ibc-go/v7/modules/core/exported"
+	ibctm "github.com/cosmos/ibc-go/v7/modules/light-clients/07-tendermint"
+	"github.com/decentrio/e2e-testing-live/cosmos"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Channel is an IBC channel end of the chain, with the connection and client
+// it runs over.
+type Channel struct {
+	// PortID and CounterpartyPortID default to transfer.
+	PortID                string
+	ChannelID             string
+	CounterpartyPortID    string
+	CounterpartyChannelID string
+	// CounterpartyChainID is the chain tracked by the channel's client.
+	CounterpartyChainID string
+	// State defaults to OPEN.
+	State chanTypes.State
+	// ClientStatus defaults to Active.
+	ClientStatus exported.Status
+	// ClientStatusErr, if set, fails the client status queries with it.
+	ClientStatusErr error
+}
+
+type channel struct {
+	Channel
+	connectionID string
+	clientID     string
+}
+
+// AddChannel adds ch to the chain, over a connection and a client of its
+// own, and returns it as seen by FindTransferChannel.
+func (c *Chain) AddChannel(ch Channel) cosmos.ChannelPair {
+	if ch.PortID == "" {
+		ch.PortID = transfertypes.PortID
+	}
+	if ch.CounterpartyPortID == "" {
+		ch.CounterpartyPortID = transfertypes.PortID
+	}
+	if ch.State == chanTypes.UNINITIALIZED {
+		ch.State = chanTypes.OPEN
+	}
+	if ch.ClientStatus == "" {
+		ch.ClientStatus = exported.Active
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := len(c.channels)
+	added := &channel{
+		Channel:      ch,
+		connectionID: conntypes.FormatConnectionIdentifier(uint64(n)),
+		clientID:     clienttypes.FormatClientIdentifier(exported.Tendermint, uint64(n)),
+	}
+	c.channels = append(c.channels, added)
+	return cosmos.ChannelPair{
+		PortID:                ch.PortID,
+		ChannelID:             ch.ChannelID,
+		ConnectionID:          added.connectionID,
+		ClientID:              added.clientID,
+		CounterpartyPortID:    ch.CounterpartyPortID,
+		CounterpartyChannelID: ch.CounterpartyChannelID,
+	}
+}
+
+func (c *Chain) findChannel(match func(*channel) bool) (channel, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, ch := range c.channels {
+		if match(ch) {
+			return *ch, true
+		}
+	}
+	return channel{}, false
+}
+
+func (ch channel) identified() *chanTypes.IdentifiedChannel {
+	identified := chanTypes.NewIdentifiedChannel(ch.PortID, ch.ChannelID, chanTypes.NewChannel(
+		ch.State, chanTypes.UNORDERED,
+		chanTypes.NewCounterparty(ch.CounterpartyPortID, ch.CounterpartyChannelID),
+		[]string{ch.connectionID}, transfertypes.Version,
+	))
+	return &identified
+}
+
+func (c *Chain) serveGRPC(t testing.TB) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	server := grpc.NewServer()
+	banktypes.RegisterQueryServer(server, &bankServer{c: c})
+	authtypes.RegisterQueryServer(server, &authServer{c: c})
+	txtypes.RegisterServiceServer(server, &txServer{})
+	chanTypes.RegisterQueryServer(server, &channelServer{c: c})
+	conntypes.RegisterQueryServer(server, &connectionServer{c: c})
+	clienttypes.RegisterQueryServer(server, &clientServer{c: c})
+	if c.opts.Services != nil {
+		c.opts.Services(server)
+	}
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(server.Stop)
+	c.grpcAddr = lis.Addr().String()
+}
+
+type bankServer struct {
+	banktypes.UnimplementedQueryServer
+	c *Chain
+}
+
+func (s bankServer) Balance(_ context.Context, req *banktypes.QueryBalanceRequest) (*banktypes.QueryBalanceResponse, error) {
+	coin := sdk.NewCoin(req.Denom, s.c.Balances(req.Address).AmountOf(req.Denom))
+	return &banktypes.QueryBalanceResponse{Balance: &coin}, nil
+}
+
+func (s bankServer) AllBalances(_ context.Context, req *banktypes.QueryAllBalancesRequest) (*banktypes.QueryAllBalancesResponse, error) {
+	return &banktypes.QueryAllBalancesResponse{Balances: s.c.Balances(req.Address)}, nil
+}
+
+type authServer struct {
+	authtypes.UnimplementedQueryServer
+	c *Chain
+}
+
+func (s authServer) Account(_ context.Context, req *authtypes.QueryAccountRequest) (*authtypes.QueryAccountResponse, error) {
+	acc, err := s.c.account(req.Address)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	any, err := codectypes.NewAnyWithValue(&authtypes.BaseAccount{
+		Address:       req.Address,
+		AccountNumber: acc.number,
+		Sequence:      acc.sequence,
+	})
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &authtypes.QueryAccountResponse{Account: any}, nil
+}
+
+func (s authServer) Bech32Prefix(context.Context, *authtypes.Bech32PrefixRequest) (*authtypes.Bech32PrefixResponse, error) {
+	return &authtypes.Bech32PrefixResponse{Bech32Prefix: s.c.opts.Bech32Prefix}, nil
+}
+
+// txServer simulates every tx to SimulatedGas, so that BroadcastTx can
+// estimate its gas.
+type txServer struct {
+	txtypes.UnimplementedServiceServer
+}
+
+func (txServer) Simulate(context.Context, *txtypes.SimulateRequest) (*txtypes.SimulateResponse, error) {
+	return &txtypes.SimulateResponse{
+		GasInfo: &sdk.GasInfo{GasWanted: SimulatedGas, GasUsed: SimulatedGas},
+		Result:  &sdk.Result{},
+	}, nil
+}
+
+type channelServer struct {
+	chanTypes.UnimplementedQueryServer
+	c *Chain
+}
+
+func (s channelServer) Channels(context.Context, *chanTypes.QueryChannelsRequest) (*chanTypes.QueryChannelsResponse, error) {
+	s.c.mu.Lock()
+	defer s.c.mu.Unlock()
+	res := &chanTypes.QueryChannelsResponse{Height: clienttypes.NewHeight(0, uint64(len(s.c.blocks)))}
+	for _, ch := range s.c.channels {
+		res.Channels = append(res.Channels, ch.identified())
+	}
+	return res, nil
+}
+
+func (s channelServer) Channel(_ context.Context, req *chanTypes.QueryChannelRequest) (*chanTypes.QueryChannelResponse, error) {
+	ch, ok := s.c.findChannel(func(ch *channel) bool {
+		return ch.PortID == req.PortId && ch.ChannelID == req.ChannelId
+	})
+	if !ok {
+		return nil, status.Error(codes.NotFound, fmt.Sprintf("port-id: %s, channel-id: %s: channel not found", req.PortId, req.ChannelId))
+	}
+	identified := ch.identified()
+	return &chanTypes.QueryChannelResponse{Channel: &chanTypes.Channel{
+		State:          identified.State,
+		Ordering:       identified.Ordering,
+		Counterparty:   identified.Counterparty,
+		ConnectionHops: identified.ConnectionHops,
+		Version:        identified.Version,
+	}}, nil
+}
+
+type connectionServer struct {
+	conntypes.UnimplementedQueryServer
+	c *Chain
+}
+
+func (s connectionServer) Connection(_ context.Context, req *conntypes.QueryConnectionRequest) (*conntypes.QueryConnectionResponse, error) {
+	ch, ok := s.c.findChannel(func(ch *channel) bool { return ch.connectionID == req.ConnectionId })
+	if !ok {
+		return nil, status.Error(codes.NotFound, fmt.Sprintf("%s: connection not found", req.ConnectionId))
+	}
+	conn := conntypes.NewConnectionEnd(conntypes.OPEN, ch.clientID, conntypes.Counterparty{}, []*conntypes.Version{conntypes.DefaultIBCVersion}, 0)
+	return &conntypes.QueryConnectionResponse{Connection: &conn}, nil
+}
+
+type clientServer struct {
+	clienttypes.UnimplementedQueryServer
+	c *Chain
+}
+
+func (s clientServer) ClientState(_ context.Context, req *clienttypes.QueryClientStateRequest) (*clienttypes.QueryClientStateResponse, error) {
+	ch, ok := s.c.findChannel(func(ch *channel) bool { return ch.clientID == req.ClientId })
+	if !ok {
+		return nil, status.Error(codes.NotFound, fmt.Sprintf("%s: light client not found", req.ClientId))
+	}
+	clientState := ibctm.NewClientState(ch.CounterpartyChainID, ibctm.DefaultTrustLevel,
+		14*24*time.Hour, 21*24*time.Hour, 10*time.Second, clienttypes.NewHeight(1, 1), nil, nil)
+	any, err := clienttypes.PackClientState(clientState)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &clienttypes.QueryClientStateResponse{ClientState: any}, nil
+}
+
+func (s clientServer) ClientStatus(_ context.Context, req *clienttypes.QueryClientStatusRequest) (*clienttypes.QueryClientStatusResponse, error) {
+	ch, ok := s.c.findChannel(func(ch *channel) bool { return ch.clientID == req.ClientId })
+	if !ok {
+		return nil, status.Error(codes.NotFound, fmt.Sprintf("%s: light client not found", req.ClientId))
+	}
+	if ch.ClientStatusErr != nil {
+		return nil, ch.ClientStatusErr
+	}
+	return &clienttypes.QueryClientStatusResponse{Status: ch.ClientStatus.String()}, nil
+}
@@ -0,0 +1,99 @@
+package fakechain
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	abcitypes "github.com/cometbft/cometbft/abci/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	clienttypes "github.com/cosmos/ibc-go/v7/modules/core/02-client/types"
+	chanTypes "github.com/cosmos/ibc-go/v7/modules/core/04-channel/types"
+	"github.com/decentrio/rollup-e2e-testing/ibc"
+)
+
+// SendPacketTx is a tx of msgs, e.g. an ICS-20 transfer, sending packet over
+// one of the chain's channels.
+func (c *Chain) SendPacketTx(packet ibc.Packet, msgs ...sdk.Msg) Tx {
+	return Tx{
+		Msgs:   msgs,
+		Result: abcitypes.ResponseDeliverTx{Events: []abcitypes.Event{c.packetEvent(chanTypes.EventTypeSendPacket, packet, true)}},
+	}
+}
+
+// RecvPacketTx is the relayer's tx delivering packet to the chain, which
+// writes ack for it.
+func (c *Chain) RecvPacketTx(relayer string, packet ibc.Packet, ack []byte) Tx {
+	writeAck := c.packetEvent(chanTypes.EventTypeWriteAck, packet, false)
+	writeAck.Attributes = append(writeAck.Attributes,
+		abcitypes.EventAttribute{Key: chanTypes.AttributeKeyAck, Value: string(ack)},
+		abcitypes.EventAttribute{Key: chanTypes.AttributeKeyAckHex, Value: hex.EncodeToString(ack)},
+	)
+	return Tx{
+		Msgs: []sdk.Msg{chanTypes.NewMsgRecvPacket(chanPacket(packet), []byte("proof"), clienttypes.ZeroHeight(), relayer)},
+		Result: abcitypes.ResponseDeliverTx{Events: []abcitypes.Event{
+			c.packetEvent(chanTypes.EventTypeRecvPacket, packet, true),
+			writeAck,
+		}},
+	}
+}
+
+// AcknowledgePacketTx is the relayer's tx delivering ack of packet back to
+// the chain that sent it.
+func (c *Chain) AcknowledgePacketTx(relayer string, packet ibc.Packet, ack []byte) Tx {
+	return Tx{
+		Msgs: []sdk.Msg{chanTypes.NewMsgAcknowledgement(chanPacket(packet), ack, []byte("proof"), clienttypes.ZeroHeight(), relayer)},
+		Result: abcitypes.ResponseDeliverTx{Events: []abcitypes.Event{
+			c.packetEvent(chanTypes.EventTypeAcknowledgePacket, packet, false),
+		}},
+	}
+}
+
+// TimeoutPacketTx is the relayer's tx timing out packet on the chain that sent it.
+func (c *Chain) TimeoutPacketTx(relayer string, packet ibc.Packet) Tx {
+	return Tx{
+		Msgs: []sdk.Msg{chanTypes.NewMsgTimeout(chanPacket(packet), packet.Sequence, []byte("proof"), clienttypes.ZeroHeight(), relayer)},
+		Result: abcitypes.ResponseDeliverTx{Events: []abcitypes.Event{
+			c.packetEvent(chanTypes.EventTypeTimeoutPacket, packet, false),
+		}},
+	}
+}
+
+// packetEvent builds a channel module event of eventType for packet, with
+// its data when withData is set. The connection is the one of the chain's
+// end of the channel, if the chain has it.
+func (c *Chain) packetEvent(eventType string, packet ibc.Packet, withData bool) abcitypes.Event {
+	var connectionID string
+	if ch, ok := c.findChannel(func(ch *channel) bool {
+		return (ch.PortID == packet.SourcePort && ch.ChannelID == packet.SourceChannel) ||
+			(ch.PortID == packet.DestPort && ch.ChannelID == packet.DestChannel)
+	}); ok {
+		connectionID = ch.connectionID
+	}
+	attrs := []abcitypes.EventAttribute{
+		{Key: chanTypes.AttributeKeyTimeoutHeight, Value: chanPacket(packet).TimeoutHeight.String()},
+		{Key: chanTypes.AttributeKeyTimeoutTimestamp, Value: fmt.Sprint(uint64(packet.TimeoutTimestamp))},
+		{Key: chanTypes.AttributeKeySequence, Value: fmt.Sprint(packet.Sequence)},
+		{Key: chanTypes.AttributeKeySrcPort, Value: packet.SourcePort},
+		{Key: chanTypes.AttributeKeySrcChannel, Value: packet.SourceChannel},
+		{Key: chanTypes.AttributeKeyDstPort, Value: packet.DestPort},
+		{Key: chanTypes.AttributeKeyDstChannel, Value: packet.DestChannel},
+		{Key: chanTypes.AttributeKeyChannelOrdering, Value: chanTypes.UNORDERED.String()},
+		{Key: chanTypes.AttributeKeyConnection, Value: connectionID},
+	}
+	if withData {
+		attrs = append([]abcitypes.EventAttribute{
+			{Key: chanTypes.AttributeKeyData, Value: string(packet.Data)},
+			{Key: chanTypes.AttributeKeyDataHex, Value: hex.EncodeToString(packet.Data)},
+		}, attrs...)
+	}
+	return abcitypes.Event{Type: eventType, Attributes: attrs}
+}
+
+// chanPacket converts packet to its ibc-go form. An unparsable timeout
+// height is left zero.
+func chanPacket(packet ibc.Packet) chanTypes.Packet {
+	timeoutHeight, _ := clienttypes.ParseHeight(packet.TimeoutHeight)
+	return chanTypes.NewPacket(packet.Data, packet.Sequence,
+		packet.SourcePort, packet.SourceChannel, packet.DestPort, packet.DestChannel,
+		timeoutHeight, uint64(packet.TimeoutTimestamp))
+}
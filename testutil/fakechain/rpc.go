@@ -0,0 +1,245 @@
+package fakechain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	abcitypes "github.com/cometbft/cometbft/abci/types"
+	"github.com/cometbft/cometbft/libs/log"
+	cmtpubsub "github.com/cometbft/cometbft/libs/pubsub"
+	cmtquery "github.com/cometbft/cometbft/libs/pubsub/query"
+	"github.com/cometbft/cometbft/p2p"
+	coretypes "github.com/cometbft/cometbft/rpc/core/types"
+	rpcserver "github.com/cometbft/cometbft/rpc/jsonrpc/server"
+	rpctypes "github.com/cometbft/cometbft/rpc/jsonrpc/types"
+	tmtypes "github.com/cometbft/cometbft/types"
+)
+
+// subscriptionBuffer is the number of events buffered per websocket
+// subscription before the subscriber counts as slow and is dropped.
+const subscriptionBuffer = 100
+
+// serveRPC serves the CometBFT RPC routes of the chain over HTTP, with the
+// same JSON encoding as a node, and its subscriptions over /websocket.
+func (c *Chain) serveRPC(t testing.TB) {
+	routes := map[string]*rpcserver.RPCFunc{
+		"subscribe":       rpcserver.NewWSRPCFunc(c.subscribe, "query"),
+		"unsubscribe":     rpcserver.NewWSRPCFunc(c.unsubscribe, "query"),
+		"unsubscribe_all": rpcserver.NewWSRPCFunc(c.unsubscribeAll, ""),
+
+		"status":        rpcserver.NewRPCFunc(c.status, ""),
+		"block":         rpcserver.NewRPCFunc(c.block, "height"),
+		"block_results": rpcserver.NewRPCFunc(c.blockResults, "height"),
+		"tx":            rpcserver.NewRPCFunc(c.tx, "hash,prove"),
+
+		"broadcast_tx_async":  rpcserver.NewRPCFunc(c.broadcastTxAsync, "tx"),
+		"broadcast_tx_sync":   rpcserver.NewRPCFunc(c.broadcastTxSync, "tx"),
+		"broadcast_tx_commit": rpcserver.NewRPCFunc(c.broadcastTxCommit, "tx"),
+	}
+	mux := http.NewServeMux()
+	rpcserver.RegisterRPCFuncs(mux, routes, log.NewNopLogger())
+	wm := rpcserver.NewWebsocketManager(routes, rpcserver.OnDisconnect(func(remoteAddr string) {
+		_ = c.eventBus.UnsubscribeAll(context.Background(), remoteAddr)
+	}))
+	mux.HandleFunc("/websocket", wm.WebsocketHandler)
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	c.rpcAddr = server.URL
+}
+
+func (c *Chain) status(*rpctypes.Context) (*coretypes.ResultStatus, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	latest := c.blocks[len(c.blocks)-1]
+	return &coretypes.ResultStatus{
+		NodeInfo: p2p.DefaultNodeInfo{Network: c.opts.ChainID, Moniker: "fakechain"},
+		SyncInfo: coretypes.SyncInfo{
+			LatestBlockHash:     latest.id.Hash,
+			LatestBlockHeight:   latest.block.Height,
+			LatestBlockTime:     latest.block.Time,
+			EarliestBlockHash:   c.blocks[0].id.Hash,
+			EarliestBlockHeight: 1,
+			EarliestBlockTime:   c.blocks[0].block.Time,
+		},
+	}, nil
+}
+
+func (c *Chain) block(_ *rpctypes.Context, height *int64) (*coretypes.ResultBlock, error) {
+	b, err := c.blockAt(height)
+	if err != nil {
+		return nil, err
+	}
+	return &coretypes.ResultBlock{BlockID: b.id, Block: b.block}, nil
+}
+
+func (c *Chain) blockResults(_ *rpctypes.Context, height *int64) (*coretypes.ResultBlockResults, error) {
+	b, err := c.blockAt(height)
+	if err != nil {
+		return nil, err
+	}
+	return b.results, nil
+}
+
+// blockAt returns the block at height, the latest one if nil, failing like
+// a node does for heights it does not have.
+func (c *Chain) blockAt(height *int64) (*block, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	latest := int64(len(c.blocks))
+	if height == nil {
+		return c.blocks[latest-1], nil
+	}
+	switch h := *height; {
+	case h <= 0:
+		return nil, fmt.Errorf("height must be greater than 0, but got %d", h)
+	case h > latest:
+		return nil, fmt.Errorf("height %d must be less than or equal to the current blockchain height %d", h, latest)
+	default:
+		return c.blocks[h-1], nil
+	}
+}
+
+func (c *Chain) tx(_ *rpctypes.Context, hash []byte, _ bool) (*coretypes.ResultTx, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r, ok := c.txs[string(hash)]
+	if !ok {
+		return nil, fmt.Errorf("tx (%X) not found", hash)
+	}
+	return &coretypes.ResultTx{
+		Hash:     hash,
+		Height:   r.Height,
+		Index:    r.Index,
+		TxResult: r.Result,
+		Tx:       r.Tx,
+	}, nil
+}
+
+// checkTx adds tx to the mempool unless Options.CheckTx rejects it.
+func (c *Chain) checkTx(tx tmtypes.Tx) abcitypes.ResponseCheckTx {
+	var res abcitypes.ResponseCheckTx
+	if c.opts.CheckTx != nil {
+		res = c.opts.CheckTx(tx)
+	}
+	if res.IsOK() {
+		c.mu.Lock()
+		c.mempool = append(c.mempool, tx)
+		c.mu.Unlock()
+	}
+	return res
+}
+
+func (c *Chain) broadcastTxAsync(_ *rpctypes.Context, tx tmtypes.Tx) (*coretypes.ResultBroadcastTx, error) {
+	c.checkTx(tx)
+	return &coretypes.ResultBroadcastTx{Hash: tx.Hash()}, nil
+}
+
+func (c *Chain) broadcastTxSync(_ *rpctypes.Context, tx tmtypes.Tx) (*coretypes.ResultBroadcastTx, error) {
+	res := c.checkTx(tx)
+	return &coretypes.ResultBroadcastTx{
+		Code:      res.Code,
+		Data:      res.Data,
+		Log:       res.Log,
+		Codespace: res.Codespace,
+		Hash:      tx.Hash(),
+	}, nil
+}
+
+// broadcastTxCommit returns once tx is committed: by the next block when
+// Options.BlockTime is set, by a block committed right away otherwise.
+func (c *Chain) broadcastTxCommit(ctx *rpctypes.Context, tx tmtypes.Tx) (*coretypes.ResultBroadcastTxCommit, error) {
+	checkRes := c.checkTx(tx)
+	res := &coretypes.ResultBroadcastTxCommit{CheckTx: checkRes, Hash: tx.Hash()}
+	if !checkRes.IsOK() {
+		return res, nil
+	}
+	if c.opts.BlockTime <= 0 {
+		c.Commit()
+	}
+	for {
+		c.mu.Lock()
+		r, ok := c.txs[string(tx.Hash())]
+		committed := c.committed
+		c.mu.Unlock()
+		if ok {
+			res.DeliverTx, res.Height = r.Result, r.Height
+			return res, nil
+		}
+		select {
+		case <-committed:
+		case <-ctx.Context().Done():
+			return nil, fmt.Errorf("timed out waiting for tx to be included in a block: %w", ctx.Context().Err())
+		}
+	}
+}
+
+// subscribe forwards the events matching query to the websocket, like the
+// subscribe route of a node.
+func (c *Chain) subscribe(ctx *rpctypes.Context, query string) (*coretypes.ResultSubscribe, error) {
+	q, err := cmtquery.New(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse query: %w", err)
+	}
+	sub, err := c.eventBus.Subscribe(ctx.Context(), ctx.RemoteAddr(), q, subscriptionBuffer)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.subscriptions++
+	c.mu.Unlock()
+	subscriptionID := ctx.JSONReq.ID
+	go func() {
+		for {
+			select {
+			case msg := <-sub.Out():
+				resp := rpctypes.NewRPCSuccessResponse(subscriptionID, &coretypes.ResultEvent{
+					Query:  query,
+					Data:   msg.Data(),
+					Events: msg.Events(),
+				})
+				if err := ctx.WSConn.WriteRPCResponse(context.Background(), resp); err != nil {
+					return
+				}
+			case <-sub.Cancelled():
+				if !errors.Is(sub.Err(), cmtpubsub.ErrUnsubscribed) {
+					ctx.WSConn.TryWriteRPCResponse(rpctypes.RPCServerError(subscriptionID,
+						fmt.Errorf("subscription was canceled (reason: %v)", sub.Err())))
+				}
+				return
+			}
+		}
+	}()
+	return &coretypes.ResultSubscribe{}, nil
+}
+
+// Subscriptions returns the number of subscriptions made so far. The RPC
+// client returns from Subscribe before the node handled the request, so tests
+// wait for the subscription before committing the blocks they expect events of.
+func (c *Chain) Subscriptions() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.subscriptions
+}
+
+func (c *Chain) unsubscribe(ctx *rpctypes.Context, query string) (*coretypes.ResultUnsubscribe, error) {
+	q, err := cmtquery.New(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse query: %w", err)
+	}
+	if err := c.eventBus.Unsubscribe(context.Background(), ctx.RemoteAddr(), q); err != nil {
+		return nil, err
+	}
+	return &coretypes.ResultUnsubscribe{}, nil
+}
+
+func (c *Chain) unsubscribeAll(ctx *rpctypes.Context) (*coretypes.ResultUnsubscribe, error) {
+	if err := c.eventBus.UnsubscribeAll(context.Background(), ctx.RemoteAddr()); err != nil {
+		return nil, err
+	}
+	return &coretypes.ResultUnsubscribe{}, nil
+}
@@ -0,0 +1,128 @@
+package fakechain
+
+import (
+	"fmt"
+
+	errorsmod "cosmossdk.io/errors"
+	abcitypes "github.com/cometbft/cometbft/abci/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/decentrio/e2e-testing-live/cosmos"
+)
+
+// SimulatedGas is the gas used by every tx simulated by the chain.
+const SimulatedGas = 100_000
+
+type account struct {
+	number   uint64
+	sequence uint64
+}
+
+// SetBalance replaces the balances of address with coins, creating its
+// account if needed.
+func (c *Chain) SetBalance(address string, coins ...sdk.Coin) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.balances[address] = sdk.NewCoins(coins...)
+	c.ensureAccount(address)
+}
+
+// Balances returns the balances of address.
+func (c *Chain) Balances(address string) sdk.Coins {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.balances[address]
+}
+
+// SetAccount creates or updates the account of address.
+func (c *Chain) SetAccount(address string, number, sequence uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.accounts[address] = &account{number: number, sequence: sequence}
+}
+
+// ensureAccount gives address the next account number, like the auth module
+// does for the first funds sent to an address.
+func (c *Chain) ensureAccount(address string) {
+	if _, ok := c.accounts[address]; !ok {
+		c.accounts[address] = &account{number: uint64(len(c.accounts))}
+	}
+}
+
+// deliverTx runs the Options.DeliverTx of a broadcast tx, or applies its bank sends.
+func (c *Chain) deliverTx(bz []byte) abcitypes.ResponseDeliverTx {
+	if c.opts.DeliverTx != nil {
+		return c.opts.DeliverTx(bz)
+	}
+	tx, err := cosmos.DefaultEncoding().TxConfig.TxDecoder()(bz)
+	if err != nil {
+		return errorResult(sdkerrors.ErrTxDecode.Wrap(err.Error()))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	balances := make(map[string]sdk.Coins, len(c.balances))
+	for addr, coins := range c.balances {
+		balances[addr] = coins
+	}
+	var logs sdk.ABCIMessageLogs
+	var events []abcitypes.Event
+	for i, msg := range tx.GetMsgs() {
+		send, ok := msg.(*banktypes.MsgSend)
+		if !ok {
+			continue
+		}
+		left, negative := balances[send.FromAddress].SafeSub(send.Amount...)
+		if negative {
+			return errorResult(sdkerrors.ErrInsufficientFunds.Wrapf("%s is smaller than %s", balances[send.FromAddress], send.Amount))
+		}
+		balances[send.FromAddress] = left
+		balances[send.ToAddress] = balances[send.ToAddress].Add(send.Amount...)
+		msgEvents := sdk.Events{
+			sdk.NewEvent(sdk.EventTypeMessage,
+				sdk.NewAttribute(sdk.AttributeKeyAction, sdk.MsgTypeURL(send)),
+				sdk.NewAttribute(sdk.AttributeKeySender, send.FromAddress),
+				sdk.NewAttribute(sdk.AttributeKeyModule, banktypes.ModuleName),
+			),
+			sdk.NewEvent(banktypes.EventTypeTransfer,
+				sdk.NewAttribute(banktypes.AttributeKeyRecipient, send.ToAddress),
+				sdk.NewAttribute(banktypes.AttributeKeySender, send.FromAddress),
+				sdk.NewAttribute(sdk.AttributeKeyAmount, send.Amount.String()),
+			),
+		}
+		logs = append(logs, sdk.NewABCIMessageLog(uint32(i), "", msgEvents))
+		events = append(events, msgEvents.ToABCIEvents()...)
+	}
+	for addr, coins := range balances {
+		c.balances[addr] = coins
+		c.ensureAccount(addr)
+	}
+	return abcitypes.ResponseDeliverTx{
+		Log:       logs.String(),
+		GasWanted: SimulatedGas,
+		GasUsed:   SimulatedGas,
+		Events:    events,
+	}
+}
+
+func errorResult(err error) abcitypes.ResponseDeliverTx {
+	codespace, code, log := errorsmod.ABCIInfo(err, false)
+	return abcitypes.ResponseDeliverTx{
+		Codespace: codespace,
+		Code:      code,
+		Log:       log,
+		GasWanted: SimulatedGas,
+		GasUsed:   SimulatedGas,
+	}
+}
+
+func (c *Chain) account(address string) (*account, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	acc, ok := c.accounts[address]
+	if !ok {
+		return nil, fmt.Errorf("account %s not found", address)
+	}
+	return &account{number: acc.number, sequence: acc.sequence}, nil
+}
@@ -9,8 +9,8 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-func AssertBalance(t *testing.T, ctx context.Context, user cosmos.User, denom, grpc string, expectedBalance sdkmath.Int) {
-	balance, err := user.GetBalance(ctx, denom, grpc)
+func AssertBalance(t *testing.T, ctx context.Context, user cosmos.User, denom string, chain cosmos.CosmosChain, expectedBalance sdkmath.Int) {
+	balance, err := user.GetBalance(ctx, denom, chain)
 	require.NoError(t, err)
 	require.Equal(t, expectedBalance.String(), balance.String())
 }
@@ -0,0 +1,310 @@
+package testutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	"github.com/decentrio/e2e-testing-live/cosmos"
+	"github.com/decentrio/rollup-e2e-testing/ibc"
+)
+
+// DefaultStageBlocks is how many blocks PacketTracker searches for each stage.
+const DefaultStageBlocks = 100
+
+// PacketStage is a step of an IBC packet's lifecycle.
+type PacketStage string
+
+const (
+	StageSend     PacketStage = "send"
+	StageRecv     PacketStage = "recv"
+	StageWriteAck PacketStage = "write_acknowledgement"
+	StageAck      PacketStage = "acknowledge"
+	StageTimeout  PacketStage = "timeout"
+)
+
+// TrackedChain is a chain PacketTracker can follow packets on.
+type TrackedChain interface {
+	ChainAcker
+	ChainTimeouter
+	ChainRecver
+	WriteAcknowledgements(ctx context.Context, height uint64) ([]ibc.PacketAcknowledgement, error)
+	BlockTime(ctx context.Context, height uint64) (time.Time, error)
+}
+
+var _ TrackedChain = cosmos.CosmosChain{}
+
+// StageResult records when a stage happened. Latency is measured from the
+// previous stage using block times.
+type StageResult struct {
+	Stage   PacketStage
+	Height  uint64
+	Time    time.Time
+	Latency time.Duration
+}
+
+// PacketResult is the lifecycle of a tracked packet.
+type PacketResult struct {
+	Packet          ibc.Packet
+	Stages          []StageResult
+	Acknowledgement []byte
+	// AckError is the error carried by an error acknowledgement.
+	AckError string
+	TimedOut bool
+}
+
+// Stage returns the result of stage, if it was reached.
+func (r PacketResult) Stage(stage PacketStage) (StageResult, bool) {
+	for _, s := range r.Stages {
+		if s.Stage == stage {
+			return s, true
+		}
+	}
+	return StageResult{}, false
+}
+
+// Acked reports whether the packet was acknowledged with a successful acknowledgement.
+func (r PacketResult) Acked() bool {
+	_, ok := r.Stage(StageAck)
+	return ok && r.AckError == ""
+}
+
+// SourceBlocks is the number of source chain blocks between the send and the
+// ack or timeout, zero if neither was seen.
+func (r PacketResult) SourceBlocks() uint64 {
+	send, _ := r.Stage(StageSend)
+	for _, final := range []PacketStage{StageAck, StageTimeout} {
+		if s, ok := r.Stage(final); ok {
+			return s.Height - send.Height
+		}
+	}
+	return 0
+}
+
+// Latency is the time from the send to the last stage reached.
+func (r PacketResult) Latency() time.Duration {
+	if len(r.Stages) == 0 {
+		return 0
+	}
+	return r.Stages[len(r.Stages)-1].Time.Sub(r.Stages[0].Time)
+}
+
+// PacketTracker follows a packet sent from Src through its receipt and
+// acknowledgement on Dst, back to the ack or timeout on Src.
+type PacketTracker struct {
+	Src, Dst          TrackedChain
+	InterfaceRegistry codectypes.InterfaceRegistry
+	// StageBlocks bounds the number of blocks searched for each stage.
+	StageBlocks uint64
+
+	dstStart uint64
+}
+
+// NewPacketTracker snapshots the destination height so that a packet relayed
+// before Track is called is still found. Create it before sending the packet.
+func NewPacketTracker(ctx context.Context, src, dst TrackedChain) (*PacketTracker, error) {
+	h, err := dst.Height(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("destination height: %w", err)
+	}
+	return &PacketTracker{
+		Src:               src,
+		Dst:               dst,
+		InterfaceRegistry: cosmos.DefaultEncoding().InterfaceRegistry,
+		StageBlocks:       DefaultStageBlocks,
+		dstStart:          h,
+	}, nil
+}
+
+// Track extracts the packet from sendTx and waits for the rest of its
+// lifecycle. The partial result is returned alongside any error so tests can
+// report how far the packet got.
+func (t *PacketTracker) Track(ctx context.Context, sendTx cosmos.TxResponse) (PacketResult, error) {
+	tx, err := cosmos.GetIbcTxFromTxResponse(sendTx)
+	if err != nil {
+		return PacketResult{}, fmt.Errorf("extract packet from send tx: %w", err)
+	}
+	res := PacketResult{Packet: tx.Packet}
+	sendHeight := uint64(tx.Height)
+	if err := t.record(ctx, &res, StageSend, t.Src, sendHeight); err != nil {
+		return res, err
+	}
+
+	// The packet either arrives on Dst or times out on Src, whichever happens
+	// first. The other search is cancelled as soon as one of them succeeds.
+	raceCtx, cancelRace := context.WithCancel(ctx)
+	defer cancelRace()
+	type raceResult struct {
+		recv    bool
+		height  uint64
+		timeout ibc.PacketTimeout
+		err     error
+	}
+	results := make(chan raceResult, 2)
+	go func() {
+		h, err := t.pollRecv(raceCtx, tx.Packet)
+		results <- raceResult{recv: true, height: h, err: err}
+	}()
+	go func() {
+		h, timeout, err := t.pollTimeout(raceCtx, sendHeight, tx.Packet)
+		results <- raceResult{height: h, timeout: timeout, err: err}
+	}()
+
+	var won *raceResult
+	var errs []error
+	received := 0
+	for won == nil && received < 2 {
+		r := <-results
+		received++
+		if r.err != nil {
+			errs = append(errs, r.err)
+			continue
+		}
+		won = &r
+	}
+	cancelRace()
+	for ; received < 2; received++ {
+		<-results
+	}
+	if won == nil {
+		return res, fmt.Errorf("packet neither received nor timed out: %w", errors.Join(errs...))
+	}
+	if !won.recv {
+		res.TimedOut = true
+		res.Packet = won.timeout.Packet
+		return res, t.record(ctx, &res, StageTimeout, t.Src, won.height)
+	}
+	recvHeight := won.height
+
+	if err := t.record(ctx, &res, StageRecv, t.Dst, recvHeight); err != nil {
+		return res, err
+	}
+
+	writeAckHeight, written, err := t.pollWriteAck(ctx, recvHeight, tx.Packet)
+	if err != nil {
+		return res, fmt.Errorf("write acknowledgement: %w", err)
+	}
+	res.Acknowledgement = written.Acknowledgement
+	if res.AckError, err = cosmos.AcknowledgementError(written.Acknowledgement); err != nil {
+		return res, err
+	}
+	if err := t.record(ctx, &res, StageWriteAck, t.Dst, writeAckHeight); err != nil {
+		return res, err
+	}
+
+	ackHeight, err := t.pollAck(ctx, sendHeight, tx.Packet)
+	if err != nil {
+		return res, fmt.Errorf("acknowledgement: %w", err)
+	}
+	return res, t.record(ctx, &res, StageAck, t.Src, ackHeight)
+}
+
+func (t *PacketTracker) record(ctx context.Context, res *PacketResult, stage PacketStage, chain TrackedChain, height uint64) error {
+	ts, err := chain.BlockTime(ctx, height)
+	if err != nil {
+		return fmt.Errorf("%s block time: %w", stage, err)
+	}
+	s := StageResult{Stage: stage, Height: height, Time: ts}
+	if n := len(res.Stages); n > 0 {
+		s.Latency = ts.Sub(res.Stages[n-1].Time)
+	}
+	res.Stages = append(res.Stages, s)
+	return nil
+}
+
+func (t *PacketTracker) pollRecv(ctx context.Context, packet ibc.Packet) (uint64, error) {
+	return pollHeight(ctx, t.Dst, t.dstStart, t.StageBlocks, func(ctx context.Context, height uint64) (bool, error) {
+		packets, err := t.Dst.RecvPackets(ctx, height)
+		if err != nil {
+			return false, err
+		}
+		for _, p := range packets {
+			if p.Equal(packet) {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+}
+
+func (t *PacketTracker) pollWriteAck(ctx context.Context, recvHeight uint64, packet ibc.Packet) (uint64, ibc.PacketAcknowledgement, error) {
+	var found ibc.PacketAcknowledgement
+	h, err := pollHeight(ctx, t.Dst, recvHeight, t.StageBlocks, func(ctx context.Context, height uint64) (bool, error) {
+		acks, err := t.Dst.WriteAcknowledgements(ctx, height)
+		if err != nil {
+			return false, err
+		}
+		for _, ack := range acks {
+			if ack.Packet.Equal(packet) {
+				found = ack
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	return h, found, err
+}
+
+func (t *PacketTracker) pollAck(ctx context.Context, sendHeight uint64, packet ibc.Packet) (uint64, error) {
+	return pollHeight(ctx, t.Src, sendHeight, t.StageBlocks, func(ctx context.Context, height uint64) (bool, error) {
+		acks, err := t.Src.Acknowledgements(ctx, t.InterfaceRegistry, height)
+		if err != nil {
+			return false, err
+		}
+		for _, ack := range acks {
+			if ack.Packet.Equal(packet) {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+}
+
+func (t *PacketTracker) pollTimeout(ctx context.Context, sendHeight uint64, packet ibc.Packet) (uint64, ibc.PacketTimeout, error) {
+	var found ibc.PacketTimeout
+	h, err := pollHeight(ctx, t.Src, sendHeight, t.StageBlocks, func(ctx context.Context, height uint64) (bool, error) {
+		timeouts, err := t.Src.Timeouts(ctx, height)
+		if err != nil {
+			return false, err
+		}
+		for _, timeout := range timeouts {
+			if timeout.Packet.Equal(packet) {
+				found = timeout
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	return h, found, err
+}
+
+// pollHeight returns the first height from start at which match reports true,
+// searching up to stageBlocks blocks past the chain's current height.
+func pollHeight(ctx context.Context, chain ChainHeighter, start, stageBlocks uint64, match func(ctx context.Context, height uint64) (bool, error)) (uint64, error) {
+	cur, err := chain.Height(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if start > cur {
+		start = cur
+	}
+	watcher, release := watchHeight(chain)
+	defer release()
+	poller := BlockPoller[uint64]{
+		CurrentHeight: chain.Height,
+		Watcher:       watcher,
+		PollFunc: func(ctx context.Context, height uint64) (uint64, error) {
+			ok, err := match(ctx, height)
+			if err != nil {
+				return 0, err
+			}
+			if !ok {
+				return 0, ErrNotFound
+			}
+			return height, nil
+		},
+	}
+	return poller.DoPoll(ctx, start, cur+stageBlocks)
+}
@@ -0,0 +1,177 @@
+package testutil
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"testing"
+	"time"
+
+	abcitypes "github.com/cometbft/cometbft/abci/types"
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	"github.com/decentrio/e2e-testing-live/cosmos"
+	"github.com/decentrio/rollup-e2e-testing/ibc"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeChain is a TrackedChain whose blocks are all produced already. Trackers
+// using it search no further than the current height (StageBlocks is zero).
+type fakeChain struct {
+	height    uint64
+	recvs     map[uint64][]ibc.Packet
+	writeAcks map[uint64][]ibc.PacketAcknowledgement
+	acks      map[uint64][]ibc.PacketAcknowledgement
+	timeouts  map[uint64][]ibc.PacketTimeout
+}
+
+var genesis = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func (c *fakeChain) Height(ctx context.Context) (uint64, error) { return c.height, ctx.Err() }
+
+func (c *fakeChain) BlockTime(_ context.Context, h uint64) (time.Time, error) {
+	return genesis.Add(time.Duration(h) * time.Second), nil
+}
+
+func (c *fakeChain) RecvPackets(_ context.Context, h uint64) ([]ibc.Packet, error) {
+	return c.recvs[h], nil
+}
+
+func (c *fakeChain) WriteAcknowledgements(_ context.Context, h uint64) ([]ibc.PacketAcknowledgement, error) {
+	return c.writeAcks[h], nil
+}
+
+func (c *fakeChain) Acknowledgements(_ context.Context, _ codectypes.InterfaceRegistry, h uint64) ([]ibc.PacketAcknowledgement, error) {
+	return c.acks[h], nil
+}
+
+func (c *fakeChain) Timeouts(_ context.Context, h uint64) ([]ibc.PacketTimeout, error) {
+	return c.timeouts[h], nil
+}
+
+func sendPacketTx(height int64, p ibc.Packet) cosmos.TxResponse {
+	attr := func(k, v string) abcitypes.EventAttribute {
+		return abcitypes.EventAttribute{
+			Key:   base64.StdEncoding.EncodeToString([]byte(k)),
+			Value: base64.StdEncoding.EncodeToString([]byte(v)),
+		}
+	}
+	return cosmos.TxResponse{
+		Height:    fmt.Sprint(height),
+		TxHash:    "AB",
+		GasWanted: "1",
+		Events: []abcitypes.Event{{Type: "send_packet", Attributes: []abcitypes.EventAttribute{
+			attr("packet_sequence", fmt.Sprint(p.Sequence)),
+			attr("packet_src_port", p.SourcePort),
+			attr("packet_src_channel", p.SourceChannel),
+			attr("packet_dst_port", p.DestPort),
+			attr("packet_dst_channel", p.DestChannel),
+			attr("packet_timeout_height", p.TimeoutHeight),
+			attr("packet_timeout_timestamp", fmt.Sprint(p.TimeoutTimestamp)),
+			attr("packet_data", string(p.Data)),
+		}}},
+	}
+}
+
+var trackedPacket = ibc.Packet{
+	Sequence:         3,
+	SourcePort:       "transfer",
+	SourceChannel:    "channel-1",
+	DestPort:         "transfer",
+	DestChannel:      "channel-0",
+	Data:             []byte(`{"amount":"1"}`),
+	TimeoutHeight:    "0-0",
+	TimeoutTimestamp: 99,
+}
+
+func TestPacketTrackerAcked(t *testing.T) {
+	ctx := context.Background()
+	src := &fakeChain{height: 30, acks: map[uint64][]ibc.PacketAcknowledgement{
+		25: {{Packet: trackedPacket, Acknowledgement: []byte(`{"result":"AQ=="}`)}},
+	}}
+	dst := &fakeChain{height: 5}
+	tracker, err := NewPacketTracker(ctx, src, dst)
+	require.NoError(t, err)
+	tracker.StageBlocks = 0
+
+	dst.height = 60
+	dst.recvs = map[uint64][]ibc.Packet{7: {trackedPacket}}
+	dst.writeAcks = map[uint64][]ibc.PacketAcknowledgement{
+		50: {{Packet: trackedPacket, Acknowledgement: []byte(`{"result":"AQ=="}`)}},
+	}
+
+	res, err := tracker.Track(ctx, sendPacketTx(20, trackedPacket))
+	require.NoError(t, err)
+	require.True(t, res.Acked())
+	require.False(t, res.TimedOut)
+	require.Equal(t, uint64(5), res.SourceBlocks())
+
+	var stages []PacketStage
+	for _, s := range res.Stages {
+		stages = append(stages, s.Stage)
+	}
+	require.Equal(t, []PacketStage{StageSend, StageRecv, StageWriteAck, StageAck}, stages)
+
+	writeAck, ok := res.Stage(StageWriteAck)
+	require.True(t, ok)
+	require.Equal(t, uint64(50), writeAck.Height)
+	require.Equal(t, 43*time.Second, writeAck.Latency)
+	require.Equal(t, 5*time.Second, res.Latency())
+}
+
+func TestPacketTrackerErrorAck(t *testing.T) {
+	ctx := context.Background()
+	errAck := []byte(`{"error":"insufficient funds"}`)
+	src := &fakeChain{height: 30, acks: map[uint64][]ibc.PacketAcknowledgement{
+		22: {{Packet: trackedPacket, Acknowledgement: errAck}},
+	}}
+	dst := &fakeChain{
+		height:    10,
+		recvs:     map[uint64][]ibc.Packet{10: {trackedPacket}},
+		writeAcks: map[uint64][]ibc.PacketAcknowledgement{10: {{Packet: trackedPacket, Acknowledgement: errAck}}},
+	}
+	tracker, err := NewPacketTracker(ctx, src, dst)
+	require.NoError(t, err)
+	tracker.StageBlocks = 0
+
+	res, err := tracker.Track(ctx, sendPacketTx(20, trackedPacket))
+	require.NoError(t, err)
+	require.False(t, res.Acked())
+	require.Equal(t, "insufficient funds", res.AckError)
+}
+
+func TestPacketTrackerTimeout(t *testing.T) {
+	ctx := context.Background()
+	src := &fakeChain{height: 40, timeouts: map[uint64][]ibc.PacketTimeout{
+		31: {{Packet: trackedPacket}},
+	}}
+	dst := &fakeChain{height: 10}
+	tracker, err := NewPacketTracker(ctx, src, dst)
+	require.NoError(t, err)
+	tracker.StageBlocks = 0
+
+	res, err := tracker.Track(ctx, sendPacketTx(20, trackedPacket))
+	require.NoError(t, err)
+	require.True(t, res.TimedOut)
+	require.False(t, res.Acked())
+	require.Equal(t, uint64(11), res.SourceBlocks())
+}
+
+func TestPacketTrackerTimeoutStopsRecvSearch(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	src := &fakeChain{height: 40, timeouts: map[uint64][]ibc.PacketTimeout{
+		31: {{Packet: trackedPacket}},
+	}}
+	// The destination never produces the blocks the receipt would be
+	// searched in.
+	dst := &fakeChain{height: 10}
+	tracker, err := NewPacketTracker(ctx, src, dst)
+	require.NoError(t, err)
+	tracker.StageBlocks = 1000
+
+	start := time.Now()
+	res, err := tracker.Track(ctx, sendPacketTx(20, trackedPacket))
+	require.NoError(t, err)
+	require.True(t, res.TimedOut)
+	require.Less(t, time.Since(start), 2*time.Second, "the receipt search ends with the timeout")
+}
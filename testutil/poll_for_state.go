@@ -1,4 +1,3 @@
-
 package testutil
 
 import (
@@ -7,18 +6,28 @@ import (
 	"fmt"
 	"strings"
 
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
 	"github.com/davecgh/go-spew/spew"
+	"github.com/decentrio/e2e-testing-live/cosmos"
 	"github.com/decentrio/rollup-e2e-testing/ibc"
-	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
 )
 
 var ErrNotFound = errors.New("not found")
 
+// BlockPoller calls PollFunc on each height of a range, waiting for the
+// chain to produce the heights it does not have yet.
 type BlockPoller[T any] struct {
 	CurrentHeight func(ctx context.Context) (uint64, error)
 	PollFunc      func(ctx context.Context, height uint64) (T, error)
+	// Watcher, if set, is waited on for new heights instead of polling
+	// CurrentHeight every cosmos.HeightPollInterval.
+	Watcher *cosmos.HeightWatcher
 }
 
+// DoPoll returns the result of the first height from startHeight to
+// maxHeight that PollFunc does not fail on, or the last failure. It fails
+// with a *cosmos.ChainStalledError if the chain stops producing blocks
+// before maxHeight.
 func (p BlockPoller[T]) DoPoll(ctx context.Context, startHeight, maxHeight uint64) (T, error) {
 	if maxHeight < startHeight {
 		panic("maxHeight must be greater than or equal to startHeight")
@@ -29,21 +38,19 @@ func (p BlockPoller[T]) DoPoll(ctx context.Context, startHeight, maxHeight uint6
 		zero    T
 	)
 
-	cursor := startHeight
-	for cursor <= maxHeight {
-		curHeight, err := p.CurrentHeight(ctx)
-		if err != nil {
+	watcher := p.Watcher
+	if watcher == nil {
+		watcher = cosmos.NewHeightWatcher("", p.CurrentHeight, nil)
+		defer watcher.Stop()
+	}
+	for cursor := startHeight; cursor <= maxHeight; cursor++ {
+		if _, err := watcher.WaitForHeight(ctx, cursor, 0); err != nil {
 			return zero, err
 		}
-		if cursor > curHeight {
-			continue
-		}
 
 		found, findErr := p.PollFunc(ctx, cursor)
-
 		if findErr != nil {
 			pollErr = findErr
-			cursor++
 			continue
 		}
 
@@ -79,7 +86,9 @@ func PollForAck(ctx context.Context, chain ChainAcker, interfaceRegistry codecty
 		return zero, ErrNotFound
 	}
 
-	poller := BlockPoller[ibc.PacketAcknowledgement]{CurrentHeight: chain.Height, PollFunc: poll}
+	watcher, release := watchHeight(chain)
+	defer release()
+	poller := BlockPoller[ibc.PacketAcknowledgement]{CurrentHeight: chain.Height, PollFunc: poll, Watcher: watcher}
 	found, err := poller.DoPoll(ctx, startHeight, maxHeight)
 	if err != nil {
 		pollError.SetErr(err)
@@ -113,7 +122,45 @@ func PollForTimeout(ctx context.Context, chain ChainTimeouter, startHeight, maxH
 		return zero, ErrNotFound
 	}
 
-	poller := BlockPoller[ibc.PacketTimeout]{CurrentHeight: chain.Height, PollFunc: poll}
+	watcher, release := watchHeight(chain)
+	defer release()
+	poller := BlockPoller[ibc.PacketTimeout]{CurrentHeight: chain.Height, PollFunc: poll, Watcher: watcher}
+	found, err := poller.DoPoll(ctx, startHeight, maxHeight)
+	if err != nil {
+		pollError.SetErr(err)
+		return zero, pollError
+	}
+	return found, nil
+}
+
+// ChainRecver is a chain that can get the packets it received at a specified height
+type ChainRecver interface {
+	ChainHeighter
+	RecvPackets(ctx context.Context, height uint64) ([]ibc.Packet, error)
+}
+
+// PollForRecv attempts to find a received packet equal to the packet argument on the counterparty chain.
+// Otherwise, works identically to PollForAck.
+func PollForRecv(ctx context.Context, chain ChainRecver, startHeight, maxHeight uint64, packet ibc.Packet) (ibc.Packet, error) {
+	pollError := &packetPollError{targetPacket: packet}
+	var zero ibc.Packet
+	poll := func(ctx context.Context, height uint64) (ibc.Packet, error) {
+		packets, err := chain.RecvPackets(ctx, height)
+		if err != nil {
+			return zero, err
+		}
+		for _, p := range packets {
+			pollError.PushSearched(p)
+			if p.Equal(packet) {
+				return p, nil
+			}
+		}
+		return zero, ErrNotFound
+	}
+
+	watcher, release := watchHeight(chain)
+	defer release()
+	poller := BlockPoller[ibc.Packet]{CurrentHeight: chain.Height, PollFunc: poll, Watcher: watcher}
 	found, err := poller.DoPoll(ctx, startHeight, maxHeight)
 	if err != nil {
 		pollError.SetErr(err)
@@ -155,3 +202,9 @@ func (pe *packetPollError) Format(s fmt.State, verb rune) {
 	final := fmt.Sprintf("%s\n- target packet:\n%s\n- searched:\n%+v", pe.error, target, searched)
 	fmt.Fprint(s, final)
 }
+
+var (
+	_ ChainAcker     = cosmos.CosmosChain{}
+	_ ChainTimeouter = cosmos.CosmosChain{}
+	_ ChainRecver    = cosmos.CosmosChain{}
+)
@@ -2,8 +2,11 @@ package testutil
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/decentrio/e2e-testing-live/cosmos"
+	"golang.org/x/sync/errgroup"
 )
 
 // ChainHeighter fetches the current chain block height.
@@ -11,47 +14,114 @@ type ChainHeighter interface {
 	Height(ctx context.Context) (uint64, error)
 }
 
-// WaitForBlocks blocks until all chains reach a block height delta equal to or greater than the delta argument.
-// If a ChainHeighter does not monotonically increase the height, this function may block program execution indefinitely.
-func WaitForBlocks(ctx context.Context, delta int, chain cosmos.CosmosChain) error {
-	h := &height{Chain: chain}
-	return h.WaitForDelta(ctx, delta)
+// ChainBlockTimer is a chain that can get the time of its blocks.
+type ChainBlockTimer interface {
+	ChainHeighter
+	BlockTime(ctx context.Context, height uint64) (time.Time, error)
+}
 
+// heightWatcherChain is a chain with a shared height watcher, such as
+// cosmos.CosmosChain and the chains embedding it.
+type heightWatcherChain interface {
+	WatchHeight() (*cosmos.HeightWatcher, func())
 }
 
-type height struct {
-	Chain cosmos.CosmosChain
+// watchHeight returns the shared height watcher of chain if it has one, a
+// watcher polling its height otherwise. release stops using it.
+func watchHeight(chain ChainHeighter) (w *cosmos.HeightWatcher, release func()) {
+	if wc, ok := chain.(heightWatcherChain); ok {
+		return wc.WatchHeight()
+	}
+	w = cosmos.NewHeightWatcher(fmt.Sprintf("%T", chain), chain.Height, nil)
+	return w, w.Stop
+}
 
-	starting uint64
-	current  uint64
+// WaitForBlocks blocks until every chain is delta blocks past the first
+// height seen on it, waiting on the chains concurrently. It fails as soon as
+// one of them does, with an error naming it, e.g. a *cosmos.ChainStalledError
+// if it stops producing blocks.
+func WaitForBlocks(ctx context.Context, delta int, chains ...ChainHeighter) error {
+	return WaitForBlocksWithin(ctx, 0, delta, chains...)
 }
 
-func (h *height) WaitForDelta(ctx context.Context, delta int) error {
-	for h.delta() < delta {
-		cur, err := h.Chain.Height(ctx)
-		if err != nil {
+// WaitForBlocksWithin is WaitForBlocks failing once timeout elapses, unless
+// it is zero. A timeout longer than cosmos.StallTimeout replaces the stall
+// check, giving idle chains, such as rollapps producing blocks on demand,
+// that long to advance.
+func WaitForBlocksWithin(ctx context.Context, timeout time.Duration, delta int, chains ...ChainHeighter) error {
+	deadline := time.Now().Add(timeout)
+	eg, ctx := errgroup.WithContext(ctx)
+	for _, chain := range chains {
+		chain := chain
+		eg.Go(func() error {
+			w, release := watchHeight(chain)
+			defer release()
+			start, err := w.WaitForHeight(ctx, 1, remaining(deadline, timeout))
+			if err != nil {
+				return err
+			}
+			_, err = w.WaitForHeight(ctx, start+uint64(delta), remaining(deadline, timeout))
 			return err
+		})
+	}
+	return eg.Wait()
+}
+
+// remaining is the time left until deadline, or zero if timeout is, rounded
+// to the millisecond so errors read like the timeout given.
+func remaining(deadline time.Time, timeout time.Duration) time.Duration {
+	if timeout <= 0 {
+		return 0
+	}
+	if left := time.Until(deadline).Round(time.Millisecond); left > 0 {
+		return left
+	}
+	return time.Millisecond
+}
+
+// WaitForHeight blocks until chain reaches height and returns the height seen
+// then. The optional timeout bounds the wait; errors name the chain.
+func WaitForHeight(ctx context.Context, chain ChainHeighter, height uint64, timeout ...time.Duration) (uint64, error) {
+	w, release := watchHeight(chain)
+	defer release()
+	return w.WaitForHeight(ctx, height, firstTimeout(timeout))
+}
+
+// WaitForBlockTime blocks until chain commits a block at or after t and
+// returns its height. The optional timeout bounds the wait; errors name the
+// chain.
+func WaitForBlockTime(ctx context.Context, chain ChainBlockTimer, t time.Time, timeout ...time.Duration) (uint64, error) {
+	ctx, cancel := withTimeout(ctx, firstTimeout(timeout))
+	defer cancel()
+	w, release := watchHeight(chain)
+	defer release()
+	for next := uint64(1); ; {
+		height, err := w.WaitForHeight(ctx, next, 0)
+		if err != nil {
+			return height, err
+		}
+		blockTime, err := chain.BlockTime(ctx, height)
+		if err != nil {
+			return height, fmt.Errorf("time of block %d: %w", height, err)
 		}
-		// We assume the chain will eventually return a non-zero height, otherwise
-		// this may block indefinitely.
-		if cur == 0 {
-			continue
+		if !blockTime.Before(t) {
+			return height, nil
 		}
-		h.update(cur)
+		next = height + 1
 	}
-	return nil
 }
 
-func (h *height) delta() int {
-	if h.starting == 0 {
+func firstTimeout(timeout []time.Duration) time.Duration {
+	if len(timeout) == 0 {
 		return 0
 	}
-	return int(h.current - h.starting)
+	return timeout[0]
 }
 
-func (h *height) update(height uint64) {
-	if h.starting == 0 {
-		h.starting = height
+// withTimeout bounds ctx by timeout, unless it is zero.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
 	}
-	h.current = height
+	return context.WithTimeout(ctx, timeout)
 }
@@ -0,0 +1,98 @@
+package testutil
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/decentrio/e2e-testing-live/cosmos"
+	"github.com/stretchr/testify/require"
+)
+
+// tickingChain produces a block every interval, or none if interval is zero,
+// its blocks being a second apart from genesis.
+type tickingChain struct {
+	height atomic.Uint64
+}
+
+func newTickingChain(t *testing.T, height uint64, interval time.Duration) *tickingChain {
+	c := &tickingChain{}
+	c.height.Store(height)
+	if interval == 0 {
+		return c
+	}
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				c.height.Add(1)
+			case <-done:
+				return
+			}
+		}
+	}()
+	t.Cleanup(func() {
+		ticker.Stop()
+		close(done)
+	})
+	return c
+}
+
+func (c *tickingChain) Height(ctx context.Context) (uint64, error) { return c.height.Load(), ctx.Err() }
+
+func (c *tickingChain) BlockTime(_ context.Context, h uint64) (time.Time, error) {
+	return genesis.Add(time.Duration(h) * time.Second), nil
+}
+
+func TestWaitForBlocks(t *testing.T) {
+	defer func(interval, stall time.Duration) {
+		cosmos.HeightPollInterval, cosmos.StallTimeout = interval, stall
+	}(cosmos.HeightPollInterval, cosmos.StallTimeout)
+	cosmos.HeightPollInterval = time.Millisecond
+	cosmos.StallTimeout = 100 * time.Millisecond
+
+	ctx := context.Background()
+	fast := newTickingChain(t, 10, 2*time.Millisecond)
+	slow := newTickingChain(t, 500, 10*time.Millisecond)
+	require.NoError(t, WaitForBlocks(ctx, 3, fast, slow))
+	require.GreaterOrEqual(t, slow.height.Load(), uint64(503))
+
+	stalled := newTickingChain(t, 7, 0)
+	err := WaitForBlocks(ctx, 3, fast, stalled)
+	var stallErr *cosmos.ChainStalledError
+	require.ErrorAs(t, err, &stallErr)
+	require.Equal(t, "*testutil.tickingChain", stallErr.ChainID)
+	require.Equal(t, uint64(7), stallErr.Height)
+
+	cosmos.StallTimeout = time.Minute
+	err = WaitForBlocksWithin(ctx, 30*time.Millisecond, 3, stalled)
+	require.ErrorContains(t, err, "chain *testutil.tickingChain did not reach height 10 within")
+
+	// An explicit timeout longer than StallTimeout bounds the wait instead.
+	cosmos.StallTimeout = 20 * time.Millisecond
+	err = WaitForBlocksWithin(ctx, 150*time.Millisecond, 3, stalled)
+	require.False(t, errors.As(err, &stallErr), "stalled after %v", err)
+	require.ErrorContains(t, err, "did not reach height 10 within")
+}
+
+func TestWaitForHeightAndBlockTime(t *testing.T) {
+	defer func(interval time.Duration) { cosmos.HeightPollInterval = interval }(cosmos.HeightPollInterval)
+	cosmos.HeightPollInterval = time.Millisecond
+
+	ctx := context.Background()
+	chain := newTickingChain(t, 1, 2*time.Millisecond)
+	h, err := WaitForHeight(ctx, chain, 5)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, h, uint64(5))
+
+	h, err = WaitForBlockTime(ctx, chain, genesis.Add(12*time.Second), 5*time.Second)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, h, uint64(12))
+
+	_, err = WaitForHeight(ctx, newTickingChain(t, 1, 0), 2, 20*time.Millisecond)
+	require.EqualError(t, err, "chain *testutil.tickingChain did not reach height 2 within 20ms (height 1)")
+}